@@ -8,6 +8,7 @@ import (
 // particular task (invocation), to go with all outgoing IPs from that task
 type AuditInfo struct {
 	ID          string
+	TaskID      string
 	ProcessName string
 	Command     string
 	Params      map[string]string
@@ -17,6 +18,7 @@ type AuditInfo struct {
 	ExecTimeNS  time.Duration
 	OutFiles    map[string]string
 	Upstream    map[string]*AuditInfo
+	Env         map[string]string `json:",omitempty"`
 }
 
 // NewAuditInfo returns a new AuditInfo struct
@@ -0,0 +1,169 @@
+package scipipe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Audit report
+// ----------------------------------------------------------------------------
+
+// AuditReport is a machine-readable provenance document for a workflow run,
+// as written to the path set with Workflow.SetAuditFilePath
+type AuditReport struct {
+	Workflow string
+	Tasks    []AuditReportTask
+	// Edges are the dependencies between the tasks, reconstructed from the
+	// outputs of tasks being inputs of other tasks
+	Edges []AuditReportEdge
+}
+
+// AuditReportTask contains the audit information of an executed task
+type AuditReportTask struct {
+	TaskID     string
+	Process    string
+	Command    string
+	Params     map[string]string
+	InFiles    []AuditReportFile
+	OutFiles   []AuditReportFile
+	StartTime  time.Time
+	FinishTime time.Time
+	ExitCode   int
+	Host       string
+}
+
+// AuditReportFile contains the audit information of an input or output file
+// of a task. Size is -1, and SHA256 empty, for files that could not be read,
+// such as streamed (FIFO) files. Only the size is recorded for directories.
+type AuditReportFile struct {
+	Port   string
+	Path   string
+	Size   int64
+	SHA256 string `json:",omitempty"`
+}
+
+// AuditReportEdge is a dependency between two tasks, where the output at Path
+// of the task FromTask is an input of the task ToTask
+type AuditReportEdge struct {
+	FromTask string
+	ToTask   string
+	Path     string
+}
+
+// SetAuditFilePath makes the workflow write an audit report as JSON to the
+// file at path (see AuditReport) when it has finished running, with the
+// command, parameters, input and output files (with sizes and hashes), start
+// and finish times, exit code and host of every task executed in the run.
+// Tasks skipped because their outputs already existed are not included.
+func (wf *Workflow) SetAuditFilePath(path string) {
+	wf.auditFilePath = path
+}
+
+// addAuditReportTask records the audit information of the executed task t,
+// with the given start and finish times, if an audit report should be written
+func (wf *Workflow) addAuditReportTask(t *Task, startTime time.Time, finishTime time.Time) {
+	if wf == nil || wf.auditFilePath == "" {
+		return
+	}
+	host, err := os.Hostname()
+	if err != nil {
+		wf.WarningLogger().Printf("| %-32s | Could not get host name for audit report: %s\n", t.Name, err.Error())
+	}
+	art := AuditReportTask{
+		TaskID:     t.ID(),
+		Process:    t.Name,
+		Command:    t.Command,
+		Params:     t.Params,
+		InFiles:    []AuditReportFile{},
+		OutFiles:   []AuditReportFile{},
+		StartTime:  startTime,
+		FinishTime: finishTime,
+		ExitCode:   t.exitCode,
+		Host:       host,
+	}
+	if t.Process != nil {
+		art.Process = t.Process.Name()
+	}
+	for _, ipName := range sortedFileIPMapKeys(t.InIPs) {
+		if subIPs, ok := t.subStreamIPs[ipName]; ok {
+			for _, subIP := range subIPs {
+				art.InFiles = append(art.InFiles, auditReportFile(ipName, subIP))
+			}
+			continue
+		}
+		art.InFiles = append(art.InFiles, auditReportFile(ipName, t.InIPs[ipName]))
+	}
+	for _, oipName := range sortedFileIPMapKeys(t.OutIPs) {
+		art.OutFiles = append(art.OutFiles, auditReportFile(oipName, t.OutIPs[oipName]))
+	}
+	wf.auditTasksMx.Lock()
+	wf.auditTasks = append(wf.auditTasks, art)
+	wf.auditTasksMx.Unlock()
+}
+
+// auditReportFile returns the audit information of the file of ip, sent on
+// the port named portName
+func auditReportFile(portName string, ip *FileIP) AuditReportFile {
+	arf := AuditReportFile{Port: portName, Path: ip.Path(), Size: -1}
+	if ip.doStream {
+		return arf
+	}
+	fi, err := os.Stat(ip.Path())
+	if err != nil {
+		return arf
+	}
+	arf.Size = fi.Size()
+	if fi.Mode().IsRegular() {
+		if hash, err := fileHash(ip.Path()); err == nil {
+			arf.SHA256 = hash
+		}
+	}
+	return arf
+}
+
+// AuditReport returns the audit report of the tasks executed so far, if an
+// audit file path has been set with SetAuditFilePath
+func (wf *Workflow) AuditReport() AuditReport {
+	wf.auditTasksMx.Lock()
+	defer wf.auditTasksMx.Unlock()
+	report := AuditReport{
+		Workflow: wf.Name(),
+		Tasks:    make([]AuditReportTask, len(wf.auditTasks)),
+		Edges:    []AuditReportEdge{},
+	}
+	copy(report.Tasks, wf.auditTasks)
+
+	producers := map[string]string{}
+	for _, art := range report.Tasks {
+		for _, arf := range art.OutFiles {
+			producers[arf.Path] = art.TaskID
+		}
+	}
+	for _, art := range report.Tasks {
+		for _, arf := range art.InFiles {
+			if fromTask, ok := producers[arf.Path]; ok {
+				report.Edges = append(report.Edges, AuditReportEdge{FromTask: fromTask, ToTask: art.TaskID, Path: arf.Path})
+			}
+		}
+	}
+	return report
+}
+
+// writeAuditReport writes the audit report of the workflow to the path set
+// with SetAuditFilePath, if any
+func (wf *Workflow) writeAuditReport() {
+	if wf.auditFilePath == "" {
+		return
+	}
+	reportJSON, err := json.MarshalIndent(wf.AuditReport(), "", "    ")
+	CheckWithMsg(err, "Could not marshal audit report of workflow "+wf.Name())
+	err = os.MkdirAll(filepath.Dir(wf.auditFilePath), 0777)
+	CheckWithMsg(err, "Could not create directory for audit report: "+wf.auditFilePath)
+	err = ioutil.WriteFile(wf.auditFilePath, reportJSON, 0644)
+	CheckWithMsg(err, "Could not write audit report: "+wf.auditFilePath)
+	wf.Logger().Printf("| workflow:%-23s | Wrote audit report to %s", wf.Name(), wf.auditFilePath)
+}
@@ -0,0 +1,57 @@
+package scipipe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+)
+
+func TestSetAuditFilePath(t *testing.T) {
+	initTestLogs()
+	reportPath := "/tmp/audit_report_test.json"
+	defer cleanFiles(reportPath, "/tmp/audit_report_test_foo.txt", "/tmp/audit_report_test_foo.txt.bar.txt")
+
+	wf := NewWorkflow("test_wf", 4)
+	wf.SetAuditFilePath(reportPath)
+	foo := wf.NewProc("foo", "echo foo > {o:out}")
+	foo.SetOut("out", "/tmp/audit_report_test_foo.txt")
+	bar := wf.NewProc("bar", "sed 's/foo/bar/' {i:in} > {o:out}")
+	bar.In("in").From(foo.Out("out"))
+	bar.SetOut("out", "{i:in}.bar.txt")
+	wf.Run()
+
+	reportJSON, err := ioutil.ReadFile(reportPath)
+	if err != nil {
+		t.Fatalf("Could not read audit report %s: %s", reportPath, err.Error())
+	}
+	report := AuditReport{}
+	err = json.Unmarshal(reportJSON, &report)
+	Check(err)
+
+	if len(report.Tasks) != 2 {
+		t.Fatalf("Expected 2 tasks in audit report, got %d", len(report.Tasks))
+	}
+	tasks := map[string]AuditReportTask{}
+	for _, art := range report.Tasks {
+		tasks[art.Process] = art
+	}
+	fooOut := tasks["foo"].OutFiles[0]
+	if fooOut.Path != "/tmp/audit_report_test_foo.txt" || fooOut.Size != 4 {
+		t.Errorf("Expected output of foo with path /tmp/audit_report_test_foo.txt and size 4, got %v", fooOut)
+	}
+	// sha256 of "foo\n"
+	if fooOut.SHA256 != "b5bb9d8014a0f9b1d61e21e796d78dccdf1352f23cd32812f4850b878ae4944c" {
+		t.Errorf("Unexpected hash of output of foo: %s", fooOut.SHA256)
+	}
+	if tasks["bar"].InFiles[0].Path != fooOut.Path {
+		t.Errorf("Expected input of bar to be %s, got %s", fooOut.Path, tasks["bar"].InFiles[0].Path)
+	}
+	if tasks["bar"].ExitCode != 0 || tasks["bar"].Host == "" || tasks["bar"].StartTime.IsZero() {
+		t.Errorf("Expected exit code 0, host and start time to be recorded for bar, got %v", tasks["bar"])
+	}
+
+	expectedEdge := AuditReportEdge{FromTask: tasks["foo"].TaskID, ToTask: tasks["bar"].TaskID, Path: fooOut.Path}
+	if len(report.Edges) != 1 || report.Edges[0] != expectedEdge {
+		t.Errorf("Expected edges %v, got %v", []AuditReportEdge{expectedEdge}, report.Edges)
+	}
+}
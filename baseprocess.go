@@ -1,5 +1,7 @@
 package scipipe
 
+import "sync"
+
 // BaseProcess provides a skeleton for processes, such as the main Process
 // component, and the custom components in the scipipe/components library
 type BaseProcess struct {
@@ -187,7 +189,7 @@ func (p *BaseProcess) DeleteOutParamPort(portName string) {
 func (p *BaseProcess) Ready() (isReady bool) {
 	isReady = true
 	for portName, port := range p.inPorts {
-		if !port.Ready() {
+		if !port.Ready() && !port.Optional() {
 			Error.Printf("InPort %s of process %s is not connected - check your workflow code!\n", portName, p.name)
 			isReady = false
 		}
@@ -233,18 +235,23 @@ func (p *BaseProcess) CloseAllOutPorts() {
 	p.CloseOutParamPorts()
 }
 
-func (p *BaseProcess) receiveOnInPorts() (ips map[string]*FileIP, inPortsOpen bool) {
+// receiveOnInPorts receives one IP on each of the in-ports, except for the
+// ones in skipPorts
+func (p *BaseProcess) receiveOnInPorts(skipPorts map[string]bool) (ips map[string]*FileIP, inPortsOpen bool) {
 	inPortsOpen = true
 	ips = make(map[string]*FileIP)
 	// Read input IPs on in-ports and set up path mappings
 	for inpName, inPort := range p.InPorts() {
-		Debug.Printf("Process %s: Receieving on inPort %s ...", p.name, inpName)
+		if skipPorts[inpName] {
+			continue
+		}
+		p.workflow.DebugLogger().Printf("Process %s: Receieving on inPort %s ...", p.name, inpName)
 		ip, open := <-inPort.Chan
 		if !open {
 			inPortsOpen = false
 			continue
 		}
-		Debug.Printf("Process %s: Got ip %s ...", p.name, ip.Path())
+		p.workflow.DebugLogger().Printf("Process %s: Got ip %s ...", p.name, ip.Path())
 		ips[inpName] = ip
 	}
 	return
@@ -260,8 +267,32 @@ func (p *BaseProcess) receiveOnInParamPorts() (params map[string]string, paramPo
 			paramPortsOpen = false
 			continue
 		}
-		Debug.Printf("Process %s: Got param %s ...", p.name, pval)
+		p.workflow.DebugLogger().Printf("Process %s: Got param %s ...", p.name, pval)
 		params[pname] = pval
 	}
 	return
 }
+
+// drainInPorts receives and discards everything sent on the in-ports and
+// param in-ports of the process, until they are closed, so that upstream
+// processes are not blocked when the process stops consuming its inputs early
+func (p *BaseProcess) drainInPorts() {
+	wg := sync.WaitGroup{}
+	for _, inPort := range p.InPorts() {
+		wg.Add(1)
+		go func(inPort *InPort) {
+			defer wg.Done()
+			for range inPort.Chan {
+			}
+		}(inPort)
+	}
+	for _, pport := range p.InParamPorts() {
+		wg.Add(1)
+		go func(pport *InParamPort) {
+			defer wg.Done()
+			for range pport.Chan {
+			}
+		}(pport)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,184 @@
+package scipipe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Task caching
+// ----------------------------------------------------------------------------
+
+// CacheMode decides how tasks whose outputs already exist are checked for
+// being up to date, before they are skipped
+type CacheMode int
+
+const (
+	// CacheModeOff skips tasks whenever any of their outputs exist, which is
+	// the default
+	CacheModeOff CacheMode = iota
+	// CacheModeMtime skips tasks only if their command, parameters, and the
+	// modification times of their inputs, are the same as when their outputs
+	// were produced
+	CacheModeMtime
+	// CacheModeContent skips tasks only if their command, parameters, and the
+	// content of their inputs, are the same as when their outputs were
+	// produced. This is stricter than CacheModeMtime, but inputs have to be
+	// hashed, which takes time for large files.
+	CacheModeContent
+)
+
+// cacheRecordExt is the extension of the sidecar files, written next to each
+// output, in which the cache key of the task producing it is recorded
+const cacheRecordExt = ".scipipe.json"
+
+// cacheRecord is the content of the cache record sidecar file of an output
+type cacheRecord struct {
+	TaskID   string
+	CacheKey string
+}
+
+// SetCacheMode sets how tasks whose outputs already exist are checked for being
+// up to date (see CacheMode). With caching enabled, a cache key, computed from
+// the command and parameters of a task and the state of its inputs, is
+// recorded in a sidecar file next to each of its outputs. Tasks are then
+// skipped only if the keys recorded for all outputs match, and otherwise
+// re-run, replacing their outputs. Outputs lacking sidecar files, such as ones
+// produced before caching was enabled, are thus re-created. Tasks streaming
+// any inputs or outputs via FIFO files are never cached.
+func (wf *Workflow) SetCacheMode(mode CacheMode) {
+	wf.cacheMode = mode
+}
+
+// cachingEnabled tells whether the task should be skipped based on its cache
+// key, rather than on the existence of its outputs
+func (t *Task) cachingEnabled() bool {
+	if t.workflow == nil || t.workflow.cacheMode == CacheModeOff {
+		return false
+	}
+	for _, ip := range t.InIPs {
+		if ip.doStream {
+			return false
+		}
+	}
+	for _, oip := range t.OutIPs {
+		if oip.doStream {
+			return false
+		}
+	}
+	return true
+}
+
+// cacheHit tells whether all outputs of the task exist, with cache records
+// matching the current cache key of the task. The key is kept on the task, for
+// recording it if the task is executed.
+func (t *Task) cacheHit() bool {
+	cacheKey, err := t.computeCacheKey()
+	if err != nil {
+		t.workflow.WarningLogger().Printf("| %-32s | Could not compute cache key, so re-running task %s: %s\n", t.Name, t.ID(), err.Error())
+		return false
+	}
+	t.cacheKey = cacheKey
+	for _, oip := range t.OutIPs {
+		if _, err := os.Stat(oip.Path()); err != nil {
+			return false
+		}
+		record, err := readCacheRecord(oip.Path() + cacheRecordExt)
+		if err != nil || record.CacheKey != cacheKey {
+			t.workflow.Logger().Printf("| %-32s | Cache key of output differs, so re-running task %s: %s\n", t.Name, t.ID(), oip.Path())
+			return false
+		}
+	}
+	return len(t.OutIPs) > 0
+}
+
+// computeCacheKey returns a hash of the command and parameters of the task,
+// and of the modification times or content of its inputs, depending on the
+// cache mode of the workflow
+func (t *Task) computeCacheKey() (string, error) {
+	keyPcs := []string{fmt.Sprintf("mode_%d", t.workflow.cacheMode), "cmd_" + t.Command}
+	for _, paramName := range sortedStringMapKeys(t.Params) {
+		keyPcs = append(keyPcs, "param_"+paramName+"_"+t.Params[paramName])
+	}
+	inPaths := []string{}
+	for _, ipName := range sortedFileIPMapKeys(t.InIPs) {
+		if _, ok := t.subStreamIPs[ipName]; ok {
+			for _, subIP := range t.subStreamIPs[ipName] {
+				inPaths = append(inPaths, subIP.Path())
+			}
+			continue
+		}
+		inPaths = append(inPaths, t.InIPs[ipName].Path())
+	}
+	for _, inPath := range inPaths {
+		inState, err := t.inputCacheState(inPath)
+		if err != nil {
+			return "", err
+		}
+		keyPcs = append(keyPcs, "in_"+inPath+"_"+inState)
+	}
+	hash := sha256.Sum256([]byte(strings.Join(keyPcs, "\n")))
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// inputCacheState returns the modification time, or the content hash, of the
+// input file at path, depending on the cache mode of the workflow. Only the
+// modification time is used for directories.
+func (t *Task) inputCacheState(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", errWrapf(err, "Could not stat input %s", path)
+	}
+	if t.workflow.cacheMode == CacheModeContent && fi.Mode().IsRegular() {
+		hash, err := fileHash(path)
+		if err != nil {
+			return "", errWrapf(err, "Could not hash input %s", path)
+		}
+		return hash, nil
+	}
+	return fmt.Sprintf("%d", fi.ModTime().UnixNano()), nil
+}
+
+// removeStaleOutputs removes any existing outputs of a task that is re-run
+// because its cache key differs, together with their cache records
+func (t *Task) removeStaleOutputs() {
+	for _, oip := range t.OutIPs {
+		for _, path := range []string{oip.Path(), oip.Path() + cacheRecordExt} {
+			err := os.RemoveAll(path)
+			CheckWithMsg(err, "Could not remove stale output: "+path)
+		}
+	}
+}
+
+// writeCacheRecords writes the cache key of the task to the cache record
+// sidecar files of all its outputs, if the key could be computed
+func (t *Task) writeCacheRecords() {
+	if t.cacheKey == "" {
+		return
+	}
+	record := cacheRecord{TaskID: t.ID(), CacheKey: t.cacheKey}
+	recordJSON, err := json.MarshalIndent(record, "", "    ")
+	CheckWithMsg(err, "Could not marshal cache record of task "+t.ID())
+	for _, oip := range t.OutIPs {
+		recordPath := oip.Path() + cacheRecordExt
+		if err := ioutil.WriteFile(recordPath, recordJSON, 0644); err != nil {
+			t.workflow.WarningLogger().Printf("| %-32s | Could not write cache record %s: %s\n", t.Name, recordPath, err.Error())
+		}
+	}
+}
+
+// readCacheRecord reads the cache record sidecar file at path
+func readCacheRecord(path string) (cacheRecord, error) {
+	record := cacheRecord{}
+	recordJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		return record, err
+	}
+	err = json.Unmarshal(recordJSON, &record)
+	return record, err
+}
@@ -0,0 +1,55 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSetCacheMode(t *testing.T) {
+	initTestLogs()
+	inFile := "/tmp/cache_test_in.txt"
+	outFile := "/tmp/cache_test_in.txt.upper.txt"
+	defer cleanFiles(inFile, outFile, outFile+cacheRecordExt, outFile+".audit.json")
+
+	for _, mode := range []CacheMode{CacheModeMtime, CacheModeContent} {
+		cleanFiles(outFile, outFile+cacheRecordExt)
+		err := ioutil.WriteFile(inFile, []byte("abc\n"), 0644)
+		Check(err)
+
+		runWorkflow := func() {
+			wf := NewWorkflow("test_wf", 4)
+			wf.SetCacheMode(mode)
+			src := NewFileSource(wf, "src", inFile)
+			upper := wf.NewProc("upper", "tr a-z A-Z < {i:in} > {o:out}; date +%N >> {o:out}")
+			upper.In("in").From(src.Out())
+			upper.SetOut("out", "{i:in}.upper.txt")
+			wf.Run()
+		}
+
+		runWorkflow()
+		firstOut, err := ioutil.ReadFile(outFile)
+		Check(err)
+		if _, err := os.Stat(outFile + cacheRecordExt); err != nil {
+			t.Fatalf("Expected cache record to be written next to output (mode %d): %s", mode, err.Error())
+		}
+
+		// Nothing changed, so the task should be skipped
+		runWorkflow()
+		secondOut, err := ioutil.ReadFile(outFile)
+		Check(err)
+		if string(secondOut) != string(firstOut) {
+			t.Errorf("Expected task to be skipped when its cache key matches (mode %d), but output changed from %q to %q", mode, firstOut, secondOut)
+		}
+
+		// Changing the input should make the task re-run
+		err = ioutil.WriteFile(inFile, []byte("def\n"), 0644)
+		Check(err)
+		runWorkflow()
+		thirdOut, err := ioutil.ReadFile(outFile)
+		Check(err)
+		if string(thirdOut[:4]) != "DEF\n" {
+			t.Errorf("Expected task to be re-run when its input changed (mode %d), but got output %q", mode, thirdOut)
+		}
+	}
+}
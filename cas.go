@@ -0,0 +1,77 @@
+package scipipe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// ----------------------------------------------------------------------------
+// Content-addressed storage
+// ----------------------------------------------------------------------------
+
+// EnableCAS enables a content-addressed store in storeDir, for the output
+// files of all tasks in the workflow. Each output file is stored in storeDir
+// under the SHA-256 hash of its content, and hardlinked to its output path, so
+// that identical files produced by different tasks, such as on different
+// branches of the workflow, take up space only once. Since hardlinks can not
+// span file systems, storeDir should be on the same file system as the
+// outputs. Outputs that can not be stored are left as they are.
+func (wf *Workflow) EnableCAS(storeDir string) {
+	err := os.MkdirAll(storeDir, 0777)
+	CheckWithMsg(err, "Could not create directory for content-addressed store: "+storeDir)
+	wf.casDir = storeDir
+}
+
+// storeInCAS stores the file at path in the content-addressed store of the
+// workflow, replacing it with a hardlink to the stored blob, if an identical
+// file is already stored
+func (wf *Workflow) storeInCAS(path string) error {
+	hash, err := fileHash(path)
+	if err != nil {
+		return errWrapf(err, "Could not hash file %s", path)
+	}
+	blobPath := filepath.Join(wf.casDir, hash[:2], hash)
+
+	// Tasks with identical outputs may finish at the same time
+	wf.casMx.Lock()
+	defer wf.casMx.Unlock()
+
+	if _, err := os.Stat(blobPath); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(blobPath), 0777); err != nil {
+			return errWrapf(err, "Could not create directory for blob %s", blobPath)
+		}
+		if err := os.Link(path, blobPath); err != nil {
+			return errWrapf(err, "Could not store file %s as blob %s", path, blobPath)
+		}
+		return nil
+	}
+	// Link to the stored blob via a temporary path, so that the output path
+	// is replaced atomically
+	tmpPath := path + ".cas.tmp"
+	if err := os.Link(blobPath, tmpPath); err != nil {
+		return errWrapf(err, "Could not link to blob %s", blobPath)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return errWrapf(err, "Could not replace file %s with link to blob %s", path, blobPath)
+	}
+	return nil
+}
+
+// fileHash returns the hex encoded SHA-256 hash of the content of the file at
+// path
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,45 @@
+package scipipe
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnableCAS(t *testing.T) {
+	initTestLogs()
+	storeDir := "/tmp/cas_test_store"
+	defer os.RemoveAll(storeDir)
+
+	wf := NewWorkflow("test_wf", 4)
+	wf.EnableCAS(storeDir)
+	// Two branches producing identical outputs, and one producing a
+	// different one
+	for _, name := range []string{"branch_a", "branch_b"} {
+		p := wf.NewProc(name, "echo identical > {o:out}")
+		p.SetOut("out", "/tmp/cas_test_"+name+".txt")
+	}
+	p := wf.NewProc("branch_c", "echo different > {o:out}")
+	p.SetOut("out", "/tmp/cas_test_branch_c.txt")
+	wf.Run()
+	defer cleanFiles("/tmp/cas_test_branch_a.txt", "/tmp/cas_test_branch_b.txt", "/tmp/cas_test_branch_c.txt")
+
+	blobs, err := filepath.Glob(filepath.Join(storeDir, "*", "*"))
+	Check(err)
+	if len(blobs) != 2 {
+		t.Fatalf("Expected 2 stored blobs, for 3 outputs of which 2 are identical, but found %d: %v", len(blobs), blobs)
+	}
+
+	fiA, err := os.Stat("/tmp/cas_test_branch_a.txt")
+	Check(err)
+	fiB, err := os.Stat("/tmp/cas_test_branch_b.txt")
+	Check(err)
+	fiC, err := os.Stat("/tmp/cas_test_branch_c.txt")
+	Check(err)
+	if !os.SameFile(fiA, fiB) {
+		t.Error("Expected identical outputs to resolve to the same stored blob")
+	}
+	if os.SameFile(fiA, fiC) {
+		t.Error("Expected different outputs to resolve to different stored blobs")
+	}
+}
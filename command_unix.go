@@ -0,0 +1,27 @@
+//go:build !windows
+// +build !windows
+
+package scipipe
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes command run in a new process group, so that it can
+// be signalled together with any processes it starts
+func setProcessGroup(command *exec.Cmd) {
+	command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminateProcessGroup asks the process group of the started command to
+// terminate, with SIGTERM
+func terminateProcessGroup(command *exec.Cmd) error {
+	return syscall.Kill(-command.Process.Pid, syscall.SIGTERM)
+}
+
+// killProcessGroup kills the process group of the started command, with
+// SIGKILL
+func killProcessGroup(command *exec.Cmd) error {
+	return syscall.Kill(-command.Process.Pid, syscall.SIGKILL)
+}
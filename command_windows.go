@@ -0,0 +1,23 @@
+//go:build windows
+// +build windows
+
+package scipipe
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup is a no-op on Windows, where there are no process groups,
+// so that only the command itself is terminated on timeout
+func setProcessGroup(command *exec.Cmd) {}
+
+// terminateProcessGroup terminates the started command. Windows has no
+// signals for asking processes to terminate, so it is killed right away.
+func terminateProcessGroup(command *exec.Cmd) error {
+	return command.Process.Kill()
+}
+
+// killProcessGroup kills the started command
+func killProcessGroup(command *exec.Cmd) error {
+	return command.Process.Kill()
+}
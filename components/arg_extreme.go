@@ -0,0 +1,55 @@
+package components
+
+import (
+	"github.com/scipipe/scipipe"
+)
+
+// ArgExtreme is a process that receives all IPs on its in-port, and once the
+// in-port is closed, sends on its out-port only the IP with the largest value
+// of metric, if max is true, or the smallest value otherwise. This is useful
+// for "pick the best" steps, such as selecting the largest assembly. If
+// multiple IPs have the extreme value, the first one received is sent.
+type ArgExtreme struct {
+	scipipe.BaseProcess
+	metric func(*scipipe.FileIP) float64
+	max    bool
+}
+
+// NewArgExtreme returns a new initialized ArgExtreme process, selecting the IP
+// with the max value of metric if max is true, or the min value otherwise
+func NewArgExtreme(wf *scipipe.Workflow, name string, metric func(*scipipe.FileIP) float64, max bool) *ArgExtreme {
+	p := &ArgExtreme{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		metric:      metric,
+		max:         max,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the IPs to select from are received
+func (p *ArgExtreme) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the selected IP is sent
+func (p *ArgExtreme) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the ArgExtreme process
+func (p *ArgExtreme) Run() {
+	defer p.CloseAllOutPorts()
+
+	var best *scipipe.FileIP
+	var bestVal float64
+	for ip := range p.In().Chan {
+		val := p.metric(ip)
+		if best == nil || (p.max && val > bestVal) || (!p.max && val < bestVal) {
+			best = ip
+			bestVal = val
+		}
+	}
+	if best != nil {
+		p.Workflow().Logger().Printf("| %-32s | Selected %s, with metric value %g\n", p.Name(), best.Path(), bestVal)
+		p.Out().Send(best)
+	}
+}
@@ -0,0 +1,50 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestArgExtreme(t *testing.T) {
+	dir, err := ioutil.TempDir("", "arg_extreme_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	paths := []string{}
+	for name, size := range map[string]int{"small.fa": 10, "largest.fa": 300, "medium.fa": 100} {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, []byte(strings.Repeat("A", size)), 0644)
+		scipipe.Check(err)
+		paths = append(paths, path)
+	}
+	fileSize := func(ip *scipipe.FileIP) float64 {
+		return float64(ip.Size())
+	}
+
+	for _, tc := range []struct {
+		max      bool
+		expected string
+	}{
+		{true, "largest.fa"},
+		{false, "small.fa"},
+	} {
+		wf := scipipe.NewWorkflow("wf", 4)
+		src := NewFileSource(wf, "src", paths...)
+		pick := NewArgExtreme(wf, "pick", fileSize, tc.max)
+		pick.In().From(src.Out())
+		col := newIPCollector(wf, "collector")
+		col.In().From(pick.Out())
+		wf.Run()
+
+		expected := []string{filepath.Join(dir, tc.expected)}
+		if !reflect.DeepEqual(col.paths(), expected) {
+			t.Errorf("Expected only %v to be selected (max: %t), got %v", expected, tc.max, col.paths())
+		}
+	}
+}
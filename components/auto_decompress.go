@@ -0,0 +1,103 @@
+package components
+
+import (
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scipipe/scipipe"
+)
+
+// AutoDecompress is a process that, for each IP received on its in-port with
+// a path ending in a known compression extension (.gz or .bz2), decompresses
+// the file, and sends an IP for the decompressed file on its out-port, for use
+// with tools that can only read uncompressed files. The decompressed files are
+// named after the compressed ones, with the compression extension removed.
+// IPs for uncompressed files are passed through unchanged.
+type AutoDecompress struct {
+	scipipe.BaseProcess
+}
+
+// NewAutoDecompress returns a new initialized AutoDecompress process
+func NewAutoDecompress(wf *scipipe.Workflow, name string) *AutoDecompress {
+	p := &AutoDecompress{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the files to decompress
+func (p *AutoDecompress) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the decompressed (or already uncompressed)
+// files are sent
+func (p *AutoDecompress) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// decompressors contains functions for decompressing files, by the file
+// extensions they are used for
+var decompressors = map[string]func(r io.Reader) (io.Reader, error){
+	".gz": func(r io.Reader) (io.Reader, error) {
+		return gzip.NewReader(r)
+	},
+	".bz2": func(r io.Reader) (io.Reader, error) {
+		return bzip2.NewReader(r), nil
+	},
+}
+
+// Run runs the AutoDecompress process
+func (p *AutoDecompress) Run() {
+	defer p.CloseAllOutPorts()
+
+	for inIP := range p.In().Chan {
+		ext := filepath.Ext(inIP.Path())
+		if _, ok := decompressors[ext]; !ok {
+			p.Out().Send(inIP)
+			continue
+		}
+		outPath := strings.TrimSuffix(inIP.Path(), ext)
+		if _, err := os.Stat(outPath); err == nil {
+			p.Workflow().Logger().Printf("| %-32s | Decompressed file already exists, so skipping: %s\n", p.Name(), outPath)
+		} else {
+			err := decompressFile(inIP.Path(), outPath, decompressors[ext])
+			scipipe.CheckWithMsg(err, "AutoDecompress "+p.Name()+": Could not decompress file "+inIP.Path())
+		}
+		p.Out().Send(scipipe.NewFileIP(outPath))
+	}
+}
+
+// decompressFile decompresses the file at srcPath into dstPath, using
+// decompressor. The file is decompressed into a temporary file, which is
+// renamed to dstPath when done, so that a partially decompressed file never
+// appears at dstPath.
+func decompressFile(srcPath string, dstPath string, decompressor func(io.Reader) (io.Reader, error)) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	r, err := decompressor(src)
+	if err != nil {
+		return errWrap(err, "Could not read compressed file "+srcPath)
+	}
+
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return errWrap(err, "Could not create file "+tmpPath)
+	}
+	if _, err := io.Copy(dst, r); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return errWrap(err, "Could not decompress into file "+tmpPath)
+	}
+	if err := dst.Close(); err != nil {
+		return errWrap(err, "Could not close file "+tmpPath)
+	}
+	return os.Rename(tmpPath, dstPath)
+}
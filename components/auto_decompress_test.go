@@ -0,0 +1,52 @@
+package components
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestAutoDecompress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auto_decompress_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	gzPath := filepath.Join(dir, "compressed.txt.gz")
+	gzFile, err := os.Create(gzPath)
+	scipipe.Check(err)
+	gzw := gzip.NewWriter(gzFile)
+	_, err = gzw.Write([]byte("compressed content\n"))
+	scipipe.Check(err)
+	scipipe.Check(gzw.Close())
+	scipipe.Check(gzFile.Close())
+
+	plainPath := filepath.Join(dir, "plain.txt")
+	err = ioutil.WriteFile(plainPath, []byte("plain content\n"), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", gzPath, plainPath)
+	dec := NewAutoDecompress(wf, "decompress")
+	dec.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(dec.Out())
+	wf.Run()
+
+	decompressedPath := filepath.Join(dir, "compressed.txt")
+	expected := []string{decompressedPath, plainPath}
+	if !reflect.DeepEqual(col.paths(), expected) {
+		t.Fatalf("Expected output paths %v, got %v", expected, col.paths())
+	}
+	for path, expectedContent := range map[string]string{decompressedPath: "compressed content\n", plainPath: "plain content\n"} {
+		content, err := ioutil.ReadFile(path)
+		scipipe.Check(err)
+		if string(content) != expectedContent {
+			t.Errorf("Expected file %s to contain '%s', got '%s'", path, expectedContent, string(content))
+		}
+	}
+}
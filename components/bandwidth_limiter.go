@@ -0,0 +1,141 @@
+package components
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+// BandwidthLimiter is a process that copies the file of each IP received on
+// its in-port, reading it at a rate of at most bytesPerSec bytes per second,
+// and sends an IP for the copy on its out-port. The rate limit applies to the
+// aggregate throughput over all files, which are copied one at a time. This
+// is useful for example for copying files from a shared file system without
+// saturating it. Copies are written to OutDir, if set, under the same file
+// names, and otherwise next to the original files, with ".copy" inserted
+// before the file extension. Existing copies are not copied again.
+type BandwidthLimiter struct {
+	scipipe.BaseProcess
+	bytesPerSec int64
+	// OutDir is the directory to write copies to
+	OutDir string
+}
+
+// NewBandwidthLimiter returns a new initialized BandwidthLimiter process,
+// copying files at a rate of at most bytesPerSec bytes per second
+func NewBandwidthLimiter(wf *scipipe.Workflow, name string, bytesPerSec int64) *BandwidthLimiter {
+	if bytesPerSec < 1 {
+		scipipe.Failf("BandwidthLimiter with name '%s': Bytes per second must be at least 1, got %d", name, bytesPerSec)
+	}
+	p := &BandwidthLimiter{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		bytesPerSec: bytesPerSec,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the files to copy
+func (p *BandwidthLimiter) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the copied files are sent
+func (p *BandwidthLimiter) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the BandwidthLimiter process
+func (p *BandwidthLimiter) Run() {
+	defer p.CloseAllOutPorts()
+
+	limiter := &rateLimiter{bytesPerSec: p.bytesPerSec}
+	for inIP := range p.In().Chan {
+		outPath := pathWithInfix(inIP.Path(), "copy")
+		if p.OutDir != "" {
+			outPath = filepath.Join(p.OutDir, filepath.Base(inIP.Path()))
+		}
+		if _, err := os.Stat(outPath); err == nil {
+			p.Workflow().Logger().Printf("| %-32s | Copy already exists, so skipping: %s\n", p.Name(), outPath)
+		} else {
+			err := copyFileLimited(inIP.Path(), outPath, limiter)
+			scipipe.CheckWithMsg(err, "BandwidthLimiter "+p.Name()+": Could not copy file "+inIP.Path())
+		}
+		p.Out().Send(scipipe.NewFileIP(outPath))
+	}
+}
+
+// copyFileLimited copies the file at srcPath to dstPath, at the rate allowed
+// by limiter. The file is copied into a temporary file, which is renamed to
+// dstPath when done, so that a partially copied file never appears at dstPath.
+func copyFileLimited(srcPath string, dstPath string, limiter *rateLimiter) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if dir := filepath.Dir(dstPath); dir != "." {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return errWrap(err, "Could not create directory: "+dir)
+		}
+	}
+	tmpPath := dstPath + ".tmp"
+	dst, err := os.Create(tmpPath)
+	if err != nil {
+		return errWrap(err, "Could not create file "+tmpPath)
+	}
+	if _, err := io.Copy(dst, &limitedReader{r: src, limiter: limiter}); err != nil {
+		dst.Close()
+		os.Remove(tmpPath)
+		return errWrap(err, "Could not copy into file "+tmpPath)
+	}
+	if err := dst.Close(); err != nil {
+		return errWrap(err, "Could not close file "+tmpPath)
+	}
+	return os.Rename(tmpPath, dstPath)
+}
+
+// rateLimiter paces the transfer of bytes to a rate of bytesPerSec
+type rateLimiter struct {
+	bytesPerSec int64
+	// next is the time at which the bytes transferred so far are paid for
+	next time.Time
+}
+
+// wait blocks until the transfer of n more bytes is within the rate limit.
+// Time spent idle between transfers does not allow for later bursts.
+func (l *rateLimiter) wait(n int) {
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.bytesPerSec))
+	time.Sleep(time.Until(l.next))
+}
+
+// limitedReader is an io.Reader reading from r at the rate allowed by limiter
+type limitedReader struct {
+	r       io.Reader
+	limiter *rateLimiter
+}
+
+// limitedReadSize is the max number of bytes read at a time by limitedReader,
+// so that the rate is smooth also for slow rates
+const limitedReadSize = 32 * 1024
+
+func (lr *limitedReader) Read(p []byte) (int, error) {
+	maxSize := int64(limitedReadSize)
+	if lr.limiter.bytesPerSec < maxSize {
+		maxSize = lr.limiter.bytesPerSec
+	}
+	if int64(len(p)) > maxSize {
+		p = p[:maxSize]
+	}
+	n, err := lr.r.Read(p)
+	if n > 0 {
+		lr.limiter.wait(n)
+	}
+	return n, err
+}
@@ -0,0 +1,55 @@
+package components
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestBandwidthLimiter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bandwidth_limiter_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// 2 files of 1500 bytes each, at 10000 bytes per second, should take at
+	// least 300 ms in total
+	content := bytes.Repeat([]byte("ACGT"), 375)
+	inPaths := []string{filepath.Join(dir, "a.fq"), filepath.Join(dir, "b.fq")}
+	for _, path := range inPaths {
+		err := ioutil.WriteFile(path, content, 0644)
+		scipipe.Check(err)
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", inPaths...)
+	limiter := NewBandwidthLimiter(wf, "limiter", 10000)
+	limiter.OutDir = filepath.Join(dir, "copies")
+	limiter.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(limiter.Out())
+
+	start := time.Now()
+	wf.Run()
+	elapsed := time.Since(start)
+
+	if elapsed < 300*time.Millisecond {
+		t.Errorf("Expected copying 3000 bytes at 10000 bytes/sec to take at least 300ms, but took %v", elapsed)
+	}
+	expectedPaths := []string{filepath.Join(dir, "copies", "a.fq"), filepath.Join(dir, "copies", "b.fq")}
+	if !reflect.DeepEqual(col.paths(), expectedPaths) {
+		t.Fatalf("Expected copies %v, got %v", expectedPaths, col.paths())
+	}
+	for _, path := range expectedPaths {
+		copied, err := ioutil.ReadFile(path)
+		scipipe.Check(err)
+		if !bytes.Equal(copied, content) {
+			t.Errorf("Copy %s does not have the content of the original file", path)
+		}
+	}
+}
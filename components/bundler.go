@@ -0,0 +1,134 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/scipipe/scipipe"
+)
+
+// Bundler is a process that concatenates the (typically small) files received
+// on its in-port into bundle files, each not exceeding MaxBytes bytes, to
+// reduce the number of files on the file system. For each bundle, an index
+// file is also written, with one tab-separated line per bundled file, with the
+// original path of the file, and the offset and size in bytes of its content
+// in the bundle. The bundles are named [BundlePrefix].bundle_[number], and the
+// index files [bundle path].index.tsv.
+type Bundler struct {
+	scipipe.BaseProcess
+	MaxBytes     int64
+	BundlePrefix string
+}
+
+// NewBundler returns a new initialized Bundler process, writing bundles of at
+// most maxBytes bytes
+func NewBundler(wf *scipipe.Workflow, name string, maxBytes int64) *Bundler {
+	if maxBytes < 1 {
+		scipipe.Failf("Bundler with name '%s': Max bytes must be at least 1, but was %d", name, maxBytes)
+	}
+	p := &Bundler{
+		BaseProcess:  scipipe.NewBaseProcess(wf, name),
+		MaxBytes:     maxBytes,
+		BundlePrefix: name,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "bundle")
+	p.InitOutPort(p, "index")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the files to bundle are received
+func (p *Bundler) In() *scipipe.InPort { return p.InPort("in") }
+
+// OutBundle returns the out-port on which the bundle files are sent
+func (p *Bundler) OutBundle() *scipipe.OutPort { return p.OutPort("bundle") }
+
+// OutIndex returns the out-port on which the index files of the bundles are
+// sent, in the same order as the bundles
+func (p *Bundler) OutIndex() *scipipe.OutPort { return p.OutPort("index") }
+
+// bundleIndexEntry is the location of the content of a file in a bundle
+type bundleIndexEntry struct {
+	path   string
+	offset int64
+	size   int64
+}
+
+// Run runs the Bundler process
+func (p *Bundler) Run() {
+	defer p.CloseAllOutPorts()
+
+	bundleNo := 1
+	entries := []bundleIndexEntry{}
+	var bundleSize int64
+	var bundleFile *os.File
+	var bundleIP *scipipe.FileIP
+	var taskDir string
+
+	for inIP := range p.In().Chan {
+		fileInfo, err := os.Stat(inIP.Path())
+		scipipe.CheckWithMsg(err, "[Bundler] Could not stat file "+inIP.Path())
+		if fileInfo.Size() > p.MaxBytes {
+			scipipe.Failf("Bundler %s: File %s (%d bytes) is larger than the max bundle size (%d bytes)\n", p.Name(), inIP.Path(), fileInfo.Size(), p.MaxBytes)
+		}
+		if bundleFile != nil && bundleSize+fileInfo.Size() > p.MaxBytes {
+			p.finishBundle(bundleFile, bundleIP, taskDir, entries)
+			bundleFile = nil
+			bundleNo++
+		}
+		if bundleFile == nil {
+			bundleIP = scipipe.NewFileIP(p.BundlePrefix + fmt.Sprintf(".bundle_%d", bundleNo))
+			taskDir = "_scipipe_tmp_" + p.Name() + "." + filepath.Base(bundleIP.Path())
+			bundleFile = p.createTempFile(taskDir, bundleIP)
+			bundleSize = 0
+			entries = []bundleIndexEntry{}
+		}
+
+		inFile, err := os.Open(inIP.Path())
+		scipipe.CheckWithMsg(err, "[Bundler] Could not open file "+inIP.Path())
+		written, err := io.Copy(bundleFile, inFile)
+		scipipe.CheckWithMsg(err, "[Bundler] Could not copy file "+inIP.Path()+" into bundle "+bundleIP.Path())
+		inFile.Close()
+
+		entries = append(entries, bundleIndexEntry{inIP.Path(), bundleSize, written})
+		bundleSize += written
+	}
+	if bundleFile != nil {
+		p.finishBundle(bundleFile, bundleIP, taskDir, entries)
+	}
+}
+
+// createTempFile creates the temp file of ip in taskDir
+func (p *Bundler) createTempFile(taskDir string, ip *scipipe.FileIP) *os.File {
+	tempPath := filepath.Join(taskDir, ip.TempPath())
+	err := os.MkdirAll(filepath.Dir(tempPath), 0777)
+	scipipe.CheckWithMsg(err, "[Bundler] Could not create dirs for file "+tempPath)
+	tempFile, err := os.Create(tempPath)
+	scipipe.CheckWithMsg(err, "[Bundler] Could not create temp file "+tempPath)
+	return tempFile
+}
+
+// finishBundle closes the bundle file, writes its index file, and moves both
+// in place, before sending them on the out-ports
+func (p *Bundler) finishBundle(bundleFile *os.File, bundleIP *scipipe.FileIP, taskDir string, entries []bundleIndexEntry) {
+	err := bundleFile.Close()
+	scipipe.CheckWithMsg(err, "[Bundler] Could not close bundle file "+bundleIP.Path())
+
+	indexIP := scipipe.NewFileIP(bundleIP.Path() + ".index.tsv")
+	indexFile := p.createTempFile(taskDir, indexIP)
+	bw := bufio.NewWriter(indexFile)
+	for _, entry := range entries {
+		fmt.Fprintf(bw, "%s\t%d\t%d\n", entry.path, entry.offset, entry.size)
+	}
+	err = bw.Flush()
+	scipipe.CheckWithMsg(err, "[Bundler] Could not write index file "+indexIP.Path())
+	indexFile.Close()
+
+	scipipe.AtomizeIPs(taskDir, bundleIP, indexIP)
+	p.OutBundle().Send(bundleIP)
+	p.OutIndex().Send(indexIP)
+}
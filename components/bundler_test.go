@@ -0,0 +1,74 @@
+package components
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestBundler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bundler_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// Ten files of ten bytes each
+	contents := map[string]string{}
+	paths := []string{}
+	for i := 0; i < 10; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("tiny_%d.txt", i))
+		content := fmt.Sprintf("content_%d\n", i)
+		err := ioutil.WriteFile(path, []byte(content), 0644)
+		scipipe.Check(err)
+		contents[path] = content
+		paths = append(paths, path)
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	bundler := NewBundler(wf, "bundler", 50)
+	bundler.BundlePrefix = filepath.Join(dir, "tiny")
+	bundler.In().From(src.Out())
+	bundles := newIPCollector(wf, "bundle_collector")
+	bundles.In().From(bundler.OutBundle())
+	// The index out-port is left unconnected, so that its IPs go to the sink
+	wf.Run()
+
+	if len(bundles.ips) != 2 {
+		t.Fatalf("Expected 2 bundles, got %d: %v", len(bundles.ips), bundles.paths())
+	}
+
+	bundled := 0
+	for i, bundleIP := range bundles.ips {
+		expectedPath := filepath.Join(dir, fmt.Sprintf("tiny.bundle_%d", i+1))
+		if bundleIP.Path() != expectedPath {
+			t.Errorf("Expected bundle path %s, got %s", expectedPath, bundleIP.Path())
+		}
+		bundle, err := ioutil.ReadFile(bundleIP.Path())
+		scipipe.Check(err)
+		if int64(len(bundle)) > bundler.MaxBytes {
+			t.Errorf("Bundle %s has %d bytes, which is more than the max %d", bundleIP.Path(), len(bundle), bundler.MaxBytes)
+		}
+		index, err := ioutil.ReadFile(bundleIP.Path() + ".index.tsv")
+		scipipe.Check(err)
+		for _, line := range strings.Split(strings.TrimSpace(string(index)), "\n") {
+			fields := strings.Split(line, "\t")
+			offset, err := strconv.Atoi(fields[1])
+			scipipe.Check(err)
+			size, err := strconv.Atoi(fields[2])
+			scipipe.Check(err)
+			if got := string(bundle[offset : offset+size]); got != contents[fields[0]] {
+				t.Errorf("Expected content '%s' of file %s at offset %d in bundle %s, got '%s'", contents[fields[0]], fields[0], offset, bundleIP.Path(), got)
+			}
+			bundled++
+		}
+	}
+	if bundled != 10 {
+		t.Errorf("Expected all 10 files to be indexed in bundles, but %d were", bundled)
+	}
+}
@@ -0,0 +1,87 @@
+package components
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/scipipe/scipipe"
+)
+
+// CardinalityCheck is a process that checks that the number of IPs output by a
+// process is the expected ratio of the number of IPs input to it, such as
+// exactly one output per input for a ratio of 1.0. The IPs sent to the
+// checked process should also be connected to the InUpstream in-port, where
+// they are just counted, while the outputs of the checked process should be
+// connected to the InDownstream in-port, from which they are passed through on
+// the Out out-port. When both in-ports are closed, the workflow fails if the
+// number of outputs deviates from the expected one.
+type CardinalityCheck struct {
+	scipipe.BaseProcess
+	ratio float64
+}
+
+// NewCardinalityCheck returns a new initialized CardinalityCheck process,
+// expecting ratio outputs per input
+func NewCardinalityCheck(wf *scipipe.Workflow, name string, ratio float64) *CardinalityCheck {
+	if ratio < 0 {
+		scipipe.Failf("CardinalityCheck with name '%s': Ratio can't be negative, but was %f", name, ratio)
+	}
+	p := &CardinalityCheck{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		ratio:       ratio,
+	}
+	p.InitInPort(p, "upstream")
+	p.InitInPort(p, "downstream")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// InUpstream returns the in-port on which the inputs of the checked process
+// are received
+func (p *CardinalityCheck) InUpstream() *scipipe.InPort { return p.InPort("upstream") }
+
+// InDownstream returns the in-port on which the outputs of the checked
+// process are received
+func (p *CardinalityCheck) InDownstream() *scipipe.InPort { return p.InPort("downstream") }
+
+// Out returns the out-port on which the outputs of the checked process are
+// passed through
+func (p *CardinalityCheck) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the CardinalityCheck process
+func (p *CardinalityCheck) Run() {
+	defer p.CloseAllOutPorts()
+
+	// Count upstream IPs concurrently, so that neither port blocks the other
+	upstreamCount := 0
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range p.InUpstream().Chan {
+			upstreamCount++
+		}
+	}()
+	downstreamCount := 0
+	for ip := range p.InDownstream().Chan {
+		downstreamCount++
+		p.Out().Send(ip)
+	}
+	wg.Wait()
+
+	if err := checkCardinality(upstreamCount, downstreamCount, p.ratio); err != nil {
+		scipipe.Failf("CardinalityCheck %s: %s\n", p.Name(), err.Error())
+	}
+}
+
+// checkCardinality returns an error if downstreamCount is not ratio times
+// upstreamCount, rounded to the nearest integer
+func checkCardinality(upstreamCount int, downstreamCount int, ratio float64) error {
+	expected := int(math.Round(float64(upstreamCount) * ratio))
+	if downstreamCount != expected {
+		return fmt.Errorf("Expected %d outputs for %d inputs (ratio %g), but got %d", expected, upstreamCount, ratio, downstreamCount)
+	}
+	return nil
+}
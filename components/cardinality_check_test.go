@@ -0,0 +1,42 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestCardinalityCheck(t *testing.T) {
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", "/tmp/cardinality_a.txt", "/tmp/cardinality_b.txt")
+	// A process producing one output per input
+	copier := wf.NewProc("copier", "# {i:in} {o:out}")
+	copier.In("in").From(src.Out())
+	copier.SetOut("out", "{i:in}.copy")
+	copier.CustomExecute = func(tsk *scipipe.Task) {}
+	chk := NewCardinalityCheck(wf, "check", 1.0)
+	chk.InUpstream().From(src.Out())
+	chk.InDownstream().From(copier.Out("out"))
+	col := newIPCollector(wf, "collector")
+	col.In().From(chk.Out())
+	wf.Run()
+
+	expected := []string{"/tmp/cardinality_a.txt.copy", "/tmp/cardinality_b.txt.copy"}
+	if !reflect.DeepEqual(col.paths(), expected) {
+		t.Errorf("Expected outputs to be passed through: %v, got: %v", expected, col.paths())
+	}
+}
+
+func TestCheckCardinality(t *testing.T) {
+	if err := checkCardinality(10, 10, 1.0); err != nil {
+		t.Errorf("Expected one output per input to pass, but got error: %v", err)
+	}
+	if err := checkCardinality(10, 20, 2.0); err != nil {
+		t.Errorf("Expected two outputs per input to pass with ratio 2, but got error: %v", err)
+	}
+	// One dropped output
+	if err := checkCardinality(10, 9, 1.0); err == nil {
+		t.Errorf("Expected a dropped output to be detected, but it was not")
+	}
+}
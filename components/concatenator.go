@@ -1,9 +1,19 @@
 package components
 
-import "github.com/scipipe/scipipe"
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/scipipe/scipipe"
+)
 
 // Concatenator is a process that concatenates the content of multiple files
-// received in the in-port In, into one file returned on its out-port, Out
+// received in the in-port In, into one file returned on its out-port, Out.
+// The contents of the files are appended as is, in the order the files are
+// received, and are streamed rather than read into memory. The concatenated
+// file is sent when the in-port is closed, and is empty if no files were
+// received.
 type Concatenator struct {
 	scipipe.BaseProcess
 	OutPath string
@@ -32,21 +42,34 @@ func (p *Concatenator) Out() *scipipe.OutPort { return p.OutPort("out") }
 func (p *Concatenator) Run() {
 	defer p.CloseAllOutPorts()
 
-	outIP := scipipe.NewFileIP(p.OutPath)
-	outFh := outIP.OpenWriteTemp()
+	if dir := filepath.Dir(p.OutPath); dir != "." {
+		err := os.MkdirAll(dir, 0777)
+		scipipe.CheckWithMsg(err, "Concatenator "+p.Name()+": Could not create directory "+dir)
+	}
+	// Write to a temporary file, so that a partially concatenated file never
+	// appears at the output path
+	tmpPath := p.OutPath + ".tmp"
+	outFh, err := os.Create(tmpPath)
+	scipipe.CheckWithMsg(err, "Concatenator "+p.Name()+": Could not create file "+tmpPath)
 	for inIP := range p.In().Chan {
-		fr := NewFileToParamsReader(p.Workflow(), p.Name()+"_filereader_"+getRandString(7), inIP.Path())
-
-		pip := scipipe.NewInParamPort(p.Name() + "temp_line_reader")
-		pip.SetProcess(p)
-		pip.From(fr.OutLine())
+		err := appendFile(outFh, inIP.Path())
+		scipipe.CheckWithMsg(err, "Concatenator "+p.Name()+": Could not append file "+inIP.Path())
+	}
+	err = outFh.Close()
+	scipipe.CheckWithMsg(err, "Concatenator "+p.Name()+": Could not close file "+tmpPath)
+	err = os.Rename(tmpPath, p.OutPath)
+	scipipe.CheckWithMsg(err, "Concatenator "+p.Name()+": Could not rename "+tmpPath+" to "+p.OutPath)
 
-		go fr.Run()
+	p.Out().Send(scipipe.NewFileIP(p.OutPath))
+}
 
-		for line := range pip.Chan {
-			outFh.WriteString(line)
-		}
+// appendFile copies the content of the file at path to w
+func appendFile(w io.Writer, path string) error {
+	inFh, err := os.Open(path)
+	if err != nil {
+		return err
 	}
-	outFh.Close()
-	p.Out().Send(outIP)
+	defer inFh.Close()
+	_, err = io.Copy(w, inFh)
+	return err
 }
@@ -0,0 +1,54 @@
+package components
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestConcatenator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "concatenator_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	contents := []string{"chr1\t10\n", "chr2\t20\n", "chr3\t30"}
+	paths := []string{}
+	for i, content := range contents {
+		path := filepath.Join(dir, fmt.Sprintf("chr%d.txt", i+1))
+		err := ioutil.WriteFile(path, []byte(content), 0644)
+		scipipe.Check(err)
+		paths = append(paths, path)
+	}
+
+	for _, tc := range []struct {
+		name     string
+		paths    []string
+		expected string
+	}{
+		{"all", paths, "chr1\t10\nchr2\t20\nchr3\t30"},
+		{"empty", []string{}, ""},
+	} {
+		outPath := filepath.Join(dir, "out", tc.name+".txt")
+
+		wf := scipipe.NewWorkflow("wf", 4)
+		src := NewFileSource(wf, "src", tc.paths...)
+		cat := NewConcatenator(wf, "cat", outPath)
+		cat.In().From(src.Out())
+		col := newIPCollector(wf, "collector")
+		col.In().From(cat.Out())
+		wf.Run()
+
+		if len(col.paths()) != 1 || col.paths()[0] != outPath {
+			t.Fatalf("Expected one IP with path %s for case %s, got: %v", outPath, tc.name, col.paths())
+		}
+		dat, err := ioutil.ReadFile(outPath)
+		scipipe.Check(err)
+		if string(dat) != tc.expected {
+			t.Errorf("Expected concatenated content %q for case %s, got %q", tc.expected, tc.name, string(dat))
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package components
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/scipipe/scipipe"
+)
+
+// ContentNamer is a process that gives each file received on its in-port a
+// stable name based on its content, by hard-linking it to [hash][ext] in a
+// destination directory, where hash is the hex encoded SHA-256 hash of the
+// content, and sending an IP for the linked file on its out-port, with the
+// tags of the incoming IP. Files with identical content thus get the same
+// name, which is useful for caching. Files that can not be hard-linked, such
+// as when the destination directory is on another file system, are copied.
+type ContentNamer struct {
+	scipipe.BaseProcess
+	destDir string
+	ext     string
+}
+
+// NewContentNamer returns a new initialized ContentNamer process, naming files
+// after their content, with the extension ext (including any dot), in the
+// directory destDir
+func NewContentNamer(wf *scipipe.Workflow, name string, destDir string, ext string) *ContentNamer {
+	p := &ContentNamer{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		destDir:     destDir,
+		ext:         ext,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the files to name are received
+func (p *ContentNamer) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the content-named files are sent
+func (p *ContentNamer) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the ContentNamer process
+func (p *ContentNamer) Run() {
+	defer p.CloseAllOutPorts()
+
+	err := os.MkdirAll(p.destDir, 0777)
+	scipipe.CheckWithMsg(err, "ContentNamer "+p.Name()+": Could not create directory "+p.destDir)
+	for inIP := range p.In().Chan {
+		hash, err := contentHash(inIP.Path())
+		scipipe.CheckWithMsg(err, "ContentNamer "+p.Name()+": Could not hash file "+inIP.Path())
+		outPath := filepath.Join(p.destDir, hash+p.ext)
+		if _, err := os.Stat(outPath); err == nil {
+			p.Workflow().Logger().Printf("| %-32s | File with identical content already exists, so skipping: %s\n", p.Name(), outPath)
+		} else {
+			err := linkOrCopyFile(inIP.Path(), outPath)
+			scipipe.CheckWithMsg(err, "ContentNamer "+p.Name()+": Could not link file "+inIP.Path()+" to "+outPath)
+		}
+		outIP := scipipe.NewFileIP(outPath)
+		outIP.AddTags(inIP.Tags())
+		p.Out().Send(outIP)
+	}
+}
+
+// contentHash returns the hex encoded SHA-256 hash of the content of the file
+// at path
+func contentHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// linkOrCopyFile hard-links the file at path to newPath, or copies it if it
+// can not be linked, via a temporary file, so that a partially copied file
+// never appears at newPath
+func linkOrCopyFile(path string, newPath string) error {
+	if err := os.Link(path, newPath); err == nil {
+		return nil
+	}
+	inFh, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer inFh.Close()
+	tmpPath := newPath + ".tmp"
+	outFh, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFh, inFh); err != nil {
+		outFh.Close()
+		return err
+	}
+	if err := outFh.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, newPath)
+}
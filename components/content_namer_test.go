@@ -0,0 +1,56 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestContentNamer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "content_namer_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	contents := map[string]string{"a.txt": "same\n", "b.txt": "same\n", "c.txt": "other\n"}
+	paths := []string{}
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, []byte(contents[name]), 0644)
+		scipipe.Check(err)
+		paths = append(paths, path)
+	}
+	destDir := filepath.Join(dir, "named")
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	namer := NewContentNamer(wf, "namer", destDir, ".txt")
+	namer.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(namer.Out())
+	wf.Run()
+
+	outPaths := col.paths()
+	if len(outPaths) != 3 {
+		t.Fatalf("Expected 3 IPs, got: %v", outPaths)
+	}
+	if outPaths[0] != outPaths[1] {
+		t.Errorf("Expected files with identical content to get the same name, got: %s and %s", outPaths[0], outPaths[1])
+	}
+	if outPaths[0] == outPaths[2] {
+		t.Errorf("Expected files with different content to get different names, but both got: %s", outPaths[0])
+	}
+	for i, outPath := range outPaths {
+		if filepath.Dir(outPath) != destDir || !strings.HasSuffix(outPath, ".txt") {
+			t.Errorf("Expected file named [hash].txt in %s, got: %s", destDir, outPath)
+		}
+		dat, err := ioutil.ReadFile(outPath)
+		scipipe.Check(err)
+		if string(dat) != contents[filepath.Base(paths[i])] {
+			t.Errorf("Expected content %q in %s, got %q", contents[filepath.Base(paths[i])], outPath, string(dat))
+		}
+	}
+}
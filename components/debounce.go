@@ -0,0 +1,58 @@
+package components
+
+import (
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+// Debounce is a process that forwards each IP received on its in-port to its
+// out-port, unless an IP with the same key, as returned by keyFunc, was
+// forwarded less than window ago, in which case it is dropped. This is useful
+// for deduplicating event-driven triggers, such as from a DirWatcher, where
+// the same file can be reported many times in a quick succession.
+type Debounce struct {
+	scipipe.BaseProcess
+	keyFunc func(ip *scipipe.FileIP) string
+	window  time.Duration
+}
+
+// NewDebounce returns a new initialized Debounce process, forwarding only one
+// IP per key, as returned by keyFunc, within each time window of length window
+func NewDebounce(wf *scipipe.Workflow, name string, keyFunc func(ip *scipipe.FileIP) string, window time.Duration) *Debounce {
+	if window <= 0 {
+		scipipe.Failf("Debounce with name '%s': Window must be positive, but was %s", name, window)
+	}
+	p := &Debounce{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		keyFunc:     keyFunc,
+		window:      window,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the IPs to debounce are received
+func (p *Debounce) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the IPs not dropped are sent
+func (p *Debounce) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the Debounce process
+func (p *Debounce) Run() {
+	defer p.CloseAllOutPorts()
+
+	lastSent := map[string]time.Time{}
+	for ip := range p.In().Chan {
+		key := p.keyFunc(ip)
+		now := time.Now()
+		if sentTime, ok := lastSent[key]; ok && now.Sub(sentTime) < p.window {
+			p.Workflow().Logger().Printf("| %-32s | IP with key %s already sent within the last %s, so dropping: %s\n", p.Name(), key, p.window, ip.Path())
+			continue
+		}
+		lastSent[key] = now
+		p.Out().Send(ip)
+	}
+}
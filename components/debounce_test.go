@@ -0,0 +1,66 @@
+package components
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestDebounce(t *testing.T) {
+	wf := scipipe.NewWorkflow("wf", 4)
+	// Key on the file name up to the first dot, so that a.1.txt and a.2.txt
+	// have the same key
+	keyFunc := func(ip *scipipe.FileIP) string {
+		return strings.Split(filepath.Base(ip.Path()), ".")[0]
+	}
+	src := newTimedFileSource(wf, "src", 200*time.Millisecond,
+		[]string{"a.1.txt", "a.2.txt", "b.1.txt", "a.3.txt", "b.2.txt"},
+		[]string{"a.4.txt", "b.3.txt", "b.4.txt"})
+	debounce := NewDebounce(wf, "debounce", keyFunc, 100*time.Millisecond)
+	debounce.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(debounce.Out())
+	wf.Run()
+
+	expected := []string{"a.1.txt", "b.1.txt", "a.4.txt", "b.3.txt"}
+	if !reflect.DeepEqual(col.paths(), expected) {
+		t.Errorf("Expected only the first IP per key and window to pass: %v, got: %v", expected, col.paths())
+	}
+}
+
+// timedFileSource sends IPs for each batch of paths in a quick succession,
+// pausing between the batches
+type timedFileSource struct {
+	scipipe.BaseProcess
+	pause   time.Duration
+	batches [][]string
+}
+
+func newTimedFileSource(wf *scipipe.Workflow, name string, pause time.Duration, batches ...[]string) *timedFileSource {
+	p := &timedFileSource{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		pause:       pause,
+		batches:     batches,
+	}
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+func (p *timedFileSource) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+func (p *timedFileSource) Run() {
+	defer p.CloseAllOutPorts()
+	for i, batch := range p.batches {
+		if i > 0 {
+			time.Sleep(p.pause)
+		}
+		for _, path := range batch {
+			p.Out().Send(scipipe.NewFileIP(path))
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+// DirWatcher is a process that watches a directory, and emits a FileIP on its
+// out-port each time a new file matching the glob pattern appears in it, until
+// Stop() is called. To avoid emitting files that are still being written, a
+// file is only emitted once its size and modification time have stayed the
+// same between two consecutive checks, PollInterval apart. Files already
+// present in the directory when the process starts are not emitted.
+//
+// Since SciPipe has no third-party dependencies, the directory is polled,
+// rather than watched with fsnotify.
+type DirWatcher struct {
+	scipipe.BaseProcess
+	dir          string
+	glob         string
+	PollInterval time.Duration
+	stop         chan struct{}
+	stopOnce     sync.Once
+}
+
+// NewDirWatcher returns a new initialized DirWatcher process, watching dir for
+// new files matching glob
+func NewDirWatcher(wf *scipipe.Workflow, name string, dir string, glob string) *DirWatcher {
+	if _, err := filepath.Match(glob, ""); err != nil {
+		scipipe.Failf("DirWatcher with name '%s': Invalid glob pattern '%s': %s", name, glob, err.Error())
+	}
+	p := &DirWatcher{
+		BaseProcess:  scipipe.NewBaseProcess(wf, name),
+		dir:          dir,
+		glob:         glob,
+		PollInterval: 500 * time.Millisecond,
+		stop:         make(chan struct{}),
+	}
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// Out returns the out-port, on which file IPs for new files are sent
+func (p *DirWatcher) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Stop makes the DirWatcher stop watching the directory and close its
+// out-port. It is safe to call Stop multiple times.
+func (p *DirWatcher) Stop() {
+	p.stopOnce.Do(func() { close(p.stop) })
+}
+
+// fileState is the state of a file, used to detect whether it has changed
+// between two checks
+type fileState struct {
+	size    int64
+	modTime time.Time
+}
+
+// Run runs the DirWatcher process
+func (p *DirWatcher) Run() {
+	defer p.CloseAllOutPorts()
+
+	emitted := map[string]bool{}
+	for path := range p.matchingFiles() {
+		emitted[path] = true
+	}
+	pending := map[string]fileState{}
+
+	ticker := time.NewTicker(p.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			files := p.matchingFiles()
+			paths := []string{}
+			for path := range files {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				if emitted[path] {
+					continue
+				}
+				state := files[path]
+				if prevState, ok := pending[path]; ok && prevState == state {
+					p.Workflow().Logger().Printf("%s: Sending new file %s", p.Name(), path)
+					p.Out().Send(scipipe.NewFileIP(path))
+					emitted[path] = true
+					delete(pending, path)
+					continue
+				}
+				pending[path] = state
+			}
+		}
+	}
+}
+
+// matchingFiles returns the current state of the (non-directory) files in
+// the watched directory, that match the glob pattern
+func (p *DirWatcher) matchingFiles() map[string]fileState {
+	files := map[string]fileState{}
+	matches, err := filepath.Glob(filepath.Join(p.dir, p.glob))
+	scipipe.CheckWithMsg(err, "DirWatcher: This glob pattern doesn't look right: "+p.glob)
+	for _, path := range matches {
+		fi, err := os.Stat(path)
+		if err != nil || fi.IsDir() {
+			continue
+		}
+		files[path] = fileState{size: fi.Size(), modTime: fi.ModTime()}
+	}
+	return files
+}
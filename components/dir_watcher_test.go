@@ -0,0 +1,59 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestDirWatcher(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dir_watcher_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// A file existing before the watcher starts should not be emitted
+	err = ioutil.WriteFile(filepath.Join(dir, "existing.txt"), []byte("existing\n"), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	watcher := NewDirWatcher(wf, "watcher", dir, "*.txt")
+	watcher.PollInterval = 20 * time.Millisecond
+	col := newIPCollector(wf, "collector")
+	col.In().From(watcher.Out())
+
+	done := make(chan struct{})
+	go func() {
+		wf.Run()
+		close(done)
+	}()
+	// Give the watcher time to register the existing file
+	time.Sleep(100 * time.Millisecond)
+
+	expected := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	for _, path := range expected {
+		err := ioutil.WriteFile(path, []byte("new\n"), 0644)
+		scipipe.Check(err)
+	}
+	// Files not matching the glob pattern should not be emitted
+	err = ioutil.WriteFile(filepath.Join(dir, "c.csv"), []byte("new\n"), 0644)
+	scipipe.Check(err)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(col.paths()) < len(expected) && time.Now().Before(deadline) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	watcher.Stop()
+	<-done
+
+	paths := col.paths()
+	sort.Strings(paths)
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("Expected emitted files %v, got %v", expected, paths)
+	}
+}
@@ -0,0 +1,94 @@
+package components
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/scipipe/scipipe"
+)
+
+// FieldTransformer is a process that, for each delimited file (such as a TSV
+// or CSV file) received on its in-port, applies user-provided transform
+// functions to the fields in specific columns, and writes the result to a new
+// file, sent on its out-port. Columns are numbered from 0. This is useful for
+// example for converting between 0-based and 1-based coordinates. The output
+// files are named after the input files, with ".transformed" inserted before
+// the file extension.
+type FieldTransformer struct {
+	scipipe.BaseProcess
+	columnTransforms map[int]func(string) string
+	delimiter        rune
+}
+
+// NewFieldTransformer returns a new initialized FieldTransformer process
+func NewFieldTransformer(wf *scipipe.Workflow, name string, columnTransforms map[int]func(string) string, delimiter rune) *FieldTransformer {
+	p := &FieldTransformer{
+		BaseProcess:      scipipe.NewBaseProcess(wf, name),
+		columnTransforms: columnTransforms,
+		delimiter:        delimiter,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the files to transform
+func (p *FieldTransformer) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the transformed files are sent
+func (p *FieldTransformer) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the FieldTransformer process
+func (p *FieldTransformer) Run() {
+	defer p.CloseAllOutPorts()
+
+	columns := []int{}
+	for col := range p.columnTransforms {
+		columns = append(columns, col)
+	}
+	sort.Ints(columns)
+
+	for inIP := range p.In().Chan {
+		outPath := pathWithInfix(inIP.Path(), "transformed")
+		err := p.transformFile(inIP.Path(), outPath, columns)
+		scipipe.CheckWithMsg(err, "FieldTransformer "+p.Name()+": Could not transform file "+inIP.Path())
+		p.Out().Send(scipipe.NewFileIP(outPath))
+	}
+}
+
+// transformFile applies the column transforms, for the (sorted) columns, to
+// the file at inPath, line by line, writing the result to outPath
+func (p *FieldTransformer) transformFile(inPath string, outPath string, columns []int) error {
+	inFile, err := os.Open(inPath)
+	if err != nil {
+		return errWrap(err, "Could not open file: "+inPath)
+	}
+	defer inFile.Close()
+
+	return writeStreamAtomically(outPath, func(w io.Writer) error {
+		scanner := bufio.NewScanner(inFile)
+		scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+		for scanner.Scan() {
+			if _, err := io.WriteString(w, p.transformLine(scanner.Text(), columns)+"\n"); err != nil {
+				return err
+			}
+		}
+		return scanner.Err()
+	})
+}
+
+// transformLine applies the column transforms to the fields of line, for the
+// (sorted) columns. Columns missing in the line are left as is.
+func (p *FieldTransformer) transformLine(line string, columns []int) string {
+	fields := strings.Split(line, string(p.delimiter))
+	for _, col := range columns {
+		if col >= 0 && col < len(fields) {
+			fields[col] = p.columnTransforms[col](fields[col])
+		}
+	}
+	return strings.Join(fields, string(p.delimiter))
+}
@@ -0,0 +1,72 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestFieldTransformer(t *testing.T) {
+	inPath := "/tmp/field_transformer_test.bed"
+	err := ioutil.WriteFile(inPath, []byte("chr1\t0\t100\nchr2\t9\t20\n"), 0644)
+	scipipe.Check(err)
+
+	// Convert the 0-based start coordinate in column 1 to 1-based
+	toOneBased := func(field string) string {
+		pos, err := strconv.Atoi(field)
+		scipipe.Check(err)
+		return strconv.Itoa(pos + 1)
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	transformer := NewFieldTransformer(wf, "transformer", map[int]func(string) string{1: toOneBased}, '\t')
+	transformer.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(transformer.Out())
+	wf.Run()
+
+	expectedPaths := []string{"/tmp/field_transformer_test.transformed.bed"}
+	if !reflect.DeepEqual(col.paths(), expectedPaths) {
+		t.Fatalf("Expected output files %v, got %v", expectedPaths, col.paths())
+	}
+	dat, err := ioutil.ReadFile(expectedPaths[0])
+	scipipe.Check(err)
+	expected := "chr1\t1\t100\nchr2\t10\t20\n"
+	if string(dat) != expected {
+		t.Errorf("Transformed file contained:\n%s\nExpected:\n%s", string(dat), expected)
+	}
+
+	os.Remove(inPath)
+	os.Remove(expectedPaths[0])
+}
+
+func TestFieldTransformerLongLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "field_transformer_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// Lines longer than the default max token size of bufio.Scanner (64 KiB)
+	longField := strings.Repeat("A", 200*1024)
+	inPath := filepath.Join(dir, "long.tsv")
+	err = ioutil.WriteFile(inPath, []byte("chr1\t0\t"+longField+"\n"), 0644)
+	scipipe.Check(err)
+
+	outPath := filepath.Join(dir, "long.transformed.tsv")
+	transformer := NewFieldTransformer(scipipe.NewWorkflow("wf", 4), "transformer", map[int]func(string) string{0: strings.ToUpper}, '\t')
+	err = transformer.transformFile(inPath, outPath, []int{0})
+	if err != nil {
+		t.Fatalf("Expected file with long lines to be transformed, got: %s", err.Error())
+	}
+	dat, err := ioutil.ReadFile(outPath)
+	scipipe.Check(err)
+	if string(dat) != "CHR1\t0\t"+longField+"\n" {
+		t.Errorf("Expected long line to be transformed, got %d bytes starting with %q", len(dat), string(dat[:10]))
+	}
+}
@@ -2,16 +2,27 @@ package components
 
 import (
 	"path/filepath"
+	"sort"
 
 	"github.com/scipipe/scipipe"
 )
 
 // FileGlobber is initiated with a set of glob patterns paths, which it will
 // use to find concrete file paths, for which it will return a stream of
-// corresponding File IPs on its outport Out()
+// corresponding File IPs on its outport Out(). The paths matched by each
+// pattern are sent in sorted order. If the patterns match no files at all, the
+// workflow fails, unless AllowEmpty is set.
 type FileGlobber struct {
 	scipipe.BaseProcess
 	globPatterns []string
+	// Sorted makes the globber sort the paths matched by all of its glob
+	// patterns lexically before sending them, so that files are emitted in
+	// the same order in every run. Otherwise, the matches of each pattern are
+	// sent in turn, in the order the patterns were given.
+	Sorted bool
+	// AllowEmpty makes the globber send nothing, rather than failing, when
+	// its glob patterns match no files
+	AllowEmpty bool
 }
 
 // NewFileGlobber returns a new initialized FileGlobber process
@@ -58,13 +69,21 @@ func (p *FileGlobber) Run() {
 }
 
 func (p *FileGlobber) globFiles() {
+	filePaths := []string{}
 	for _, globPtn := range p.globPatterns {
-		scipipe.Audit.Printf("%s: Globbing for files, with pattern: %s", p.Name(), globPtn)
+		p.Workflow().Logger().Printf("%s: Globbing for files, with pattern: %s", p.Name(), globPtn)
 		matches, err := filepath.Glob(globPtn)
 		scipipe.CheckWithMsg(err, "FileGlobber: This glob pattern doesn't look right: "+globPtn)
-		for _, filePath := range matches {
-			scipipe.Audit.Printf("%s: Sending concrete file %s", p.Name(), filePath)
-			p.Out().Send(scipipe.NewFileIP(filePath))
-		}
+		filePaths = append(filePaths, matches...)
+	}
+	if len(filePaths) == 0 && !p.AllowEmpty {
+		scipipe.Failf("FileGlobber %s: No files matched the glob patterns %v (set AllowEmpty to allow this)\n", p.Name(), p.globPatterns)
+	}
+	if p.Sorted {
+		sort.Strings(filePaths)
+	}
+	for _, filePath := range filePaths {
+		p.Workflow().Logger().Printf("%s: Sending concrete file %s", p.Name(), filePath)
+		p.Out().Send(scipipe.NewFileIP(filePath))
 	}
 }
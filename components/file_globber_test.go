@@ -3,10 +3,13 @@ package components
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	"io/ioutil"
 	"log"
+	"path/filepath"
+	"reflect"
 
 	"github.com/scipipe/scipipe"
 )
@@ -126,3 +129,61 @@ func TestFileGlobberDependent(t *testing.T) {
 	os.Remove("/tmp/done.txt")
 	os.Remove("/tmp/done.txt.audit.json")
 }
+
+func TestFileGlobberSorted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file_globber_sorted_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+	filePaths := []string{}
+	for _, fileName := range []string{"a1.txt", "a2.txt", "b1.txt", "b2.txt"} {
+		filePath := filepath.Join(dir, fileName)
+		err := ioutil.WriteFile(filePath, []byte(fileName), 0644)
+		scipipe.Check(err)
+		filePaths = append(filePaths, filePath)
+	}
+
+	// The b-files are matched by the first pattern, so would be sent first
+	// without sorting
+	wf := scipipe.NewWorkflow("wf", 4)
+	globber := NewFileGlobber(wf, "globber", filepath.Join(dir, "b*.txt"), filepath.Join(dir, "a*.txt"))
+	globber.Sorted = true
+	col := newIPCollector(wf, "collector")
+	col.In().From(globber.Out())
+	wf.Run()
+
+	if !reflect.DeepEqual(col.paths(), filePaths) {
+		t.Errorf("Expected files to be emitted in sorted order %v, got %v", filePaths, col.paths())
+	}
+}
+
+func TestFileGlobberAllowEmpty(t *testing.T) {
+	wf := scipipe.NewWorkflow("wf", 4)
+	globber := NewFileGlobber(wf, "globber", "/tmp/file_globber_no_such_file_*.txt")
+	globber.AllowEmpty = true
+	col := newIPCollector(wf, "collector")
+	col.In().From(globber.Out())
+	wf.Run()
+
+	if len(col.paths()) != 0 {
+		t.Errorf("Expected no files to be sent, got %v", col.paths())
+	}
+}
+
+func TestFileGlobberNoMatches(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		scipipe.InitLogError()
+		wf := scipipe.NewWorkflow("wf", 4)
+		globber := NewFileGlobber(wf, "globber", "/tmp/file_globber_no_such_file_*.txt")
+		col := newIPCollector(wf, "collector")
+		col.In().From(globber.Out())
+		wf.Run()
+		return
+	}
+
+	out := runFailingSubprocess(t)
+	if !strings.Contains(out, "No files matched the glob patterns [/tmp/file_globber_no_such_file_*.txt]") {
+		t.Errorf("Expected output to explain that no files matched, got: %s", out)
+	}
+}
@@ -80,7 +80,7 @@ func (p *FileSplitter) Run() {
 				log.Fatal(err)
 			}
 		} else {
-			scipipe.Audit.Printf("Split file already exists: %s, so skipping.\n", splitIP.Path())
+			p.Workflow().Logger().Printf("Split file already exists: %s, so skipping.\n", splitIP.Path())
 		}
 	}
 }
@@ -0,0 +1,100 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/scipipe/scipipe"
+)
+
+// FileValidator is a process that counts the lines of each file received on
+// its in-port, and fails the workflow, naming the file and its line count, if
+// the count is outside the range MinLines to MaxLines (inclusive), or if the
+// OnFile function, if set, returns an error for the file. Valid files are
+// passed through on the out-port, which is connected to the workflow sink if
+// left unconnected, so that the validator can be used both at the end and in
+// the middle of a workflow.
+type FileValidator struct {
+	scipipe.BaseProcess
+	// MinLines is the minimum number of lines of valid files. It defaults to
+	// 0.
+	MinLines int
+	// MaxLines is the maximum number of lines of valid files. It defaults to
+	// -1, for no maximum.
+	MaxLines int
+	// OnFile is called with the IP and line count of each file whose line
+	// count is within range, for custom validation, or for collecting the
+	// counts. The workflow fails if it returns an error.
+	OnFile func(ip *scipipe.FileIP, lineCount int) error
+}
+
+// NewFileValidator returns a new initialized FileValidator process, which
+// accepts files with any number of lines, until MinLines, MaxLines or OnFile
+// are set
+func NewFileValidator(wf *scipipe.Workflow, name string) *FileValidator {
+	p := &FileValidator{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		MinLines:    0,
+		MaxLines:    -1,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the files to validate are received
+func (p *FileValidator) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which valid files are sent
+func (p *FileValidator) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the FileValidator process
+func (p *FileValidator) Run() {
+	defer p.CloseAllOutPorts()
+
+	for ip := range p.In().Chan {
+		lineCount, err := countLines(ip.Path())
+		scipipe.CheckWithMsg(err, "FileValidator "+p.Name()+": Could not count lines of file "+ip.Path())
+		if err := checkLineCount(ip.Path(), lineCount, p.MinLines, p.MaxLines); err != nil {
+			scipipe.Failf("FileValidator %s: %s\n", p.Name(), err.Error())
+		}
+		if p.OnFile != nil {
+			if err := p.OnFile(ip, lineCount); err != nil {
+				scipipe.Failf("FileValidator %s: File %s with %d lines is not valid: %s\n", p.Name(), ip.Path(), lineCount, err.Error())
+			}
+		}
+		p.Out().Send(ip)
+	}
+}
+
+// countLines returns the number of lines in the file at path, counting a
+// last line without a trailing newline too
+func countLines(path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxLineLength)
+	lineCount := 0
+	for sc.Scan() {
+		lineCount++
+	}
+	return lineCount, sc.Err()
+}
+
+// checkLineCount returns an error if lineCount, of the file at path, is below
+// minLines, or above maxLines, unless maxLines is negative
+func checkLineCount(path string, lineCount int, minLines int, maxLines int) error {
+	if lineCount < minLines {
+		return fmt.Errorf("File %s has %d lines, fewer than the minimum of %d", path, lineCount, minLines)
+	}
+	if maxLines >= 0 && lineCount > maxLines {
+		return fmt.Errorf("File %s has %d lines, more than the maximum of %d", path, lineCount, maxLines)
+	}
+	return nil
+}
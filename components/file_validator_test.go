@@ -0,0 +1,70 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestCheckLineCount(t *testing.T) {
+	for _, tc := range []struct {
+		lineCount int
+		minLines  int
+		maxLines  int
+		errSubstr string
+	}{
+		{3, 0, -1, ""},
+		{3, 3, 3, ""},
+		{0, 1, -1, "has 0 lines, fewer than the minimum of 1"},
+		{11, 1, 10, "has 11 lines, more than the maximum of 10"},
+	} {
+		err := checkLineCount("counts.txt", tc.lineCount, tc.minLines, tc.maxLines)
+		if tc.errSubstr == "" && err != nil {
+			t.Errorf("Expected %d lines to be within %d-%d, got: %s", tc.lineCount, tc.minLines, tc.maxLines, err.Error())
+		}
+		if tc.errSubstr != "" && (err == nil || !strings.Contains(err.Error(), "counts.txt") || !strings.Contains(err.Error(), tc.errSubstr)) {
+			t.Errorf("Expected error containing %q for %d lines, got: %v", tc.errSubstr, tc.lineCount, err)
+		}
+	}
+}
+
+func TestFileValidator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file_validator_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	paths := []string{}
+	for name, content := range map[string]string{"one.txt": "a\n", "three.txt": "a\nb\nc"} {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, []byte(content), 0644)
+		scipipe.Check(err)
+		paths = append(paths, path)
+	}
+
+	mx := sync.Mutex{}
+	lineCounts := map[string]int{}
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	validator := NewFileValidator(wf, "validator")
+	validator.MinLines = 1
+	validator.MaxLines = 3
+	validator.OnFile = func(ip *scipipe.FileIP, lineCount int) error {
+		mx.Lock()
+		defer mx.Unlock()
+		lineCounts[filepath.Base(ip.Path())] = lineCount
+		return nil
+	}
+	validator.In().From(src.Out())
+	wf.Run()
+
+	expected := map[string]int{"one.txt": 1, "three.txt": 3}
+	if !reflect.DeepEqual(lineCounts, expected) {
+		t.Errorf("Expected line counts %v, got %v", expected, lineCounts)
+	}
+}
@@ -0,0 +1,63 @@
+package components
+
+import (
+	"hash/fnv"
+	"strconv"
+
+	"github.com/scipipe/scipipe"
+)
+
+// HashPartitioner routes each IP received on its in-port to one of a fixed
+// number of out-ports, based on a hash of a key computed for the IP by a
+// user-provided function. This guarantees that all IPs with the same key end
+// up on the same partition, which is useful for deterministic sharding, such as
+// by chromosome or sample.
+type HashPartitioner struct {
+	scipipe.BaseProcess
+	partitions int
+	keyFunc    func(*scipipe.FileIP) string
+}
+
+// NewHashPartitioner returns a new initialized HashPartitioner process, with
+// partitions number of out-ports
+func NewHashPartitioner(wf *scipipe.Workflow, name string, partitions int, keyFunc func(*scipipe.FileIP) string) *HashPartitioner {
+	if partitions < 1 {
+		scipipe.Failf("HashPartitioner with name '%s': Number of partitions must be at least 1, got %d", name, partitions)
+	}
+	p := &HashPartitioner{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		partitions:  partitions,
+		keyFunc:     keyFunc,
+	}
+	p.InitInPort(p, "in")
+	for i := 0; i < partitions; i++ {
+		p.InitOutPort(p, partitionPortName(i))
+	}
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which IPs to partition are received
+func (p *HashPartitioner) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port for partition number i (counting from 0)
+func (p *HashPartitioner) Out(i int) *scipipe.OutPort { return p.OutPort(partitionPortName(i)) }
+
+// Run runs the HashPartitioner process
+func (p *HashPartitioner) Run() {
+	defer p.CloseAllOutPorts()
+	for ip := range p.In().Chan {
+		p.Out(p.partitionFor(p.keyFunc(ip))).Send(ip)
+	}
+}
+
+// partitionFor returns the partition index for key
+func (p *HashPartitioner) partitionFor(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(p.partitions))
+}
+
+func partitionPortName(i int) string {
+	return "out_" + strconv.Itoa(i)
+}
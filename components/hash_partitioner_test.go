@@ -0,0 +1,87 @@
+package components
+
+import (
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestHashPartitioner(t *testing.T) {
+	wf := scipipe.NewWorkflow("wf", 4)
+
+	// The key is the sample name, which is the part before the first dot
+	sampleKey := func(ip *scipipe.FileIP) string {
+		return strings.Split(filepath.Base(ip.Path()), ".")[0]
+	}
+	paths := []string{"/tmp/s1.a.txt", "/tmp/s2.a.txt", "/tmp/s3.a.txt", "/tmp/s1.b.txt", "/tmp/s2.b.txt", "/tmp/s3.b.txt"}
+	src := NewFileSource(wf, "src", paths...)
+
+	partitioner := NewHashPartitioner(wf, "partitioner", 3, sampleKey)
+	partitioner.In().From(src.Out())
+
+	collector := newPartitionCollector(wf, "collector", 3, sampleKey)
+	for i := 0; i < 3; i++ {
+		collector.InPort(partitionPortName(i)).From(partitioner.Out(i))
+	}
+
+	wf.Run()
+
+	partitionsForKey := collector.partitionsForKey
+	for _, key := range []string{"s1", "s2", "s3"} {
+		if len(partitionsForKey[key]) != 1 {
+			t.Errorf("Expected IPs with key %s to end up in exactly one partition, but got: %v", key, partitionsForKey[key])
+		}
+		// The mapping should be stable for repeated lookups
+		for range [10]int{} {
+			if !partitionsForKey[key][partitioner.partitionFor(key)] {
+				t.Errorf("Partition for key %s was not stable", key)
+			}
+		}
+	}
+}
+
+// partitionCollector records in which partitions IPs with a certain key were
+// received
+type partitionCollector struct {
+	scipipe.BaseProcess
+	keyFunc          func(*scipipe.FileIP) string
+	partitionsForKey map[string]map[int]bool
+}
+
+func newPartitionCollector(wf *scipipe.Workflow, name string, partitions int, keyFunc func(*scipipe.FileIP) string) *partitionCollector {
+	p := &partitionCollector{
+		BaseProcess:      scipipe.NewBaseProcess(wf, name),
+		keyFunc:          keyFunc,
+		partitionsForKey: map[string]map[int]bool{},
+	}
+	for i := 0; i < partitions; i++ {
+		p.InitInPort(p, partitionPortName(i))
+	}
+	wf.AddProc(p)
+	return p
+}
+
+func (p *partitionCollector) Run() {
+	mx := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for i := 0; i < len(p.InPorts()); i++ {
+		wg.Add(1)
+		i := i
+		go func() {
+			defer wg.Done()
+			for ip := range p.InPort(partitionPortName(i)).Chan {
+				mx.Lock()
+				key := p.keyFunc(ip)
+				if p.partitionsForKey[key] == nil {
+					p.partitionsForKey[key] = map[int]bool{}
+				}
+				p.partitionsForKey[key][i] = true
+				mx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+}
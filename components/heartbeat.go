@@ -0,0 +1,62 @@
+package components
+
+import (
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+// Heartbeat is a background process that updates a heartbeat file with the
+// current time, every interval while the workflow runs, so that monitoring
+// systems can check that the workflow is alive, by checking that the
+// modification time of the file keeps advancing. The file is updated a last
+// time when the workflow has finished.
+type Heartbeat struct {
+	name     string
+	path     string
+	interval time.Duration
+}
+
+// NewHeartbeat returns a new initialized Heartbeat process, which updates the
+// file at path every interval while the workflow runs
+func NewHeartbeat(wf *scipipe.Workflow, name string, path string, interval time.Duration) *Heartbeat {
+	if interval <= 0 {
+		scipipe.Failf("Heartbeat with name '%s': Interval must be positive, but was %s", name, interval)
+	}
+	p := &Heartbeat{
+		name:     name,
+		path:     path,
+		interval: interval,
+	}
+	wf.AddBackgroundProc(p)
+	return p
+}
+
+// Name returns the name of the Heartbeat process
+func (p *Heartbeat) Name() string { return p.name }
+
+// Path returns the path of the heartbeat file
+func (p *Heartbeat) Path() string { return p.path }
+
+// Run runs the Heartbeat process, until stop is closed
+func (p *Heartbeat) Run(stop <-chan struct{}) {
+	p.beat()
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			p.beat()
+			return
+		case <-ticker.C:
+			p.beat()
+		}
+	}
+}
+
+// beat writes the current time to the heartbeat file
+func (p *Heartbeat) beat() {
+	err := writeFileAtomically(p.path, []byte(time.Now().Format(time.RFC3339Nano)+"\n"))
+	scipipe.CheckWithMsg(err, "Could not write heartbeat file: "+p.path)
+}
@@ -0,0 +1,60 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestHeartbeat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "heartbeat_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+	hbPath := filepath.Join(dir, "heartbeat.txt")
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	NewHeartbeat(wf, "heartbeat", hbPath, 20*time.Millisecond)
+
+	modTime := func() time.Time {
+		fi, err := os.Stat(hbPath)
+		if err != nil {
+			t.Errorf("Could not stat heartbeat file %s: %v", hbPath, err)
+			return time.Time{}
+		}
+		return fi.ModTime()
+	}
+
+	var startModTime, endModTime time.Time
+	longTask := wf.NewProc("long_task", "# {o:out}")
+	longTask.SetOut("out", filepath.Join(dir, "out.txt"))
+	longTask.CustomExecute = func(tsk *scipipe.Task) {
+		// The heartbeat process is started concurrently with the task, so
+		// give it a moment to write the first heartbeat
+		for i := 0; i < 50; i++ {
+			if _, err := os.Stat(hbPath); err == nil {
+				break
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		startModTime = modTime()
+		time.Sleep(200 * time.Millisecond)
+		endModTime = modTime()
+	}
+
+	wf.Run()
+
+	if !endModTime.After(startModTime) {
+		t.Errorf("Expected the heartbeat file's mtime to advance during the run, but it went from %v to %v", startModTime, endModTime)
+	}
+
+	// The heartbeat should have stopped when the workflow finished
+	finalModTime := modTime()
+	time.Sleep(100 * time.Millisecond)
+	if !modTime().Equal(finalModTime) {
+		t.Errorf("Expected the heartbeat file to not be updated after the workflow finished")
+	}
+}
@@ -0,0 +1,166 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/scipipe/scipipe"
+)
+
+// Interleave is a process that, for each pair of files received on its two
+// in-ports, writes a file where records of recordLines lines each are taken
+// alternately from the first and the second file. This is useful for example
+// for interleaving paired-end reads in FASTQ format, where each record is four
+// lines. The output file gets the path of the first file, with ".interleaved"
+// inserted before the file extension.
+type Interleave struct {
+	scipipe.BaseProcess
+	recordLines int
+}
+
+// NewInterleave returns a new initialized Interleave process
+func NewInterleave(wf *scipipe.Workflow, name string, recordLines int) *Interleave {
+	if recordLines < 1 {
+		scipipe.Failf("Interleave with name '%s': Number of lines per record must be at least 1, got %d", name, recordLines)
+	}
+	p := &Interleave{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		recordLines: recordLines,
+	}
+	p.InitInPort(p, "in1")
+	p.InitInPort(p, "in2")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In1 returns the in-port for the files whose records come first
+func (p *Interleave) In1() *scipipe.InPort { return p.InPort("in1") }
+
+// In2 returns the in-port for the files whose records come second
+func (p *Interleave) In2() *scipipe.InPort { return p.InPort("in2") }
+
+// Out returns the out-port on which the interleaved files are sent
+func (p *Interleave) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the Interleave process
+func (p *Interleave) Run() {
+	defer p.CloseAllOutPorts()
+	for ip1 := range p.In1().Chan {
+		ip2, ok := <-p.In2().Chan
+		if !ok {
+			scipipe.Failf("Interleave %s: Got file %s on in-port in1, but no corresponding file on in-port in2\n", p.Name(), ip1.Path())
+		}
+		outPath := interleavedPath(ip1.Path())
+		err := interleaveFiles(ip1.Path(), ip2.Path(), outPath, p.recordLines)
+		scipipe.CheckWithMsg(err, "Interleave "+p.Name()+": Could not interleave files")
+		outIP := scipipe.NewFileIP(outPath)
+		p.Out().Send(outIP)
+	}
+	if ip2, ok := <-p.In2().Chan; ok {
+		scipipe.Failf("Interleave %s: Got file %s on in-port in2, but no corresponding file on in-port in1\n", p.Name(), ip2.Path())
+	}
+}
+
+// interleavedPath returns path with ".interleaved" inserted before the file
+// extension
+func interleavedPath(path string) string {
+	return pathWithInfix(path, "interleaved")
+}
+
+// interleaveFiles interleaves the files at path1 and path2 into a file at
+// outPath. The output is first written to a temporary file, which is renamed
+// to outPath only when done.
+func interleaveFiles(path1 string, path2 string, outPath string, recordLines int) error {
+	f1, err := os.Open(path1)
+	if err != nil {
+		return errWrap(err, "Could not open file: "+path1)
+	}
+	defer f1.Close()
+	f2, err := os.Open(path2)
+	if err != nil {
+		return errWrap(err, "Could not open file: "+path2)
+	}
+	defer f2.Close()
+
+	if dir := filepath.Dir(outPath); dir != "." {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return errWrap(err, "Could not create directory: "+dir)
+		}
+	}
+	tmpPath := outPath + ".tmp"
+	outFh, err := os.Create(tmpPath)
+	if err != nil {
+		return errWrap(err, "Could not create file: "+tmpPath)
+	}
+	err = interleave(f1, f2, outFh, recordLines)
+	outFh.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return errWrapf(err, "Could not interleave %s and %s", path1, path2)
+	}
+	return os.Rename(tmpPath, outPath)
+}
+
+// interleave reads records of recordLines lines alternately from r1 and r2,
+// writing them to w. An error is returned if the inputs contain different
+// numbers of records, or if a record is incomplete.
+func interleave(r1 io.Reader, r2 io.Reader, w io.Writer, recordLines int) error {
+	br1 := bufio.NewReader(r1)
+	br2 := bufio.NewReader(r2)
+	bw := bufio.NewWriter(w)
+	for recNo := 1; ; recNo++ {
+		rec1, err1 := readRecord(br1, recordLines)
+		if err1 != nil && err1 != io.EOF {
+			return err1
+		}
+		rec2, err2 := readRecord(br2, recordLines)
+		if err2 != nil && err2 != io.EOF {
+			return err2
+		}
+		if err1 == io.EOF && err2 == io.EOF {
+			break
+		}
+		if err1 == io.EOF || err2 == io.EOF {
+			return fmt.Errorf("Inputs contain unequal numbers of records: one input ended before record %d of the other", recNo)
+		}
+		for _, line := range append(rec1, rec2...) {
+			if _, err := bw.WriteString(line); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Flush()
+}
+
+// readRecord reads recordLines lines from r. io.EOF is returned only if no
+// lines were left to read.
+func readRecord(r *bufio.Reader, recordLines int) ([]string, error) {
+	lines := []string{}
+	for len(lines) < recordLines {
+		line, err := r.ReadString('\n')
+		if line != "" {
+			if !strings.HasSuffix(line, "\n") {
+				line += "\n"
+			}
+			lines = append(lines, line)
+		}
+		if err == io.EOF {
+			if len(lines) == 0 {
+				return nil, io.EOF
+			}
+			if len(lines) < recordLines {
+				return nil, fmt.Errorf("Incomplete record at end of input: expected %d lines, got %d", recordLines, len(lines))
+			}
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return lines, nil
+}
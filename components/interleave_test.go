@@ -0,0 +1,56 @@
+package components
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestInterleave(t *testing.T) {
+	r1 := "@r1/1\nAAAA\n+\nIIII\n@r2/1\nCCCC\n+\nIIII\n"
+	r2 := "@r1/2\nTTTT\n+\nIIII\n@r2/2\nGGGG\n+\nIIII\n"
+	path1 := "/tmp/interleave_test_R1.fq"
+	path2 := "/tmp/interleave_test_R2.fq"
+	err := ioutil.WriteFile(path1, []byte(r1), 0644)
+	scipipe.Check(err)
+	err = ioutil.WriteFile(path2, []byte(r2), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src1 := NewFileSource(wf, "src1", path1)
+	src2 := NewFileSource(wf, "src2", path2)
+	il := NewInterleave(wf, "interleave", 4)
+	il.In1().From(src1.Out())
+	il.In2().From(src2.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(il.Out())
+	wf.Run()
+
+	outPaths := col.paths()
+	if len(outPaths) != 1 || outPaths[0] != "/tmp/interleave_test_R1.interleaved.fq" {
+		t.Fatalf("Expected one output file /tmp/interleave_test_R1.interleaved.fq, got: %v", outPaths)
+	}
+	out, err := ioutil.ReadFile(outPaths[0])
+	scipipe.Check(err)
+	expected := "@r1/1\nAAAA\n+\nIIII\n@r1/2\nTTTT\n+\nIIII\n@r2/1\nCCCC\n+\nIIII\n@r2/2\nGGGG\n+\nIIII\n"
+	if string(out) != expected {
+		t.Errorf("Interleaved output was:\n%s\nExpected:\n%s", string(out), expected)
+	}
+
+	for _, path := range []string{path1, path2, outPaths[0]} {
+		os.Remove(path)
+	}
+}
+
+func TestInterleaveUnequalLengths(t *testing.T) {
+	r1 := strings.NewReader("@r1/1\nAAAA\n+\nIIII\n@r2/1\nCCCC\n+\nIIII\n")
+	r2 := strings.NewReader("@r1/2\nTTTT\n+\nIIII\n")
+	err := interleave(r1, r2, &bytes.Buffer{}, 4)
+	if err == nil || !strings.Contains(err.Error(), "unequal numbers of records") {
+		t.Errorf("Expected error about unequal numbers of records, got: %v", err)
+	}
+}
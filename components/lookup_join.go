@@ -0,0 +1,127 @@
+package components
+
+import (
+	"fmt"
+
+	"github.com/scipipe/scipipe"
+)
+
+// LookupJoin is a process that annotates each delimited file received on its
+// in-port with the columns of a static lookup table, read once from
+// tablePath. Each row of the incoming files is joined with the row of the
+// lookup table with the same value in the key column, by appending the other
+// columns of the table row. Rows whose key is missing in the table get one
+// FillValue per table column instead. Header rows are joined like any other
+// rows, so that they are annotated with the header of the table, if they share
+// the name of the key column. Columns are numbered from 0, and the key column
+// is the same for the table and the incoming files. The output files are named
+// after the input files, with ".annotated" inserted before the file extension.
+type LookupJoin struct {
+	scipipe.BaseProcess
+	tablePath string
+	keyCol    int
+	delimiter rune
+	FillValue string
+}
+
+// NewLookupJoin returns a new initialized LookupJoin process, joining with the
+// table at tablePath on column keyCol, in files delimited by delimiter
+func NewLookupJoin(wf *scipipe.Workflow, name string, tablePath string, keyCol int, delimiter rune) *LookupJoin {
+	p := &LookupJoin{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		tablePath:   tablePath,
+		keyCol:      keyCol,
+		delimiter:   delimiter,
+		FillValue:   "",
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the files to annotate
+func (p *LookupJoin) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the annotated files are sent
+func (p *LookupJoin) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the LookupJoin process
+func (p *LookupJoin) Run() {
+	defer p.CloseAllOutPorts()
+
+	tableRows, err := readDelimitedFile(p.tablePath, p.delimiter)
+	scipipe.CheckWithMsg(err, "LookupJoin "+p.Name()+": Could not read lookup table "+p.tablePath)
+	table, err := newLookupTable(tableRows, p.keyCol)
+	if err != nil {
+		scipipe.Failf("LookupJoin %s: Invalid lookup table %s: %s\n", p.Name(), p.tablePath, err.Error())
+	}
+
+	for inIP := range p.In().Chan {
+		rows, err := readDelimitedFile(inIP.Path(), p.delimiter)
+		scipipe.CheckWithMsg(err, "LookupJoin "+p.Name()+": Could not read file "+inIP.Path())
+		joined, err := table.join(rows, p.FillValue)
+		if err != nil {
+			scipipe.Failf("LookupJoin %s: Could not join file %s: %s\n", p.Name(), inIP.Path(), err.Error())
+		}
+
+		outPath := pathWithInfix(inIP.Path(), "annotated")
+		err = writeFileAtomically(outPath, formatDelimited(joined, p.delimiter))
+		scipipe.CheckWithMsg(err, "LookupJoin "+p.Name()+": Could not write file "+outPath)
+		p.Out().Send(scipipe.NewFileIP(outPath))
+	}
+}
+
+// lookupTable contains the non-key columns of the rows of a lookup table, by
+// the value of their key column
+type lookupTable struct {
+	keyCol int
+	width  int
+	rows   map[string][]string
+}
+
+// newLookupTable returns a lookupTable for rows, keyed on column keyCol. All
+// rows need to have the same number of columns, and unique keys.
+func newLookupTable(rows [][]string, keyCol int) (*lookupTable, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("Table is empty")
+	}
+	width := len(rows[0])
+	if keyCol < 0 || keyCol >= width {
+		return nil, fmt.Errorf("Key column %d out of range for table with %d columns", keyCol, width)
+	}
+	table := &lookupTable{keyCol: keyCol, width: width, rows: map[string][]string{}}
+	for i, row := range rows {
+		if len(row) != width {
+			return nil, fmt.Errorf("Line %d has %d columns, but the first line has %d", i+1, len(row), width)
+		}
+		key := row[keyCol]
+		if _, ok := table.rows[key]; ok {
+			return nil, fmt.Errorf("Line %d has a duplicate key '%s'", i+1, key)
+		}
+		annotation := append([]string{}, row[:keyCol]...)
+		table.rows[key] = append(annotation, row[keyCol+1:]...)
+	}
+	return table, nil
+}
+
+// join appends the annotation columns from the table to each of rows, filling
+// in fillValue for rows with keys missing in the table
+func (lt *lookupTable) join(rows [][]string, fillValue string) ([][]string, error) {
+	joined := [][]string{}
+	for i, row := range rows {
+		if lt.keyCol >= len(row) {
+			return nil, fmt.Errorf("Line %d has no key column %d", i+1, lt.keyCol)
+		}
+		annotation, ok := lt.rows[row[lt.keyCol]]
+		if !ok {
+			annotation = make([]string, lt.width-1)
+			for j := range annotation {
+				annotation[j] = fillValue
+			}
+		}
+		joinedRow := append([]string{}, row...)
+		joined = append(joined, append(joinedRow, annotation...))
+	}
+	return joined, nil
+}
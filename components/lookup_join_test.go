@@ -0,0 +1,78 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestLookupJoin(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup_join_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	tablePath := filepath.Join(dir, "genes.tsv")
+	err = ioutil.WriteFile(tablePath, []byte("chr17\tBRCA1\tDNA repair\n"+
+		"chr17\tTP53\ttumor suppressor\n"+
+		"chr7\tEGFR\tgrowth factor receptor\n"), 0644)
+	scipipe.Check(err)
+	inPath := filepath.Join(dir, "variants.tsv")
+	err = ioutil.WriteFile(inPath, []byte("v1\tTP53\n"+
+		"v2\tKRAS\n"+
+		"v3\tBRCA1\n"), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	join := NewLookupJoin(wf, "join", tablePath, 1, '\t')
+	join.FillValue = "NA"
+	join.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(join.Out())
+	wf.Run()
+
+	expectedPath := filepath.Join(dir, "variants.annotated.tsv")
+	if !reflect.DeepEqual(col.paths(), []string{expectedPath}) {
+		t.Fatalf("Expected annotated file %s, got %v", expectedPath, col.paths())
+	}
+	annotated, err := ioutil.ReadFile(expectedPath)
+	scipipe.Check(err)
+	expected := "v1\tTP53\tchr17\ttumor suppressor\n" +
+		"v2\tKRAS\tNA\tNA\n" +
+		"v3\tBRCA1\tchr17\tDNA repair\n"
+	if string(annotated) != expected {
+		t.Errorf("Wrong annotated file.\nExpected:\n%s\nGot:\n%s", expected, string(annotated))
+	}
+}
+
+func TestNewLookupTableDuplicateKey(t *testing.T) {
+	_, err := newLookupTable([][]string{{"a", "1"}, {"b", "2"}, {"a", "3"}}, 0)
+	if err == nil {
+		t.Error("Expected lookup table with duplicate keys to be rejected")
+	}
+}
+
+func TestReadDelimitedFileLongLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lookup_join_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// Lines longer than the default max token size of bufio.Scanner (64 KiB)
+	longField := strings.Repeat("A", 200*1024)
+	path := filepath.Join(dir, "long.tsv")
+	err = ioutil.WriteFile(path, []byte("key\tvalue\nk1\t"+longField+"\n"), 0644)
+	scipipe.Check(err)
+
+	rows, err := readDelimitedFile(path, '\t')
+	if err != nil {
+		t.Fatalf("Expected file with long lines to be read, got: %s", err.Error())
+	}
+	if len(rows) != 2 || len(rows[1]) != 2 || rows[1][1] != longField {
+		t.Errorf("Expected 2 rows, with the long field in the second one, got %d rows", len(rows))
+	}
+}
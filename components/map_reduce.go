@@ -0,0 +1,155 @@
+package components
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/scipipe/scipipe"
+)
+
+// MapReduce wires up the processes for map-reduce style processing of files,
+// where each file received on its in-port is split into chunks, by a split
+// function, each chunk is processed by a map process, and the outputs of the
+// map process for all chunks of a file are merged, by a reduce function, into
+// one file, which is sent on its out-port.
+//
+// Chunk files are named after the incoming files, with a sequential chunk
+// number appended, as in: [input file path].chunk_1. Reduced files are named
+// after the incoming files, with ".reduced" inserted before the file
+// extension, and get the tags of the incoming IPs.
+//
+// MapReduce is not a process itself, but adds a splitting process, named
+// [name]_split, and a reducing process, named [name]_reduce, to the workflow.
+// The map process must send one output for every chunk it receives, which
+// processes created with NewProc do, in the order the chunks were received.
+type MapReduce struct {
+	name     string
+	splitter *mapReduceSplitter
+	reducer  *mapReduceReducer
+}
+
+// NewMapReduce returns a new initialized MapReduce, splitting files with
+// split, which returns the contents of the chunks of the file at inPath,
+// processing each chunk with mapProc, which receives chunks on the in-port
+// named mapInPort, and sends its outputs on the out-port named mapOutPort, and
+// merging the map outputs with reduce, which returns the merged contents of
+// the files at chunkPaths, given in the order of the chunks
+func NewMapReduce(wf *scipipe.Workflow, name string, split func(inPath string) ([][]byte, error), mapProc *scipipe.Process, mapInPort string, mapOutPort string, reduce func(chunkPaths []string) ([]byte, error)) *MapReduce {
+	if split == nil || reduce == nil {
+		scipipe.Failf("MapReduce with name '%s': Both a split and a reduce function must be given\n", name)
+	}
+	// The number of chunks of each file is passed from the splitter to the
+	// reducer, in the order the files are split
+	chunkCounts := make(chan int, scipipe.BUFSIZE)
+
+	splitter := &mapReduceSplitter{
+		BaseProcess: scipipe.NewBaseProcess(wf, name+"_split"),
+		split:       split,
+		chunkCounts: chunkCounts,
+	}
+	splitter.InitInPort(splitter, "in")
+	splitter.InitOutPort(splitter, "chunk")
+	splitter.InitOutPort(splitter, "orig")
+	wf.AddProc(splitter)
+
+	reducer := &mapReduceReducer{
+		BaseProcess: scipipe.NewBaseProcess(wf, name+"_reduce"),
+		reduce:      reduce,
+		chunkCounts: chunkCounts,
+	}
+	reducer.InitInPort(reducer, "mapped")
+	reducer.InitInPort(reducer, "orig")
+	reducer.InitOutPort(reducer, "out")
+	wf.AddProc(reducer)
+
+	mapProc.In(mapInPort).From(splitter.OutPort("chunk"))
+	reducer.InPort("mapped").From(mapProc.Out(mapOutPort))
+	reducer.InPort("orig").From(splitter.OutPort("orig"))
+
+	return &MapReduce{
+		name:     name,
+		splitter: splitter,
+		reducer:  reducer,
+	}
+}
+
+// Name returns the name of the MapReduce
+func (mr *MapReduce) Name() string { return mr.name }
+
+// In returns the in-port for the files to process
+func (mr *MapReduce) In() *scipipe.InPort { return mr.splitter.InPort("in") }
+
+// Out returns the out-port on which the reduced files are sent
+func (mr *MapReduce) Out() *scipipe.OutPort { return mr.reducer.OutPort("out") }
+
+// mapReduceSplitter is the process of a MapReduce splitting incoming files
+// into chunks, which are sent to the map process. The incoming IPs are
+// forwarded to the reducer, for naming and tagging the reduced files.
+type mapReduceSplitter struct {
+	scipipe.BaseProcess
+	split       func(inPath string) ([][]byte, error)
+	chunkCounts chan<- int
+}
+
+// Run runs the mapReduceSplitter process
+func (p *mapReduceSplitter) Run() {
+	defer p.CloseAllOutPorts()
+	defer close(p.chunkCounts)
+
+	for inIP := range p.InPort("in").Chan {
+		chunks, err := p.split(inIP.Path())
+		scipipe.CheckWithMsg(err, "MapReduce "+p.Name()+": Could not split file "+inIP.Path())
+		// The count is passed before the chunks are sent, so that the
+		// reducer never waits for it while the map process waits for the
+		// reducer to receive its outputs
+		p.chunkCounts <- len(chunks)
+		p.OutPort("orig").Send(inIP)
+		for i, chunk := range chunks {
+			chunkPath := inIP.Path() + fmt.Sprintf(".chunk_%d", i+1)
+			err := writeFileAtomically(chunkPath, chunk)
+			scipipe.CheckWithMsg(err, "MapReduce "+p.Name()+": Could not write chunk file "+chunkPath)
+			chunkIP := scipipe.NewFileIP(chunkPath)
+			chunkIP.AddTags(inIP.Tags())
+			p.OutPort("chunk").Send(chunkIP)
+		}
+	}
+}
+
+// mapReduceReducer is the process of a MapReduce merging the map outputs for
+// all chunks of each incoming file
+type mapReduceReducer struct {
+	scipipe.BaseProcess
+	reduce      func(chunkPaths []string) ([]byte, error)
+	chunkCounts <-chan int
+}
+
+// Run runs the mapReduceReducer process
+func (p *mapReduceReducer) Run() {
+	defer p.CloseAllOutPorts()
+
+	for chunkCount := range p.chunkCounts {
+		origIP := <-p.InPort("orig").Chan
+		chunkPaths := []string{}
+		for i := 0; i < chunkCount; i++ {
+			mappedIP, ok := <-p.InPort("mapped").Chan
+			if !ok {
+				scipipe.Failf("MapReduce %s: Map process sent fewer outputs than the %d chunks of file %s\n", p.Name(), chunkCount, origIP.Path())
+			}
+			chunkPaths = append(chunkPaths, mappedIP.Path())
+		}
+
+		outPath := pathWithInfix(origIP.Path(), "reduced")
+		if _, err := os.Stat(outPath); err == nil {
+			p.Workflow().Logger().Printf("| %-32s | Reduced file already exists, so skipping: %s\n", p.Name(), outPath)
+		} else {
+			reduced, err := p.reduce(chunkPaths)
+			scipipe.CheckWithMsg(err, "MapReduce "+p.Name()+": Could not reduce map outputs for file "+origIP.Path())
+			err = writeFileAtomically(outPath, reduced)
+			scipipe.CheckWithMsg(err, "MapReduce "+p.Name()+": Could not write file "+outPath)
+		}
+		outIP := scipipe.NewFileIP(outPath)
+		outIP.AddTags(origIP.Tags())
+		outIP.WriteAuditLogToFile()
+		p.OutPort("out").Send(outIP)
+	}
+}
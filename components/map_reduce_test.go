@@ -0,0 +1,82 @@
+package components
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestMapReduce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "map_reduce_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+	inPath := filepath.Join(dir, "words.txt")
+	err = ioutil.WriteFile(inPath, []byte("a b c\nd e\nf\ng h i j\nk\n"), 0644)
+	scipipe.Check(err)
+
+	// Split into chunks of two lines each
+	split := func(inPath string) ([][]byte, error) {
+		dat, err := ioutil.ReadFile(inPath)
+		if err != nil {
+			return nil, err
+		}
+		lines := bytes.SplitAfter(bytes.TrimSuffix(dat, []byte("\n")), []byte("\n"))
+		chunks := [][]byte{}
+		for i := 0; i < len(lines); i += 2 {
+			end := i + 2
+			if end > len(lines) {
+				end = len(lines)
+			}
+			chunks = append(chunks, bytes.Join(lines[i:end], nil))
+		}
+		return chunks, nil
+	}
+	// Sum the word counts of all chunks
+	reduce := func(chunkPaths []string) ([]byte, error) {
+		total := 0
+		for _, chunkPath := range chunkPaths {
+			dat, err := ioutil.ReadFile(chunkPath)
+			if err != nil {
+				return nil, err
+			}
+			count, err := strconv.Atoi(strings.TrimSpace(string(dat)))
+			if err != nil {
+				return nil, err
+			}
+			total += count
+		}
+		return []byte(strconv.Itoa(total) + "\n"), nil
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	wordCount := wf.NewProc("word_count", "wc -w < {i:in} > {o:out}")
+	wordCount.SetOut("out", "{i:in}.count")
+	mr := NewMapReduce(wf, "mapreduce", split, wordCount, "in", "out", reduce)
+	mr.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(mr.Out())
+	wf.Run()
+
+	reducedPath := filepath.Join(dir, "words.reduced.txt")
+	if !reflect.DeepEqual(col.paths(), []string{reducedPath}) {
+		t.Fatalf("Expected the reduced file %s to be sent, got %v", reducedPath, col.paths())
+	}
+	reduced, err := ioutil.ReadFile(reducedPath)
+	scipipe.Check(err)
+	if string(reduced) != "11\n" {
+		t.Errorf("Expected merged word count 11, got %q", reduced)
+	}
+	chunkPaths, err := filepath.Glob(inPath + ".chunk_*.count")
+	scipipe.Check(err)
+	if len(chunkPaths) != 3 {
+		t.Errorf("Expected word counts for 3 chunks, got %v", chunkPaths)
+	}
+}
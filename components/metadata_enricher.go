@@ -0,0 +1,48 @@
+package components
+
+import (
+	"github.com/scipipe/scipipe"
+)
+
+// MetadataEnricher is a process that, for each IP received on its in-port,
+// calls a user-provided lookup function, and merges the key/value pairs it
+// returns into the tags of the IP, before sending it on the out-port. This is
+// useful for example for adding metadata from externally stored sample sheets.
+// If the lookup function returns an error, the workflow fails.
+type MetadataEnricher struct {
+	scipipe.BaseProcess
+	lookup func(ip *scipipe.FileIP) (map[string]string, error)
+}
+
+// NewMetadataEnricher returns an initialized MetadataEnricher process
+func NewMetadataEnricher(wf *scipipe.Workflow, name string, lookup func(ip *scipipe.FileIP) (map[string]string, error)) *MetadataEnricher {
+	p := &MetadataEnricher{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		lookup:      lookup,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which IPs to enrich with metadata are received
+func (p *MetadataEnricher) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which IPs supplemented with metadata tags are
+// sent
+func (p *MetadataEnricher) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the MetadataEnricher process
+func (p *MetadataEnricher) Run() {
+	defer p.CloseAllOutPorts()
+	for ip := range p.In().Chan {
+		metadata, err := p.lookup(ip)
+		if err != nil {
+			scipipe.Failf("MetadataEnricher %s: Could not look up metadata for %s: %s\n", p.Name(), ip.Path(), err.Error())
+		}
+		ip.AddTags(metadata)
+		ip.WriteAuditLogToFile()
+		p.Out().Send(ip)
+	}
+}
@@ -0,0 +1,44 @@
+package components
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestMetadataEnricher(t *testing.T) {
+	sampleSheet := map[string]map[string]string{
+		"sample_a.fq": {"sample": "a", "condition": "treated"},
+		"sample_b.fq": {"sample": "b", "condition": "control"},
+	}
+	paths := []string{"/tmp/sample_a.fq", "/tmp/sample_b.fq"}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	enricher := NewMetadataEnricher(wf, "enricher", func(ip *scipipe.FileIP) (map[string]string, error) {
+		metadata, ok := sampleSheet[filepath.Base(ip.Path())]
+		if !ok {
+			return nil, errors.New("No metadata found for " + ip.Path())
+		}
+		return metadata, nil
+	})
+	enricher.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(enricher.Out())
+	wf.Run()
+
+	if len(col.ips) != len(paths) {
+		t.Fatalf("Expected %d IPs, got %d", len(paths), len(col.ips))
+	}
+	for _, ip := range col.ips {
+		for k, v := range sampleSheet[filepath.Base(ip.Path())] {
+			if ip.Tag(k) != v {
+				t.Errorf("Expected tag %s of %s to be '%s', but was '%s'", k, ip.Path(), v, ip.Tag(k))
+			}
+		}
+		os.Remove(ip.AuditFilePath())
+	}
+}
@@ -0,0 +1,152 @@
+package components
+
+import (
+	"os"
+	"os/exec"
+	"sync"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+// --------------------------------------------------------------------------------
+// Testing Helper stuff
+// --------------------------------------------------------------------------------
+
+// ipCollector is a process that collects all IPs received on its in-port, in
+// the order they were received, for inspection in tests
+type ipCollector struct {
+	scipipe.BaseProcess
+	mx  sync.Mutex
+	ips []*scipipe.FileIP
+}
+
+func newIPCollector(wf *scipipe.Workflow, name string) *ipCollector {
+	p := &ipCollector{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+	}
+	p.InitInPort(p, "in")
+	wf.AddProc(p)
+	return p
+}
+
+func (p *ipCollector) In() *scipipe.InPort { return p.InPort("in") }
+
+func (p *ipCollector) Run() {
+	for ip := range p.In().Chan {
+		p.mx.Lock()
+		p.ips = append(p.ips, ip)
+		p.mx.Unlock()
+	}
+}
+
+func (p *ipCollector) paths() []string {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	paths := []string{}
+	for _, ip := range p.ips {
+		paths = append(paths, ip.Path())
+	}
+	return paths
+}
+
+// paramCollector is a process that collects all parameters received on its
+// param in-port, in the order they were received, for inspection in tests
+type paramCollector struct {
+	scipipe.BaseProcess
+	mx     sync.Mutex
+	params []string
+}
+
+func newParamCollector(wf *scipipe.Workflow, name string) *paramCollector {
+	p := &paramCollector{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+	}
+	p.InitInParamPort(p, "in")
+	wf.AddProc(p)
+	return p
+}
+
+func (p *paramCollector) In() *scipipe.InParamPort { return p.InParamPort("in") }
+
+func (p *paramCollector) Run() {
+	for param := range p.In().Chan {
+		p.mx.Lock()
+		p.params = append(p.params, param)
+		p.mx.Unlock()
+	}
+}
+
+// portCollector is a process that collects the IPs received on each of
+// multiple in-ports, for inspection in tests of processes with multiple
+// out-ports
+type portCollector struct {
+	scipipe.BaseProcess
+	portNames []string
+	mx        sync.Mutex
+	ips       map[string][]*scipipe.FileIP
+}
+
+func newPortCollector(wf *scipipe.Workflow, name string, portNames ...string) *portCollector {
+	p := &portCollector{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		portNames:   portNames,
+		ips:         map[string][]*scipipe.FileIP{},
+	}
+	for _, portName := range portNames {
+		p.InitInPort(p, portName)
+	}
+	wf.AddProc(p)
+	return p
+}
+
+func (p *portCollector) Run() {
+	wg := sync.WaitGroup{}
+	for _, portName := range p.portNames {
+		wg.Add(1)
+		go func(portName string) {
+			defer wg.Done()
+			for ip := range p.InPort(portName).Chan {
+				p.mx.Lock()
+				p.ips[portName] = append(p.ips[portName], ip)
+				p.mx.Unlock()
+			}
+		}(portName)
+	}
+	wg.Wait()
+}
+
+func (p *portCollector) paths(portName string) []string {
+	p.mx.Lock()
+	defer p.mx.Unlock()
+	paths := []string{}
+	for _, ip := range p.ips[portName] {
+		paths = append(paths, ip.Path())
+	}
+	return paths
+}
+
+// subprocessTestEnvVar is set to the name of the test being run in a
+// sub-process of the test binary, by runFailingSubprocess
+const subprocessTestEnvVar = "SCIPIPE_TEST_SUBPROCESS"
+
+// inSubprocess tells whether the test t is being run in a sub-process of the
+// test binary, by runFailingSubprocess
+func inSubprocess(t *testing.T) bool {
+	return os.Getenv(subprocessTestEnvVar) == t.Name()
+}
+
+// runFailingSubprocess runs the test t in a sub-process of the test binary,
+// for testing failures that exit the process, such as failing workflows, and
+// returns its combined output. The test fails if the sub-process does not exit
+// with a non-zero exit code. Extra environment variables, on the form
+// KEY=value, can be passed to the sub-process in env.
+func runFailingSubprocess(t *testing.T, env ...string) string {
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(append(os.Environ(), subprocessTestEnvVar+"="+t.Name()), env...)
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.Success() {
+		t.Fatalf("Expected %s to fail with a non-zero exit code in a sub-process, got error: %v\nOutput: %s", t.Name(), err, out)
+	}
+	return string(out)
+}
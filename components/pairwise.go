@@ -0,0 +1,82 @@
+package components
+
+import (
+	"sync"
+
+	"github.com/scipipe/scipipe"
+)
+
+// Pairwise is a process that, for each IP with a sub-stream received on its
+// in-port, creates all unordered pairs of the files on the sub-stream, for
+// pairwise comparisons in a downstream process. For each pair, the first file
+// is sent on the out-port OutFirst() and the second on OutSecond(), so that
+// connecting them to two in-ports of a downstream process gives one task per
+// pair. For the files [a b c], the pairs are (a, b), (a, c) and (b, c), in
+// that order. Files are not paired with themselves.
+type Pairwise struct {
+	scipipe.BaseProcess
+}
+
+// NewPairwise returns a new initialized Pairwise process
+func NewPairwise(wf *scipipe.Workflow, name string) *Pairwise {
+	p := &Pairwise{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "first")
+	p.InitOutPort(p, "second")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for sub-stream IPs, whose files to pair
+func (p *Pairwise) In() *scipipe.InPort { return p.InPort("in") }
+
+// OutFirst returns the out-port on which the first file of each pair is sent
+func (p *Pairwise) OutFirst() *scipipe.OutPort { return p.OutPort("first") }
+
+// OutSecond returns the out-port on which the second file of each pair is
+// sent
+func (p *Pairwise) OutSecond() *scipipe.OutPort { return p.OutPort("second") }
+
+// Run runs the Pairwise process
+func (p *Pairwise) Run() {
+	defer p.CloseAllOutPorts()
+
+	for subStreamIP := range p.In().Chan {
+		ips := []*scipipe.FileIP{}
+		for ip := range subStreamIP.SubStream.Chan {
+			ips = append(ips, ip)
+		}
+		firsts, seconds := pairs(ips)
+
+		// Send on both out-ports concurrently, so that downstream processes
+		// receiving on them in any order don't block
+		wg := &sync.WaitGroup{}
+		for _, portIPs := range []struct {
+			outPort *scipipe.OutPort
+			ips     []*scipipe.FileIP
+		}{{p.OutFirst(), firsts}, {p.OutSecond(), seconds}} {
+			wg.Add(1)
+			go func(outPort *scipipe.OutPort, ips []*scipipe.FileIP) {
+				defer wg.Done()
+				for _, ip := range ips {
+					outPort.Send(ip)
+				}
+			}(portIPs.outPort, portIPs.ips)
+		}
+		wg.Wait()
+	}
+}
+
+// pairs returns all unordered pairs of ips, as the first and the second IPs
+// of each pair, in separate slices
+func pairs(ips []*scipipe.FileIP) (firsts []*scipipe.FileIP, seconds []*scipipe.FileIP) {
+	for i := 0; i < len(ips); i++ {
+		for j := i + 1; j < len(ips); j++ {
+			firsts = append(firsts, ips[i])
+			seconds = append(seconds, ips[j])
+		}
+	}
+	return firsts, seconds
+}
@@ -0,0 +1,65 @@
+package components
+
+import (
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestPairwise(t *testing.T) {
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := newSubStreamSource(wf, "src", "a.txt", "b.txt", "c.txt", "d.txt")
+	pairwise := NewPairwise(wf, "pairwise")
+	pairwise.In().From(src.Out())
+
+	mx := sync.Mutex{}
+	compared := []string{}
+	compare := wf.NewProc("compare", "# {i:first} {i:second}")
+	compare.In("first").From(pairwise.OutFirst())
+	compare.In("second").From(pairwise.OutSecond())
+	compare.CustomExecute = func(tsk *scipipe.Task) {
+		mx.Lock()
+		compared = append(compared, tsk.InPath("first")+"-"+tsk.InPath("second"))
+		mx.Unlock()
+	}
+	wf.Run()
+
+	sort.Strings(compared)
+	expected := []string{"a.txt-b.txt", "a.txt-c.txt", "a.txt-d.txt", "b.txt-c.txt", "b.txt-d.txt", "c.txt-d.txt"}
+	if !reflect.DeepEqual(compared, expected) {
+		t.Errorf("Expected the C(4,2)=6 pairs %v, got %v", expected, compared)
+	}
+}
+
+// subStreamSource is a process that sends one IP, with IPs for filePaths on its
+// sub-stream
+type subStreamSource struct {
+	scipipe.BaseProcess
+	filePaths []string
+}
+
+func newSubStreamSource(wf *scipipe.Workflow, name string, filePaths ...string) *subStreamSource {
+	p := &subStreamSource{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		filePaths:   filePaths,
+	}
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+func (p *subStreamSource) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+func (p *subStreamSource) Run() {
+	defer p.CloseAllOutPorts()
+	subStreamIP := scipipe.NewFileIP("/tmp/scipipe_substream_dummyfile")
+	subStreamIP.SubStream.Chan = make(chan *scipipe.FileIP, len(p.filePaths))
+	for _, path := range p.filePaths {
+		subStreamIP.SubStream.Chan <- scipipe.NewFileIP(path)
+	}
+	close(subStreamIP.SubStream.Chan)
+	p.Out().Send(subStreamIP)
+}
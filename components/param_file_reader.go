@@ -0,0 +1,108 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/scipipe/scipipe"
+)
+
+// ParamFileReader is a process that reads the rows of a delimited (such as
+// TSV or CSV) file, and sends the values of the columns on param out-ports
+// named after the columns, such as for driving a workflow from a file of
+// sample metadata. The n:th value sent on each out-port together make up the
+// n:th row, so that a downstream process receiving on several of them creates
+// one task per row.
+//
+// Columns are named by the header (first line) of the file, if it has one,
+// and otherwise by their 1-based position, as in col1, col2 and so on. Only
+// the columns for which out-ports have been created with OutParam are sent.
+// The workflow fails, with the line number, if a row has a different number of
+// columns than the first one, and empty lines are skipped.
+type ParamFileReader struct {
+	scipipe.BaseProcess
+	path      string
+	hasHeader bool
+	// Delimiter is the delimiter between the columns of the file. It defaults
+	// to tab.
+	Delimiter rune
+}
+
+// NewParamFileReader returns a new initialized ParamFileReader process,
+// reading the delimited file at path, with column names in the first line if
+// hasHeader is true
+func NewParamFileReader(wf *scipipe.Workflow, name string, path string, hasHeader bool) *ParamFileReader {
+	p := &ParamFileReader{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		path:        path,
+		hasHeader:   hasHeader,
+		Delimiter:   '\t',
+	}
+	wf.AddProc(p)
+	return p
+}
+
+// OutParam returns the param out-port for the column named column, which is
+// created if it does not already exist
+func (p *ParamFileReader) OutParam(column string) *scipipe.OutParamPort {
+	if _, ok := p.OutParamPorts()[column]; !ok {
+		p.InitOutParamPort(p, column)
+	}
+	return p.OutParamPort(column)
+}
+
+// Run runs the ParamFileReader process
+func (p *ParamFileReader) Run() {
+	defer p.CloseAllOutPorts()
+
+	f, err := os.Open(p.path)
+	scipipe.CheckWithMsg(err, "ParamFileReader "+p.Name()+": Could not open file "+p.path)
+	defer f.Close()
+
+	var columns []string
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxLineLength)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		if strings.TrimSpace(sc.Text()) == "" {
+			continue
+		}
+		fields := strings.Split(strings.TrimRight(sc.Text(), "\r"), string(p.Delimiter))
+		if columns == nil {
+			columns = p.columnNames(fields)
+			if p.hasHeader {
+				continue
+			}
+		}
+		if len(fields) != len(columns) {
+			scipipe.Failf("ParamFileReader %s: Line %d of file %s has %d columns, but expected %d\n", p.Name(), lineNo, p.path, len(fields), len(columns))
+		}
+		for i, column := range columns {
+			if pop, ok := p.OutParamPorts()[column]; ok {
+				pop.Send(fields[i])
+			}
+		}
+	}
+	scipipe.CheckWithMsg(sc.Err(), "ParamFileReader "+p.Name()+": Could not read file "+p.path)
+}
+
+// columnNames returns the names of the columns, given the fields of the first
+// line, and fails if an out-port has been created for a column that does not
+// exist
+func (p *ParamFileReader) columnNames(fields []string) []string {
+	columns := []string{}
+	for i, field := range fields {
+		if p.hasHeader {
+			columns = append(columns, field)
+		} else {
+			columns = append(columns, fmt.Sprintf("col%d", i+1))
+		}
+	}
+	for portName := range p.OutParamPorts() {
+		if !containsString(columns, portName) {
+			scipipe.Failf("ParamFileReader %s: No column named '%s' in file %s (columns: %v)\n", p.Name(), portName, p.path, columns)
+		}
+	}
+	return columns
+}
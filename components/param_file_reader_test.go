@@ -0,0 +1,108 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestParamFileReader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "param_file_reader_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	tsvPath := filepath.Join(dir, "samples.tsv")
+	err = ioutil.WriteFile(tsvPath, []byte("sample\tgenome\tlane\ns1\thg19\t1\ns2\thg38\t2\n\ns3\thg38\t1\n"), 0644)
+	scipipe.Check(err)
+	csvPath := filepath.Join(dir, "samples.csv")
+	err = ioutil.WriteFile(csvPath, []byte("s1,hg19\ns2,hg38\n"), 0644)
+	scipipe.Check(err)
+
+	for _, tc := range []struct {
+		name      string
+		path      string
+		hasHeader bool
+		delimiter rune
+		sample    string
+		genome    string
+		expected  []string
+	}{
+		{"header", tsvPath, true, '\t', "sample", "genome", []string{"s1.hg19", "s2.hg38", "s3.hg38"}},
+		{"no_header", csvPath, false, ',', "col1", "col2", []string{"s1.hg19", "s2.hg38"}},
+	} {
+		wf := scipipe.NewWorkflow("wf", 4)
+		reader := NewParamFileReader(wf, "reader", tc.path, tc.hasHeader)
+		reader.Delimiter = tc.delimiter
+		// One task is created per row
+		echo := wf.NewProc("echo", "echo {p:sample} > {o:out}")
+		echo.InParam("sample").From(reader.OutParam(tc.sample))
+		echo.InParam("genome").From(reader.OutParam(tc.genome))
+		echo.SetOut("out", filepath.Join(dir, tc.name, "{p:sample}.{p:genome}"))
+		col := newIPCollector(wf, "collector")
+		col.In().From(echo.Out("out"))
+		wf.Run()
+
+		names := []string{}
+		for _, path := range col.paths() {
+			names = append(names, filepath.Base(path))
+		}
+		sort.Strings(names)
+		if !reflect.DeepEqual(names, tc.expected) {
+			t.Errorf("Expected one output per row %v for case %s, got: %v", tc.expected, tc.name, names)
+		}
+	}
+}
+
+func TestParamFileReaderLongLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "param_file_reader_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// Lines longer than the default max token size of bufio.Scanner (64 KiB)
+	longValue := strings.Repeat("A", 200*1024)
+	path := filepath.Join(dir, "long.tsv")
+	err = ioutil.WriteFile(path, []byte("sample\tnotes\ns1\t"+longValue+"\n"), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	reader := NewParamFileReader(wf, "reader", path, true)
+	pc := newParamCollector(wf, "collector")
+	pc.In().From(reader.OutParam("notes"))
+	wf.Run()
+
+	if len(pc.params) != 1 || pc.params[0] != longValue {
+		t.Errorf("Expected the long value to be read as one parameter, got %d parameters", len(pc.params))
+	}
+}
+
+func TestParamFileReaderRaggedRow(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		path := os.Getenv("SCIPIPE_TEST_PARAM_FILE_READER_PATH")
+		wf := scipipe.NewWorkflow("wf", 4)
+		reader := NewParamFileReader(wf, "reader", path, true)
+		pc := newParamCollector(wf, "collector")
+		pc.In().From(reader.OutParam("sample"))
+		wf.Run()
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "param_file_reader_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ragged.tsv")
+	err = ioutil.WriteFile(path, []byte("sample\tgenome\ns1\thg19\ns2\n"), 0644)
+	scipipe.Check(err)
+
+	out := runFailingSubprocess(t, "SCIPIPE_TEST_PARAM_FILE_READER_PATH="+path)
+	if !strings.Contains(out, "Line 3 of file "+path+" has 1 columns, but expected 2") {
+		t.Errorf("Expected error naming the line of the ragged row, got:\n%s", out)
+	}
+}
@@ -0,0 +1,81 @@
+package components
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/scipipe/scipipe"
+)
+
+// ParamSweep is a process that sends every combination in the cartesian
+// product of the value lists it is initialized with, for use in grid searches.
+// Each parameter gets a param out-port with the same name as the parameter,
+// and the n:th value sent on each out-port together make up the n:th
+// combination, so that a downstream process receiving on all of them creates
+// one task per combination. Combinations are sent in the order of the
+// parameter names, with the values of the first parameter changing the
+// slowest.
+type ParamSweep struct {
+	scipipe.BaseProcess
+	params map[string][]string
+}
+
+// NewParamSweep returns a new initialized ParamSweep process, sending all
+// combinations of the values in params, by parameter name
+func NewParamSweep(wf *scipipe.Workflow, name string, params map[string][]string) *ParamSweep {
+	if len(params) == 0 {
+		scipipe.Failf("ParamSweep with name '%s': No parameters given to sweep over\n", name)
+	}
+	p := &ParamSweep{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		params:      params,
+	}
+	for paramName := range params {
+		p.InitOutParamPort(p, paramName)
+	}
+	wf.AddProc(p)
+	return p
+}
+
+// OutParam returns the param out-port for the parameter named paramName
+func (p *ParamSweep) OutParam(paramName string) *scipipe.OutParamPort {
+	return p.OutParamPort(paramName)
+}
+
+// Count returns the number of combinations the process will send, which is
+// the product of the number of values of all parameters
+func (p *ParamSweep) Count() int {
+	count := 1
+	for _, values := range p.params {
+		count *= len(values)
+	}
+	return count
+}
+
+// Run runs the ParamSweep process
+func (p *ParamSweep) Run() {
+	defer p.CloseAllOutPorts()
+	if p.Count() == 0 {
+		return
+	}
+
+	paramNames := []string{}
+	for paramName := range p.params {
+		paramNames = append(paramNames, paramName)
+	}
+	sort.Strings(paramNames)
+
+	// The out-ports are sent on concurrently, so that a downstream process
+	// reading them in any order does not block the sweep
+	wg := &sync.WaitGroup{}
+	for paramName, values := range combine(p.params, paramNames) {
+		wg.Add(1)
+		go func(paramName string, values []string) {
+			defer wg.Done()
+			for _, value := range values {
+				p.OutParam(paramName).Send(value)
+			}
+		}(paramName, values)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,52 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestParamSweep(t *testing.T) {
+	dir, err := ioutil.TempDir("", "param_sweep_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	alphas := []string{"0.1", "0.5"}
+	kernels := []string{"linear", "rbf", "poly"}
+	wf := scipipe.NewWorkflow("wf", 4)
+	sweep := NewParamSweep(wf, "sweep", map[string][]string{
+		"alpha":  alphas,
+		"kernel": kernels,
+	})
+	if sweep.Count() != 6 {
+		t.Errorf("Expected 6 combinations, got %d", sweep.Count())
+	}
+
+	train := wf.NewProc("train", "echo {p:alpha} {p:kernel} > {o:model}")
+	train.InParam("alpha").From(sweep.OutParam("alpha"))
+	train.InParam("kernel").From(sweep.OutParam("kernel"))
+	train.SetOut("model", filepath.Join(dir, "model_{p:alpha}_{p:kernel}.txt"))
+	wf.Run()
+
+	for _, alpha := range alphas {
+		for _, kernel := range kernels {
+			modelPath := filepath.Join(dir, "model_"+alpha+"_"+kernel+".txt")
+			model, err := ioutil.ReadFile(modelPath)
+			if err != nil {
+				t.Errorf("Expected a task to be run for alpha %s and kernel %s, but found no model: %s", alpha, kernel, err.Error())
+				continue
+			}
+			if string(model) != alpha+" "+kernel+"\n" {
+				t.Errorf("Expected model %s to be trained with alpha %s and kernel %s, got %q", modelPath, alpha, kernel, model)
+			}
+		}
+	}
+	models, err := filepath.Glob(filepath.Join(dir, "model_*.txt"))
+	scipipe.Check(err)
+	if len(models) != sweep.Count() {
+		t.Errorf("Expected one task per combination (%d), got %d models", sweep.Count(), len(models))
+	}
+}
@@ -0,0 +1,53 @@
+package components
+
+import (
+	"github.com/scipipe/scipipe"
+)
+
+// Partition is a process that routes each IP received on its in-port to one
+// of two out-ports, depending on the result of a user-provided predicate
+// function: IPs for which the predicate returns true are sent on the pass
+// out-port, and the others on the fail out-port. This is useful for example
+// for separating files that need extra processing, such as files larger than
+// some threshold. For routing to more than two out-ports, see HashPartitioner.
+type Partition struct {
+	scipipe.BaseProcess
+	pred func(*scipipe.FileIP) bool
+}
+
+// NewPartition returns a new initialized Partition process, routing IPs by
+// pred
+func NewPartition(wf *scipipe.Workflow, name string, pred func(*scipipe.FileIP) bool) *Partition {
+	p := &Partition{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		pred:        pred,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "pass")
+	p.InitOutPort(p, "fail")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which IPs to partition are received
+func (p *Partition) In() *scipipe.InPort { return p.InPort("in") }
+
+// OutPass returns the out-port on which IPs for which the predicate returns
+// true are sent
+func (p *Partition) OutPass() *scipipe.OutPort { return p.OutPort("pass") }
+
+// OutFail returns the out-port on which IPs for which the predicate returns
+// false are sent
+func (p *Partition) OutFail() *scipipe.OutPort { return p.OutPort("fail") }
+
+// Run runs the Partition process
+func (p *Partition) Run() {
+	defer p.CloseAllOutPorts()
+	for ip := range p.In().Chan {
+		if p.pred(ip) {
+			p.OutPass().Send(ip)
+		} else {
+			p.OutFail().Send(ip)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package components
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestPartition(t *testing.T) {
+	dir, err := ioutil.TempDir("", "partition_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	sizes := map[string]int{"small_a.txt": 10, "large_a.txt": 2000, "small_b.txt": 100, "large_b.txt": 1001}
+	paths := []string{}
+	for _, name := range []string{"small_a.txt", "large_a.txt", "small_b.txt", "large_b.txt"} {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, bytes.Repeat([]byte("x"), sizes[name]), 0644)
+		scipipe.Check(err)
+		paths = append(paths, path)
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	partition := NewPartition(wf, "partition", func(ip *scipipe.FileIP) bool {
+		return ip.Size() > 1000
+	})
+	partition.In().From(src.Out())
+	col := newPortCollector(wf, "collector", "large", "small")
+	col.InPort("large").From(partition.OutPass())
+	col.InPort("small").From(partition.OutFail())
+	wf.Run()
+
+	expectedLarge := []string{filepath.Join(dir, "large_a.txt"), filepath.Join(dir, "large_b.txt")}
+	if !reflect.DeepEqual(col.paths("large"), expectedLarge) {
+		t.Errorf("Expected files %v on the pass port, got %v", expectedLarge, col.paths("large"))
+	}
+	expectedSmall := []string{filepath.Join(dir, "small_a.txt"), filepath.Join(dir, "small_b.txt")}
+	if !reflect.DeepEqual(col.paths("small"), expectedSmall) {
+		t.Errorf("Expected files %v on the fail port, got %v", expectedSmall, col.paths("small"))
+	}
+}
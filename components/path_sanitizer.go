@@ -0,0 +1,108 @@
+package components
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/scipipe/scipipe"
+)
+
+// unsafePathChars matches characters that are not safe to use unquoted in
+// shell commands
+var unsafePathChars = regexp.MustCompile(`[^A-Za-z0-9._+-]`)
+
+// PathSanitizer is a process that makes the files received on its in-port
+// safe to use in shell commands without quoting, by hard-linking them to
+// sanitized names in a destination directory, and sending IPs for the linked
+// files on its out-port, with the tags of the incoming IPs. In the sanitized
+// names, spaces and shell-special characters in the file name are replaced
+// by underscores. Files that can not be hard-linked, such as when the
+// destination directory is on another file system, are copied. The workflow
+// fails if two files with different content get the same sanitized name.
+type PathSanitizer struct {
+	scipipe.BaseProcess
+	destDir string
+}
+
+// NewPathSanitizer returns a new initialized PathSanitizer process, linking
+// files to sanitized names in the directory destDir, which should itself be
+// safe to use in shell commands
+func NewPathSanitizer(wf *scipipe.Workflow, name string, destDir string) *PathSanitizer {
+	p := &PathSanitizer{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		destDir:     destDir,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the files to sanitize the paths of are
+// received
+func (p *PathSanitizer) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the files with sanitized paths are sent
+func (p *PathSanitizer) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the PathSanitizer process
+func (p *PathSanitizer) Run() {
+	defer p.CloseAllOutPorts()
+
+	err := os.MkdirAll(p.destDir, 0777)
+	scipipe.CheckWithMsg(err, "PathSanitizer "+p.Name()+": Could not create directory "+p.destDir)
+	for inIP := range p.In().Chan {
+		outPath := filepath.Join(p.destDir, sanitizeFileName(filepath.Base(inIP.Path())))
+		if _, err := os.Stat(outPath); err == nil {
+			same, err := sameContent(inIP.Path(), outPath)
+			scipipe.CheckWithMsg(err, "PathSanitizer "+p.Name()+": Could not compare file "+inIP.Path()+" to "+outPath)
+			if !same {
+				scipipe.Failf("PathSanitizer %s: Sanitized path %s of file %s already exists, with different content\n", p.Name(), outPath, inIP.Path())
+			}
+			p.Workflow().Logger().Printf("| %-32s | Sanitized file already exists, so skipping: %s\n", p.Name(), outPath)
+		} else {
+			err := linkOrCopyFile(inIP.Path(), outPath)
+			scipipe.CheckWithMsg(err, "PathSanitizer "+p.Name()+": Could not link file "+inIP.Path()+" to "+outPath)
+		}
+		outIP := scipipe.NewFileIP(outPath)
+		outIP.AddTags(inIP.Tags())
+		p.Out().Send(outIP)
+	}
+}
+
+// sanitizeFileName returns fileName with all characters that are not safe to
+// use unquoted in shell commands replaced by underscores. A leading dash is
+// replaced too, so that the name is not taken for a command line flag.
+func sanitizeFileName(fileName string) string {
+	sanitized := unsafePathChars.ReplaceAllString(fileName, "_")
+	if len(sanitized) > 0 && sanitized[0] == '-' {
+		sanitized = "_" + sanitized[1:]
+	}
+	return sanitized
+}
+
+// sameContent tells whether the files at path and otherPath are the same
+// file, or have identical content
+func sameContent(path string, otherPath string) (bool, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	otherFi, err := os.Stat(otherPath)
+	if err != nil {
+		return false, err
+	}
+	if os.SameFile(fi, otherFi) {
+		return true, nil
+	}
+	hash, err := contentHash(path)
+	if err != nil {
+		return false, err
+	}
+	otherHash, err := contentHash(otherPath)
+	if err != nil {
+		return false, err
+	}
+	return hash == otherHash, nil
+}
@@ -0,0 +1,56 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestSanitizeFileName(t *testing.T) {
+	for fileName, expected := range map[string]string{
+		"sample 1.fastq.gz":      "sample_1.fastq.gz",
+		"a&b;c|d$(e)'f\"g*.txt":  "a_b_c_d__e__f_g_.txt",
+		"-rf.txt":                "_rf.txt",
+		"already_safe-1.0+x.bam": "already_safe-1.0+x.bam",
+	} {
+		if sanitized := sanitizeFileName(fileName); sanitized != expected {
+			t.Errorf("Expected %q to be sanitized to %q, got %q", fileName, expected, sanitized)
+		}
+	}
+}
+
+func TestPathSanitizer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "path_sanitizer_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	inPath := filepath.Join(dir, "my sample (v2) & more;.txt")
+	err = ioutil.WriteFile(inPath, []byte("content\n"), 0644)
+	scipipe.Check(err)
+	destDir := filepath.Join(dir, "sanitized")
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	sanitizer := NewPathSanitizer(wf, "sanitizer", destDir)
+	sanitizer.In().From(src.Out())
+	// A shell command using the sanitized path without quoting
+	cpy := wf.NewProc("cpy", "cat {i:in} > {o:out}")
+	cpy.In("in").From(sanitizer.Out())
+	cpy.SetOut("out", "{i:in}.copy")
+	col := newIPCollector(wf, "collector")
+	col.In().From(cpy.Out("out"))
+	wf.Run()
+
+	expectedPath := filepath.Join(destDir, "my_sample__v2____more_.txt.copy")
+	if len(col.paths()) != 1 || col.paths()[0] != expectedPath {
+		t.Fatalf("Expected one output with path %s, got: %v", expectedPath, col.paths())
+	}
+	dat, err := ioutil.ReadFile(expectedPath)
+	scipipe.Check(err)
+	if string(dat) != "content\n" {
+		t.Errorf("Expected content %q, got %q", "content\n", string(dat))
+	}
+}
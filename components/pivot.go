@@ -0,0 +1,205 @@
+package components
+
+import (
+	"fmt"
+
+	"github.com/scipipe/scipipe"
+)
+
+// ----------------------------------------------------------------------------
+// Pivot
+// ----------------------------------------------------------------------------
+
+// Pivot is a process that, for each long-format delimited file received on its
+// in-port, writes a wide-format file, with one row per distinct value in the
+// index column, and one column per distinct value in the pivot column, filled
+// with the values of the value column. Combinations of index and pivot values
+// missing in the input are filled with FillValue. The first line of the input
+// is treated as a header, and rows and columns are written in the order their
+// values first appear in the input. Columns are numbered from 0. The output
+// files are named after the input files, with ".pivoted" inserted before the
+// file extension.
+type Pivot struct {
+	scipipe.BaseProcess
+	indexCol  int
+	pivotCol  int
+	valueCol  int
+	Delimiter rune
+	FillValue string
+}
+
+// NewPivot returns a new initialized Pivot process, for tab-separated files
+func NewPivot(wf *scipipe.Workflow, name string, indexCol, pivotCol, valueCol int) *Pivot {
+	p := &Pivot{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		indexCol:    indexCol,
+		pivotCol:    pivotCol,
+		valueCol:    valueCol,
+		Delimiter:   '\t',
+		FillValue:   "",
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the long-format files to pivot
+func (p *Pivot) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the wide-format files are sent
+func (p *Pivot) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the Pivot process
+func (p *Pivot) Run() {
+	defer p.CloseAllOutPorts()
+
+	for inIP := range p.In().Chan {
+		rows, err := readDelimitedFile(inIP.Path(), p.Delimiter)
+		scipipe.CheckWithMsg(err, "Pivot "+p.Name()+": Could not read file "+inIP.Path())
+		wide, err := pivot(rows, p.indexCol, p.pivotCol, p.valueCol, p.FillValue)
+		if err != nil {
+			scipipe.Failf("Pivot %s: Could not pivot file %s: %s\n", p.Name(), inIP.Path(), err.Error())
+		}
+
+		outPath := pathWithInfix(inIP.Path(), "pivoted")
+		err = writeFileAtomically(outPath, formatDelimited(wide, p.Delimiter))
+		scipipe.CheckWithMsg(err, "Pivot "+p.Name()+": Could not write file "+outPath)
+		p.Out().Send(scipipe.NewFileIP(outPath))
+	}
+}
+
+// pivot turns the long-format rows (with a header row) into wide-format rows
+func pivot(rows [][]string, indexCol, pivotCol, valueCol int, fillValue string) ([][]string, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("No header found")
+	}
+	for _, col := range []int{indexCol, pivotCol, valueCol} {
+		if col < 0 || col >= len(rows[0]) {
+			return nil, fmt.Errorf("Column %d out of range for header with %d columns", col, len(rows[0]))
+		}
+	}
+
+	indexVals := []string{}
+	pivotVals := []string{}
+	values := map[string]map[string]string{}
+	for i, row := range rows[1:] {
+		if len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("Line %d has %d columns, but the header has %d", i+2, len(row), len(rows[0]))
+		}
+		indexVal, pivotVal := row[indexCol], row[pivotCol]
+		if _, ok := values[indexVal]; !ok {
+			indexVals = append(indexVals, indexVal)
+			values[indexVal] = map[string]string{}
+		}
+		if !containsString(pivotVals, pivotVal) {
+			pivotVals = append(pivotVals, pivotVal)
+		}
+		if _, ok := values[indexVal][pivotVal]; ok {
+			return nil, fmt.Errorf("Line %d has a duplicate combination of index value '%s' and pivot value '%s'", i+2, indexVal, pivotVal)
+		}
+		values[indexVal][pivotVal] = row[valueCol]
+	}
+
+	wide := [][]string{append([]string{rows[0][indexCol]}, pivotVals...)}
+	for _, indexVal := range indexVals {
+		row := []string{indexVal}
+		for _, pivotVal := range pivotVals {
+			val, ok := values[indexVal][pivotVal]
+			if !ok {
+				val = fillValue
+			}
+			row = append(row, val)
+		}
+		wide = append(wide, row)
+	}
+	return wide, nil
+}
+
+// ----------------------------------------------------------------------------
+// Unpivot
+// ----------------------------------------------------------------------------
+
+// Unpivot is a process that, for each wide-format delimited file received on
+// its in-port, writes a long-format file, with one row per value in the
+// non-index columns, with the index value, the column name and the value,
+// which is the reverse of Pivot. Values equal to FillValue are skipped, so
+// that missing combinations filled in by Pivot are not included. The first
+// line of the input is treated as a header. Columns are numbered from 0. The
+// output files are named after the input files, with ".unpivoted" inserted
+// before the file extension.
+type Unpivot struct {
+	scipipe.BaseProcess
+	indexCol     int
+	Delimiter    rune
+	FillValue    string
+	KeyColName   string
+	ValueColName string
+}
+
+// NewUnpivot returns a new initialized Unpivot process, for tab-separated
+// files
+func NewUnpivot(wf *scipipe.Workflow, name string, indexCol int) *Unpivot {
+	p := &Unpivot{
+		BaseProcess:  scipipe.NewBaseProcess(wf, name),
+		indexCol:     indexCol,
+		Delimiter:    '\t',
+		FillValue:    "",
+		KeyColName:   "key",
+		ValueColName: "value",
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the wide-format files to unpivot
+func (p *Unpivot) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the long-format files are sent
+func (p *Unpivot) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the Unpivot process
+func (p *Unpivot) Run() {
+	defer p.CloseAllOutPorts()
+
+	for inIP := range p.In().Chan {
+		rows, err := readDelimitedFile(inIP.Path(), p.Delimiter)
+		scipipe.CheckWithMsg(err, "Unpivot "+p.Name()+": Could not read file "+inIP.Path())
+		long, err := unpivot(rows, p.indexCol, p.KeyColName, p.ValueColName, p.FillValue)
+		if err != nil {
+			scipipe.Failf("Unpivot %s: Could not unpivot file %s: %s\n", p.Name(), inIP.Path(), err.Error())
+		}
+
+		outPath := pathWithInfix(inIP.Path(), "unpivoted")
+		err = writeFileAtomically(outPath, formatDelimited(long, p.Delimiter))
+		scipipe.CheckWithMsg(err, "Unpivot "+p.Name()+": Could not write file "+outPath)
+		p.Out().Send(scipipe.NewFileIP(outPath))
+	}
+}
+
+// unpivot turns the wide-format rows (with a header row) into long-format rows
+func unpivot(rows [][]string, indexCol int, keyColName string, valueColName string, fillValue string) ([][]string, error) {
+	if len(rows) == 0 {
+		return nil, fmt.Errorf("No header found")
+	}
+	header := rows[0]
+	if indexCol < 0 || indexCol >= len(header) {
+		return nil, fmt.Errorf("Column %d out of range for header with %d columns", indexCol, len(header))
+	}
+
+	long := [][]string{{header[indexCol], keyColName, valueColName}}
+	for i, row := range rows[1:] {
+		if len(row) != len(header) {
+			return nil, fmt.Errorf("Line %d has %d columns, but the header has %d", i+2, len(row), len(header))
+		}
+		for col, val := range row {
+			if col == indexCol || val == fillValue {
+				continue
+			}
+			long = append(long, []string{row[indexCol], header[col], val})
+		}
+	}
+	return long, nil
+}
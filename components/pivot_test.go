@@ -0,0 +1,67 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestPivot(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pivot_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	longPath := filepath.Join(dir, "expression.tsv")
+	err = ioutil.WriteFile(longPath, []byte("gene\tsample\tcount\n"+
+		"BRCA1\ts1\t10\n"+
+		"BRCA1\ts2\t12\n"+
+		"TP53\ts1\t7\n"+
+		"EGFR\ts2\t3\n"), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", longPath)
+	pvt := NewPivot(wf, "pivot", 0, 1, 2)
+	pvt.FillValue = "0"
+	pvt.In().From(src.Out())
+	unpvt := NewUnpivot(wf, "unpivot", 0)
+	unpvt.FillValue = "0"
+	unpvt.KeyColName = "sample"
+	unpvt.ValueColName = "count"
+	unpvt.In().From(pvt.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(unpvt.Out())
+	wf.Run()
+
+	wide, err := ioutil.ReadFile(filepath.Join(dir, "expression.pivoted.tsv"))
+	scipipe.Check(err)
+	expectedWide := "gene\ts1\ts2\n" +
+		"BRCA1\t10\t12\n" +
+		"TP53\t7\t0\n" +
+		"EGFR\t0\t3\n"
+	if string(wide) != expectedWide {
+		t.Errorf("Wrong pivoted table.\nExpected:\n%s\nGot:\n%s", expectedWide, string(wide))
+	}
+
+	// Unpivoting should give back the original table
+	long, err := ioutil.ReadFile(col.paths()[0])
+	scipipe.Check(err)
+	expectedLong := "gene\tsample\tcount\n" +
+		"BRCA1\ts1\t10\n" +
+		"BRCA1\ts2\t12\n" +
+		"TP53\ts1\t7\n" +
+		"EGFR\ts2\t3\n"
+	if string(long) != expectedLong {
+		t.Errorf("Wrong unpivoted table.\nExpected:\n%s\nGot:\n%s", expectedLong, string(long))
+	}
+}
+
+func TestPivotDuplicateCombination(t *testing.T) {
+	_, err := pivot([][]string{{"gene", "sample", "count"}, {"TP53", "s1", "7"}, {"TP53", "s1", "8"}}, 0, 1, 2, "")
+	if err == nil {
+		t.Errorf("Expected error for duplicate combination of index and pivot values, but got none")
+	}
+}
@@ -0,0 +1,115 @@
+package components
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/scipipe/scipipe"
+)
+
+// QCReporter is a process that computes QC metrics for each file received on
+// its in-port, with a user-provided function, and when the in-port is closed,
+// writes a JSON report with the metrics for each file, as well as the min,
+// max and mean of each metric over all files. The report is written to
+// ReportPath, which defaults to [process name].qc_report.json, and is sent on
+// the OutReport() out-port.
+type QCReporter struct {
+	scipipe.BaseProcess
+	metrics    func(*scipipe.FileIP) map[string]float64
+	ReportPath string
+}
+
+// QCReport is the report written by the QCReporter process
+type QCReport struct {
+	Files   []QCFileMetrics            `json:"files"`
+	Summary map[string]QCMetricSummary `json:"summary"`
+}
+
+// QCFileMetrics contains the QC metrics computed for a single file
+type QCFileMetrics struct {
+	Path    string             `json:"path"`
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// QCMetricSummary contains a summary of the values of a QC metric over all
+// files that have it
+type QCMetricSummary struct {
+	Count int     `json:"count"`
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Mean  float64 `json:"mean"`
+}
+
+// NewQCReporter returns a new initialized QCReporter process
+func NewQCReporter(wf *scipipe.Workflow, name string, metrics func(*scipipe.FileIP) map[string]float64) *QCReporter {
+	p := &QCReporter{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		metrics:     metrics,
+		ReportPath:  name + ".qc_report.json",
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "report")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which files to compute QC metrics for are
+// received
+func (p *QCReporter) In() *scipipe.InPort { return p.InPort("in") }
+
+// OutReport returns the out-port on which the JSON report is sent
+func (p *QCReporter) OutReport() *scipipe.OutPort { return p.OutPort("report") }
+
+// Run runs the QCReporter process
+func (p *QCReporter) Run() {
+	defer p.CloseAllOutPorts()
+
+	report := QCReport{
+		Files:   []QCFileMetrics{},
+		Summary: map[string]QCMetricSummary{},
+	}
+	for ip := range p.In().Chan {
+		report.Files = append(report.Files, QCFileMetrics{
+			Path:    ip.Path(),
+			Metrics: p.metrics(ip),
+		})
+	}
+	report.Summary = summarizeQCMetrics(report.Files)
+
+	reportJSON, err := json.MarshalIndent(report, "", "    ")
+	scipipe.CheckWithMsg(err, "QCReporter "+p.Name()+": Could not marshal QC report to JSON")
+	err = writeFileAtomically(p.ReportPath, reportJSON)
+	scipipe.CheckWithMsg(err, "QCReporter "+p.Name()+": Could not write QC report")
+	p.OutReport().Send(scipipe.NewFileIP(p.ReportPath))
+}
+
+// summarizeQCMetrics computes the summary of each metric over all files
+func summarizeQCMetrics(files []QCFileMetrics) map[string]QCMetricSummary {
+	summary := map[string]QCMetricSummary{}
+	sums := map[string]float64{}
+	for _, f := range files {
+		names := []string{}
+		for name := range f.Metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			val := f.Metrics[name]
+			s, ok := summary[name]
+			if !ok || val < s.Min {
+				s.Min = val
+			}
+			if !ok || val > s.Max {
+				s.Max = val
+			}
+			s.Count++
+			sums[name] += val
+			summary[name] = s
+		}
+	}
+	for name, s := range summary {
+		s.Mean = sums[name] / float64(s.Count)
+		summary[name] = s
+	}
+	return summary
+}
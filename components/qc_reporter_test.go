@@ -0,0 +1,62 @@
+package components
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestQCReporter(t *testing.T) {
+	contents := map[string]string{
+		"/tmp/qc_reporter_a.txt": "a\nb\n",
+		"/tmp/qc_reporter_b.txt": "a\nb\nc\nd\n",
+	}
+	paths := []string{"/tmp/qc_reporter_a.txt", "/tmp/qc_reporter_b.txt"}
+	for _, path := range paths {
+		err := ioutil.WriteFile(path, []byte(contents[path]), 0644)
+		scipipe.Check(err)
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	qc := NewQCReporter(wf, "qc", func(ip *scipipe.FileIP) map[string]float64 {
+		return map[string]float64{"bytes": float64(len(ip.Read()))}
+	})
+	qc.ReportPath = "/tmp/qc_reporter_report.json"
+	qc.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(qc.OutReport())
+	wf.Run()
+
+	if len(col.paths()) != 1 || col.paths()[0] != qc.ReportPath {
+		t.Fatalf("Expected the report %s to be emitted, got: %v", qc.ReportPath, col.paths())
+	}
+	reportJSON, err := ioutil.ReadFile(qc.ReportPath)
+	scipipe.Check(err)
+	report := QCReport{}
+	err = json.Unmarshal(reportJSON, &report)
+	scipipe.Check(err)
+
+	if len(report.Files) != 2 {
+		t.Fatalf("Expected metrics for 2 files in report, got %d", len(report.Files))
+	}
+	for i, path := range paths {
+		if report.Files[i].Path != path {
+			t.Errorf("Expected file %d in report to be %s, got %s", i, path, report.Files[i].Path)
+		}
+		if report.Files[i].Metrics["bytes"] != float64(len(contents[path])) {
+			t.Errorf("Expected bytes metric for %s to be %d, got %f", path, len(contents[path]), report.Files[i].Metrics["bytes"])
+		}
+	}
+	expectedSummary := QCMetricSummary{Count: 2, Min: 4, Max: 8, Mean: 6}
+	if report.Summary["bytes"] != expectedSummary {
+		t.Errorf("Expected summary %+v, got %+v", expectedSummary, report.Summary["bytes"])
+	}
+
+	for _, path := range append(paths, qc.ReportPath) {
+		os.Remove(path)
+	}
+}
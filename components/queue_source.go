@@ -0,0 +1,47 @@
+package components
+
+import (
+	"github.com/scipipe/scipipe"
+)
+
+// QueueSource is a process that pulls messages from a message queue, such as
+// SQS or Kafka, and sends them as file IPs on its out-port, for event-driven
+// workflows. Pulling messages is left to a user-supplied consume function, so
+// that SciPipe does not depend on any specific message broker. consume is
+// called repeatedly, and should block until a message is available, and then
+// return an IP for it, and true. When there are no more messages to consume,
+// it should return false, which makes the QueueSource close its out-port.
+type QueueSource struct {
+	scipipe.BaseProcess
+	consume func() (*scipipe.FileIP, bool)
+}
+
+// NewQueueSource returns a new initialized QueueSource process, emitting IPs
+// returned by consume, until it returns false
+func NewQueueSource(wf *scipipe.Workflow, name string, consume func() (*scipipe.FileIP, bool)) *QueueSource {
+	if consume == nil {
+		scipipe.Failf("QueueSource with name '%s': The consume function must not be nil", name)
+	}
+	p := &QueueSource{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		consume:     consume,
+	}
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// Out returns the out-port, on which IPs for consumed messages are sent
+func (p *QueueSource) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the QueueSource process
+func (p *QueueSource) Run() {
+	defer p.CloseAllOutPorts()
+	for {
+		ip, ok := p.consume()
+		if !ok {
+			return
+		}
+		p.Out().Send(ip)
+	}
+}
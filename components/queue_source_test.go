@@ -0,0 +1,31 @@
+package components
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestQueueSource(t *testing.T) {
+	messages := []string{"/tmp/queue_msg_1.txt", "/tmp/queue_msg_2.txt", "/tmp/queue_msg_3.txt"}
+	consumed := 0
+	consume := func() (*scipipe.FileIP, bool) {
+		if consumed == len(messages) {
+			return nil, false
+		}
+		ip := scipipe.NewFileIP(messages[consumed])
+		consumed++
+		return ip, true
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewQueueSource(wf, "queue", consume)
+	col := newIPCollector(wf, "collector")
+	col.In().From(src.Out())
+	wf.Run()
+
+	if !reflect.DeepEqual(col.paths(), messages) {
+		t.Errorf("Expected IPs %v to be emitted in order, got %v", messages, col.paths())
+	}
+}
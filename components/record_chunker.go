@@ -0,0 +1,107 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/scipipe/scipipe"
+)
+
+// RecordChunker is a process that splits each file received on its in-port
+// into multiple files, each with at most RecordsPerChunk records of
+// RecordLines lines each, such as four lines per record for FASTQ files. The
+// chunk files are named after the input file, with a sequential chunk number
+// appended, as in: [input file path].chunk_1
+type RecordChunker struct {
+	scipipe.BaseProcess
+	RecordsPerChunk int
+	RecordLines     int
+}
+
+// NewRecordChunker returns an initialized RecordChunker process
+func NewRecordChunker(wf *scipipe.Workflow, name string, recordsPerChunk int, recordLines int) *RecordChunker {
+	if recordsPerChunk < 1 {
+		scipipe.Failf("RecordChunker with name '%s': Number of records per chunk must be at least 1, got %d", name, recordsPerChunk)
+	}
+	if recordLines < 1 {
+		scipipe.Failf("RecordChunker with name '%s': Number of lines per record must be at least 1, got %d", name, recordLines)
+	}
+	p := &RecordChunker{
+		BaseProcess:     scipipe.NewBaseProcess(wf, name),
+		RecordsPerChunk: recordsPerChunk,
+		RecordLines:     recordLines,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "chunk")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the files to split into chunks
+func (p *RecordChunker) In() *scipipe.InPort { return p.InPort("in") }
+
+// OutChunk returns the out-port on which the chunk files are sent
+func (p *RecordChunker) OutChunk() *scipipe.OutPort { return p.OutPort("chunk") }
+
+// Run runs the RecordChunker process
+func (p *RecordChunker) Run() {
+	defer p.CloseAllOutPorts()
+
+	for inIP := range p.In().Chan {
+		inFile, err := os.Open(inIP.Path())
+		scipipe.CheckWithMsg(err, "[RecordChunker] Could not open file "+inIP.Path())
+		taskDir := "_scipipe_tmp_" + p.Name() + "." + filepath.Base(inIP.Path())
+
+		br := bufio.NewReader(inFile)
+		for chunkNo := 1; ; chunkNo++ {
+			records, err := readRecords(br, p.RecordsPerChunk, p.RecordLines)
+			scipipe.CheckWithMsg(err, "[RecordChunker] Could not read records from file "+inIP.Path())
+			if len(records) == 0 {
+				break
+			}
+			chunkIP := scipipe.NewFileIP(inIP.Path() + fmt.Sprintf(".chunk_%d", chunkNo))
+			p.writeChunk(chunkIP, taskDir, records)
+			scipipe.AtomizeIPs(taskDir, chunkIP)
+			p.OutChunk().Send(chunkIP)
+		}
+		inFile.Close()
+	}
+}
+
+// writeChunk writes the lines of records to the temp path of ip, in taskDir
+func (p *RecordChunker) writeChunk(ip *scipipe.FileIP, taskDir string, records [][]string) {
+	tempPath := taskDir + "/" + ip.TempPath()
+	err := os.MkdirAll(filepath.Dir(tempPath), 0777)
+	scipipe.CheckWithMsg(err, "[RecordChunker] Could not create dirs for file "+tempPath)
+	tempFile, err := os.Create(tempPath)
+	scipipe.CheckWithMsg(err, "[RecordChunker] Could not create temp file "+tempPath)
+	bw := bufio.NewWriter(tempFile)
+	for _, record := range records {
+		for _, line := range record {
+			bw.WriteString(line)
+		}
+	}
+	err = bw.Flush()
+	scipipe.CheckWithMsg(err, "[RecordChunker] Could not write to temp file "+tempPath)
+	tempFile.Close()
+}
+
+// readRecords reads at most maxRecords records of recordLines lines each from
+// r. An empty slice is returned when there are no more records to read.
+func readRecords(r *bufio.Reader, maxRecords int, recordLines int) ([][]string, error) {
+	records := [][]string{}
+	for len(records) < maxRecords {
+		record, err := readRecord(r, recordLines)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
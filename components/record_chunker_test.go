@@ -0,0 +1,49 @@
+package components
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestRecordChunker(t *testing.T) {
+	inPath := "/tmp/record_chunker_test.fq"
+	records := []string{}
+	for i := 1; i <= 10; i++ {
+		records = append(records, fmt.Sprintf("@read%d\nACGT\n+\nIIII\n", i))
+	}
+	err := ioutil.WriteFile(inPath, []byte(strings.Join(records, "")), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	chunker := NewRecordChunker(wf, "chunker", 4, 4)
+	chunker.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(chunker.OutChunk())
+	wf.Run()
+
+	expectedPaths := []string{inPath + ".chunk_1", inPath + ".chunk_2", inPath + ".chunk_3"}
+	if !reflect.DeepEqual(col.paths(), expectedPaths) {
+		t.Fatalf("Expected chunk files %v, got %v", expectedPaths, col.paths())
+	}
+	expectedContents := []string{
+		strings.Join(records[0:4], ""),
+		strings.Join(records[4:8], ""),
+		strings.Join(records[8:10], ""),
+	}
+	for i, path := range expectedPaths {
+		dat, err := ioutil.ReadFile(path)
+		scipipe.Check(err)
+		if string(dat) != expectedContents[i] {
+			t.Errorf("Chunk file %s contained:\n%s\nExpected:\n%s", path, string(dat), expectedContents[i])
+		}
+		os.Remove(path)
+	}
+	os.Remove(inPath)
+}
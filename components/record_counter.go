@@ -0,0 +1,58 @@
+package components
+
+import (
+	"strconv"
+
+	"github.com/scipipe/scipipe"
+)
+
+// RecordCounter is a process that, for each IP received on its in-port,
+// counts the records in its file, where each record is recordLines lines,
+// and adds the count as a tag named TagName ("records" by default), before
+// sending it on the out-port. Use recordLines 4 for FASTQ files, and 1 for
+// counting lines. The workflow fails if a file ends with an incomplete
+// record. This is useful for QC, or for downstream decisions based on the
+// number of records, such as by reading the tag in a path function.
+type RecordCounter struct {
+	scipipe.BaseProcess
+	recordLines int
+	TagName     string
+}
+
+// NewRecordCounter returns a new initialized RecordCounter process
+func NewRecordCounter(wf *scipipe.Workflow, name string, recordLines int) *RecordCounter {
+	if recordLines < 1 {
+		scipipe.Failf("RecordCounter with name '%s': Number of lines per record must be at least 1, got %d\n", name, recordLines)
+	}
+	p := &RecordCounter{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		recordLines: recordLines,
+		TagName:     "records",
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which IPs to count the records of are received
+func (p *RecordCounter) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which IPs tagged with their record count are
+// sent
+func (p *RecordCounter) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the RecordCounter process
+func (p *RecordCounter) Run() {
+	defer p.CloseAllOutPorts()
+	for ip := range p.In().Chan {
+		lineCount, err := countLines(ip.Path())
+		scipipe.CheckWithMsg(err, "RecordCounter "+p.Name()+": Could not count lines of file "+ip.Path())
+		if lineCount%p.recordLines != 0 {
+			scipipe.Failf("RecordCounter %s: File %s has %d lines, which is not a whole number of records of %d lines\n", p.Name(), ip.Path(), lineCount, p.recordLines)
+		}
+		ip.AddTag(p.TagName, strconv.Itoa(lineCount/p.recordLines))
+		ip.WriteAuditLogToFile()
+		p.Out().Send(ip)
+	}
+}
@@ -0,0 +1,44 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestRecordCounter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "record_counter_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	fastqPath := filepath.Join(dir, "reads.fq")
+	err = ioutil.WriteFile(fastqPath, []byte("@r1\nACGT\n+\nIIII\n@r2\nCCCC\n+\nIIII\n@r3\nGGGG\n+\nIIII"), 0644)
+	scipipe.Check(err)
+
+	for _, tc := range []struct {
+		recordLines int
+		expected    string
+	}{
+		{4, "3"},
+		{1, "12"},
+	} {
+		wf := scipipe.NewWorkflow("wf", 4)
+		src := NewFileSource(wf, "src", fastqPath)
+		counter := NewRecordCounter(wf, "record_counter", tc.recordLines)
+		counter.In().From(src.Out())
+		col := newIPCollector(wf, "collector")
+		col.In().From(counter.Out())
+		wf.Run()
+
+		if len(col.ips) != 1 {
+			t.Fatalf("Expected 1 IP, got %d", len(col.ips))
+		}
+		if actual := col.ips[0].Tag("records"); actual != tc.expected {
+			t.Errorf("Expected records tag %s with %d lines per record, but was %s", tc.expected, tc.recordLines, actual)
+		}
+		os.Remove(fastqPath + ".audit.json")
+	}
+}
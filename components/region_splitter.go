@@ -0,0 +1,107 @@
+package components
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/scipipe/scipipe"
+)
+
+// RegionSplitter is a process that, for each file received on its in-port,
+// and for each of a set of regions (such as "chr1" or "chr2:1000-2000"), runs
+// an extraction command, producing one output file per region. The command
+// template can contain the following placeholders:
+// {i:in} - The path of the input file
+// {o:out} - The path of the output file for the region
+// {region} - The region
+// An example command template: samtools view -b {i:in} {region} > {o:out}
+// The output files are named after the input file, with the (sanitized)
+// region inserted before the file extension, as in: sample.chr1.bam
+type RegionSplitter struct {
+	scipipe.BaseProcess
+	regions     []string
+	cmdTemplate string
+}
+
+// NewRegionSplitter returns a new initialized RegionSplitter process
+func NewRegionSplitter(wf *scipipe.Workflow, name string, regions []string, cmdTemplate string) *RegionSplitter {
+	if len(regions) < 1 {
+		scipipe.Failf("RegionSplitter with name '%s': No regions supplied! Must take at least one region.", name)
+	}
+	if !strings.Contains(cmdTemplate, "{o:out}") {
+		scipipe.Failf("RegionSplitter with name '%s': Command template must contain an {o:out} placeholder: %s", name, cmdTemplate)
+	}
+	p := &RegionSplitter{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		regions:     regions,
+		cmdTemplate: cmdTemplate,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the files to split by region
+func (p *RegionSplitter) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the files for each region are sent, in
+// the order of the regions
+func (p *RegionSplitter) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the RegionSplitter process
+func (p *RegionSplitter) Run() {
+	defer p.CloseAllOutPorts()
+
+	for inIP := range p.In().Chan {
+		taskDir := "_scipipe_tmp_" + p.Name() + "." + filepath.Base(inIP.Path())
+		outIPs := make([]*scipipe.FileIP, len(p.regions))
+		wg := sync.WaitGroup{}
+		for i, region := range p.regions {
+			outIPs[i] = scipipe.NewFileIP(regionPath(inIP.Path(), region))
+			wg.Add(1)
+			go func(region string, outIP *scipipe.FileIP) {
+				defer wg.Done()
+				p.Workflow().IncConcurrentTasks(1)
+				defer p.Workflow().DecConcurrentTasks(1)
+				p.extractRegion(inIP, outIP, region, taskDir)
+			}(region, outIPs[i])
+		}
+		wg.Wait()
+		scipipe.AtomizeIPs(taskDir, outIPs...)
+		for _, outIP := range outIPs {
+			p.Out().Send(outIP)
+		}
+	}
+}
+
+// extractRegion runs the extraction command for region, writing to the temp
+// path of outIP in taskDir
+func (p *RegionSplitter) extractRegion(inIP *scipipe.FileIP, outIP *scipipe.FileIP, region string, taskDir string) {
+	tempPath := filepath.Join(taskDir, outIP.TempPath())
+	err := os.MkdirAll(filepath.Dir(tempPath), 0777)
+	scipipe.CheckWithMsg(err, "RegionSplitter "+p.Name()+": Could not create dirs for file "+tempPath)
+
+	cmd := strings.NewReplacer(
+		"{i:in}", inIP.Path(),
+		"{o:out}", tempPath,
+		"{region}", region,
+	).Replace(p.cmdTemplate)
+	p.Workflow().Logger().Printf("| %-32s | Executing: %s\n", p.Name(), cmd)
+	out, err := exec.Command("bash", "-c", cmd).CombinedOutput()
+	if err != nil {
+		scipipe.Failf("RegionSplitter %s: Command failed!\nCommand:\n%s\n\nOutput:\n%s\nOriginal error:%s\n", p.Name(), cmd, string(out), err.Error())
+	}
+}
+
+var regionPathFragmentPtn = regexp.MustCompile("[^A-Za-z0-9_\\-]+")
+
+// regionPath returns path with the region, with disallowed characters
+// replaced by underscores, inserted before the file extension
+func regionPath(path string, region string) string {
+	return pathWithInfix(path, regionPathFragmentPtn.ReplaceAllString(region, "_"))
+}
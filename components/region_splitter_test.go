@@ -0,0 +1,43 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestRegionSplitter(t *testing.T) {
+	inPath := "/tmp/region_splitter_test.tsv"
+	err := ioutil.WriteFile(inPath, []byte("chr1\t100\tA\nchr2\t200\tC\nchr1\t300\tG\n"), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	splitter := NewRegionSplitter(wf, "splitter", []string{"chr1", "chr2"}, "awk '$1 == \"{region}\"' {i:in} > {o:out}")
+	splitter.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(splitter.Out())
+	wf.Run()
+
+	expectedPaths := []string{"/tmp/region_splitter_test.chr1.tsv", "/tmp/region_splitter_test.chr2.tsv"}
+	if !reflect.DeepEqual(col.paths(), expectedPaths) {
+		t.Fatalf("Expected output files %v, got %v", expectedPaths, col.paths())
+	}
+	expectedContents := []string{"chr1\t100\tA\nchr1\t300\tG\n", "chr2\t200\tC\n"}
+	for i, path := range expectedPaths {
+		dat, err := ioutil.ReadFile(path)
+		scipipe.Check(err)
+		if string(dat) != expectedContents[i] {
+			t.Errorf("File %s contained:\n%s\nExpected:\n%s", path, string(dat), expectedContents[i])
+		}
+		os.Remove(path)
+	}
+	os.Remove(inPath)
+
+	if regionPath("x/sample.bam", "chr2:1000-2000") != "x/sample.chr2_1000-2000.bam" {
+		t.Errorf("Unexpected region path: %s", regionPath("x/sample.bam", "chr2:1000-2000"))
+	}
+}
@@ -0,0 +1,62 @@
+package components
+
+import (
+	"strconv"
+
+	"github.com/scipipe/scipipe"
+)
+
+// RollingAgg is a process that extracts a value from each IP received on its
+// in-port, using valueFunc, and maintains a sliding window over the last
+// window values. For each received IP, once the window is full, the window is
+// aggregated with aggFunc, and the aggregated value is sent on the out-param
+// port. A sequence of N values thus gives N-window+1 aggregated values.
+type RollingAgg struct {
+	scipipe.BaseProcess
+	window    int
+	aggFunc   func([]float64) float64
+	valueFunc func(*scipipe.FileIP) float64
+}
+
+// NewRollingAgg returns a new initialized RollingAgg process, aggregating
+// windows of window values with aggFunc
+func NewRollingAgg(wf *scipipe.Workflow, name string, window int, aggFunc func([]float64) float64, valueFunc func(*scipipe.FileIP) float64) *RollingAgg {
+	if window < 1 {
+		scipipe.Failf("RollingAgg with name '%s': Window must be at least 1, but was %d", name, window)
+	}
+	p := &RollingAgg{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		window:      window,
+		aggFunc:     aggFunc,
+		valueFunc:   valueFunc,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutParamPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which IPs to extract values from are received
+func (p *RollingAgg) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-param port on which the aggregated values are sent
+func (p *RollingAgg) Out() *scipipe.OutParamPort { return p.OutParamPort("out") }
+
+// Run runs the RollingAgg process
+func (p *RollingAgg) Run() {
+	defer p.CloseAllOutPorts()
+
+	values := []float64{}
+	for ip := range p.In().Chan {
+		values = append(values, p.valueFunc(ip))
+		if len(values) > p.window {
+			values = values[1:]
+		}
+		if len(values) == p.window {
+			// Hand a copy of the window to aggFunc, so that it can't modify
+			// the window
+			window := append([]float64{}, values...)
+			p.Out().Send(strconv.FormatFloat(p.aggFunc(window), 'g', -1, 64))
+		}
+	}
+}
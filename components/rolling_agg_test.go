@@ -0,0 +1,54 @@
+package components
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestRollingAgg(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rolling_agg_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	paths := []string{}
+	for i, val := range []string{"1", "2", "3", "4", "5", "9"} {
+		path := filepath.Join(dir, fmt.Sprintf("value_%d.txt", i))
+		err := ioutil.WriteFile(path, []byte(val+"\n"), 0644)
+		scipipe.Check(err)
+		paths = append(paths, path)
+	}
+
+	mean := func(values []float64) float64 {
+		sum := 0.0
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	}
+	readValue := func(ip *scipipe.FileIP) float64 {
+		val, err := strconv.ParseFloat(strings.TrimSpace(string(ip.Read())), 64)
+		scipipe.Check(err)
+		return val
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	agg := NewRollingAgg(wf, "moving_average", 3, mean, readValue)
+	agg.In().From(src.Out())
+	col := newParamCollector(wf, "collector")
+	col.In().From(agg.Out())
+	wf.Run()
+
+	expected := []string{"2", "3", "4", "6"}
+	if !reflect.DeepEqual(col.params, expected) {
+		t.Errorf("Expected moving averages %v, got %v", expected, col.params)
+	}
+}
@@ -0,0 +1,65 @@
+package components
+
+import (
+	"math/rand"
+
+	"github.com/scipipe/scipipe"
+)
+
+// Sampler is a process that selects up to N random IPs from the stream of IPs
+// received on its in-port, using reservoir sampling, and sends them on its
+// out-port once the in-port is closed. The selection is reproducible for a
+// given seed, and the selected IPs are sent in the order they were received.
+type Sampler struct {
+	scipipe.BaseProcess
+	n    int
+	seed int64
+}
+
+// NewSampler returns a new initialized Sampler process, that will send up to n
+// randomly selected IPs, using the random seed seed
+func NewSampler(wf *scipipe.Workflow, name string, n int, seed int64) *Sampler {
+	p := &Sampler{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		n:           n,
+		seed:        seed,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which IPs to sample from are received
+func (p *Sampler) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the sampled IPs are sent
+func (p *Sampler) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the Sampler process
+func (p *Sampler) Run() {
+	defer p.CloseAllOutPorts()
+
+	rnd := rand.New(rand.NewSource(p.seed))
+	// The reservoir keeps the positions in the stream of the sampled IPs, so
+	// that they can be sent in the order they were received
+	reservoir := []int{}
+	ips := map[int]*scipipe.FileIP{}
+	i := 0
+	for ip := range p.In().Chan {
+		if i < p.n {
+			reservoir = append(reservoir, i)
+			ips[i] = ip
+		} else if j := rnd.Intn(i + 1); j < p.n {
+			delete(ips, reservoir[j])
+			reservoir[j] = i
+			ips[i] = ip
+		}
+		i++
+	}
+	for pos := 0; pos < i; pos++ {
+		if ip, ok := ips[pos]; ok {
+			p.Out().Send(ip)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package components
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestSampler(t *testing.T) {
+	sample := func(wfName string, seed int64) []string {
+		wf := scipipe.NewWorkflow(wfName, 4)
+		paths := []string{}
+		for i := 1; i <= 10; i++ {
+			paths = append(paths, fmt.Sprintf("/tmp/sampler_file_%d.txt", i))
+		}
+		src := NewFileSource(wf, "src", paths...)
+		smp := NewSampler(wf, "sampler", 2, seed)
+		smp.In().From(src.Out())
+		col := newIPCollector(wf, "collector")
+		col.In().From(smp.Out())
+		wf.Run()
+		return col.paths()
+	}
+
+	first := sample("wf_a", 42)
+	if len(first) != 2 {
+		t.Fatalf("Expected 2 sampled IPs, got %d: %v", len(first), first)
+	}
+	if first[0] == first[1] {
+		t.Errorf("The same IP was sampled twice: %v", first)
+	}
+	second := sample("wf_b", 42)
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("Sampling with the same seed was not reproducible. First: %v Second: %v", first, second)
+	}
+}
@@ -0,0 +1,94 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/scipipe/scipipe"
+)
+
+// SchemaGuard is a process that checks that the header (first line) of each
+// tabular file received on its in-port still contains all the columns it was
+// initialized with, in any order, for catching changes in the output formats
+// of tools in long-lived pipelines. Additional columns are allowed. Files with
+// all expected columns are passed through on the out-port, while the workflow
+// fails, listing the missing and added columns, if any expected column is
+// missing.
+type SchemaGuard struct {
+	scipipe.BaseProcess
+	expectedColumns []string
+	// Delimiter is the delimiter between the columns of the files. It
+	// defaults to tab.
+	Delimiter rune
+}
+
+// NewSchemaGuard returns a new initialized SchemaGuard process, checking that
+// files contain the columns expectedColumns
+func NewSchemaGuard(wf *scipipe.Workflow, name string, expectedColumns []string) *SchemaGuard {
+	if len(expectedColumns) == 0 {
+		scipipe.Failf("SchemaGuard with name '%s': No expected columns given\n", name)
+	}
+	p := &SchemaGuard{
+		BaseProcess:     scipipe.NewBaseProcess(wf, name),
+		expectedColumns: expectedColumns,
+		Delimiter:       '\t',
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the tabular files to check are received
+func (p *SchemaGuard) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which files with all expected columns are sent
+func (p *SchemaGuard) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the SchemaGuard process
+func (p *SchemaGuard) Run() {
+	defer p.CloseAllOutPorts()
+
+	for ip := range p.In().Chan {
+		if err := checkColumns(ip.Path(), p.expectedColumns, p.Delimiter); err != nil {
+			scipipe.Failf("SchemaGuard %s: %s\n", p.Name(), err.Error())
+		}
+		p.Out().Send(ip)
+	}
+}
+
+// checkColumns returns an error, listing the missing and added columns, if
+// the header of the file at path, delimited by delimiter, lacks any of
+// expectedColumns
+func checkColumns(path string, expectedColumns []string, delimiter rune) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errWrap(err, "Could not open file "+path)
+	}
+	defer f.Close()
+	header, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return errWrap(err, "Could not read header of file "+path)
+	}
+	columns := strings.Split(strings.TrimRight(header, "\r\n"), string(delimiter))
+
+	missing := []string{}
+	for _, col := range expectedColumns {
+		if !containsString(columns, col) {
+			missing = append(missing, col)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	added := []string{}
+	for _, col := range columns {
+		if !containsString(expectedColumns, col) {
+			added = append(added, col)
+		}
+	}
+	return fmt.Errorf("File %s lacks required columns:\n- missing: %v\n+ added:   %v\nExpected columns: %v\nFound columns:    %v", path, missing, added, expectedColumns, columns)
+}
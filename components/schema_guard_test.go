@@ -0,0 +1,52 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestSchemaGuard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema_guard_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	expectedColumns := []string{"gene", "count"}
+	// Extra and reordered columns are allowed
+	evolvedPath := filepath.Join(dir, "evolved.tsv")
+	err = ioutil.WriteFile(evolvedPath, []byte("count\tgene\tlength\n3\tBRCA1\t100\n"), 0644)
+	scipipe.Check(err)
+	brokenPath := filepath.Join(dir, "broken.tsv")
+	err = ioutil.WriteFile(brokenPath, []byte("gene_id\tcount\n"+"BRCA1\t3\n"), 0644)
+	scipipe.Check(err)
+
+	if err := checkColumns(evolvedPath, expectedColumns, '\t'); err != nil {
+		t.Errorf("Expected file with additional columns to be accepted, got: %s", err.Error())
+	}
+	err = checkColumns(brokenPath, expectedColumns, '\t')
+	if err == nil {
+		t.Fatalf("Expected file lacking the required column 'gene' to be rejected, but it was not")
+	}
+	for _, expected := range []string{brokenPath, "- missing: [gene]", "+ added:   [gene_id]"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, but it was: %s", expected, err.Error())
+		}
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", evolvedPath)
+	guard := NewSchemaGuard(wf, "guard", expectedColumns)
+	guard.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(guard.Out())
+	wf.Run()
+
+	if !reflect.DeepEqual(col.paths(), []string{evolvedPath}) {
+		t.Errorf("Expected compatible file to be passed through, got: %v", col.paths())
+	}
+}
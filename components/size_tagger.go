@@ -0,0 +1,48 @@
+package components
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/scipipe/scipipe"
+)
+
+// SizeTagger is a process that, for each IP received on its in-port, adds the
+// size of its file, in bytes, as a tag named TagName ("size" by default),
+// before sending it on the out-port. This is useful for example for
+// size-aware path formatting or scheduling downstream, such as by reading the
+// tag in a path function.
+type SizeTagger struct {
+	scipipe.BaseProcess
+	TagName string
+}
+
+// NewSizeTagger returns a new initialized SizeTagger process
+func NewSizeTagger(wf *scipipe.Workflow, name string) *SizeTagger {
+	p := &SizeTagger{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		TagName:     "size",
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which IPs to tag with their size are received
+func (p *SizeTagger) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which IPs tagged with their size are sent
+func (p *SizeTagger) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the SizeTagger process
+func (p *SizeTagger) Run() {
+	defer p.CloseAllOutPorts()
+	for ip := range p.In().Chan {
+		fi, err := os.Stat(ip.Path())
+		scipipe.CheckWithMsg(err, "SizeTagger "+p.Name()+": Could not get size of file "+ip.Path())
+		ip.AddTag(p.TagName, strconv.FormatInt(fi.Size(), 10))
+		ip.WriteAuditLogToFile()
+		p.Out().Send(ip)
+	}
+}
@@ -0,0 +1,45 @@
+package components
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestSizeTagger(t *testing.T) {
+	dir, err := ioutil.TempDir("", "size_tagger_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	sizes := map[string]int{"empty.txt": 0, "small.txt": 12, "large.txt": 4096}
+	paths := []string{}
+	for name, size := range sizes {
+		path := filepath.Join(dir, name)
+		err := ioutil.WriteFile(path, bytes.Repeat([]byte("x"), size), 0644)
+		scipipe.Check(err)
+		paths = append(paths, path)
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	tagger := NewSizeTagger(wf, "size_tagger")
+	tagger.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(tagger.Out())
+	wf.Run()
+
+	if len(col.ips) != len(paths) {
+		t.Fatalf("Expected %d IPs, got %d", len(paths), len(col.ips))
+	}
+	for _, ip := range col.ips {
+		expected := strconv.Itoa(sizes[filepath.Base(ip.Path())])
+		if ip.Tag("size") != expected {
+			t.Errorf("Expected size tag of %s to be %s, but was %s", ip.Path(), expected, ip.Tag("size"))
+		}
+	}
+}
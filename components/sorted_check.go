@@ -0,0 +1,75 @@
+package components
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/scipipe/scipipe"
+)
+
+// SortedCheck is a process that verifies that each file received on its
+// in-port is sorted, in ascending lexical order, by the key returned by
+// keyFunc for each line. Sorted files are passed through on the out-port,
+// while the workflow fails with the location of the first out-of-order line, if
+// a file is not sorted.
+type SortedCheck struct {
+	scipipe.BaseProcess
+	keyFunc func(line string) string
+}
+
+// NewSortedCheck returns a new initialized SortedCheck process, checking that
+// files are sorted by the key returned by keyFunc
+func NewSortedCheck(wf *scipipe.Workflow, name string, keyFunc func(line string) string) *SortedCheck {
+	p := &SortedCheck{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		keyFunc:     keyFunc,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which files to check are received
+func (p *SortedCheck) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which sorted files are sent
+func (p *SortedCheck) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the SortedCheck process
+func (p *SortedCheck) Run() {
+	defer p.CloseAllOutPorts()
+
+	for ip := range p.In().Chan {
+		if err := checkSorted(ip.Path(), p.keyFunc); err != nil {
+			scipipe.Failf("SortedCheck %s: %s\n", p.Name(), err.Error())
+		}
+		p.Out().Send(ip)
+	}
+}
+
+// checkSorted returns an error with the location of the first line in the file
+// at path, whose key is smaller than the one of the line before it
+func checkSorted(path string, keyFunc func(line string) string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errWrap(err, "Could not open file "+path)
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxLineLength)
+	prevKey := ""
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		key := keyFunc(sc.Text())
+		if lineNo > 1 && key < prevKey {
+			return fmt.Errorf("File %s is not sorted: Key '%s' on line %d comes after key '%s' on line %d", path, key, lineNo, prevKey, lineNo-1)
+		}
+		prevKey = key
+	}
+	if err := sc.Err(); err != nil {
+		return errWrap(err, "Could not read file "+path)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestSortedCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sorted_check_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// Sort on the second, tab-separated column
+	keyFunc := func(line string) string {
+		return strings.Split(line, "\t")[1]
+	}
+
+	sortedPath := filepath.Join(dir, "sorted.tsv")
+	err = ioutil.WriteFile(sortedPath, []byte("3\ta\n1\tb\n2\tb\n4\tc\n"), 0644)
+	scipipe.Check(err)
+	unsortedPath := filepath.Join(dir, "unsorted.tsv")
+	err = ioutil.WriteFile(unsortedPath, []byte("1\ta\n2\tc\n3\tb\n"), 0644)
+	scipipe.Check(err)
+
+	err = checkSorted(unsortedPath, keyFunc)
+	if err == nil {
+		t.Fatalf("Expected unsorted file to be rejected, but it was not")
+	}
+	if !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("Expected error to point out line 3 as out of order, but it was: %s", err.Error())
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", sortedPath)
+	chk := NewSortedCheck(wf, "sorted_check", keyFunc)
+	chk.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(chk.Out())
+	wf.Run()
+
+	if !reflect.DeepEqual(col.paths(), []string{sortedPath}) {
+		t.Errorf("Expected sorted file to be passed through, but got: %v", col.paths())
+	}
+}
+
+func TestCheckSortedLongLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sorted_check_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// Lines longer than the default max token size of bufio.Scanner (64 KiB)
+	longField := strings.Repeat("A", 200*1024)
+	path := filepath.Join(dir, "long.tsv")
+	err = ioutil.WriteFile(path, []byte("a\t"+longField+"\nb\t"+longField+"\n"), 0644)
+	scipipe.Check(err)
+
+	err = checkSorted(path, func(line string) string { return strings.Split(line, "\t")[0] })
+	if err != nil {
+		t.Errorf("Expected sorted file with long lines to pass the check, got: %s", err.Error())
+	}
+}
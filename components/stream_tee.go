@@ -0,0 +1,103 @@
+package components
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/scipipe/scipipe"
+)
+
+// StreamTee is a process that forwards a stream received on its in-port to
+// its out-port, while at the same time saving everything in the stream to a
+// file at savePath. This allows persisting a streaming intermediate result to
+// disk, without breaking up the stream. The forwarded stream gets savePath as
+// its path. Since there is only one savePath, only one stream can be received.
+type StreamTee struct {
+	scipipe.BaseProcess
+	savePath string
+}
+
+// NewStreamTee returns a new initialized StreamTee process
+func NewStreamTee(wf *scipipe.Workflow, name string, savePath string) *StreamTee {
+	p := &StreamTee{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		savePath:    savePath,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the stream to tee is received
+func (p *StreamTee) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the stream is forwarded
+func (p *StreamTee) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the StreamTee process
+func (p *StreamTee) Run() {
+	defer p.CloseAllOutPorts()
+	received := 0
+	for inIP := range p.In().Chan {
+		received++
+		if received > 1 {
+			scipipe.Failf("StreamTee %s: Got more than one stream, but can only save one stream to %s. Got: %s\n", p.Name(), p.savePath, inIP.Path())
+		}
+
+		outIP := scipipe.NewFileIP(p.savePath)
+		outIP.SetStreaming(true)
+		if outIP.FifoFileExists() {
+			scipipe.Fail("Fifo file exists, so exiting (clean up fifo files before restarting the workflow): ", outIP.FifoPath())
+		}
+		outIP.CreateFifo()
+		p.Out().Send(outIP)
+
+		inPath := inIP.Path()
+		if inIP.IsStreaming() {
+			inPath = inIP.FifoPath()
+		}
+		err := teeFile(inPath, p.savePath, outIP.FifoPath())
+		scipipe.CheckWithMsg(err, "StreamTee "+p.Name()+": Could not tee stream "+inPath)
+		os.Remove(outIP.FifoPath())
+	}
+}
+
+// teeFile copies everything read from inPath to both a file at savePath and
+// to the (typically FIFO) file at fwdPath. The saved file is first written to
+// a temporary file, which is renamed to savePath when done.
+func teeFile(inPath string, savePath string, fwdPath string) error {
+	if dir := filepath.Dir(savePath); dir != "." {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return errWrap(err, "Could not create directory: "+dir)
+		}
+	}
+	tmpPath := savePath + ".tmp"
+	saveFh, err := os.Create(tmpPath)
+	if err != nil {
+		return errWrap(err, "Could not create file: "+tmpPath)
+	}
+	defer saveFh.Close()
+
+	// Opening a FIFO for writing blocks until the downstream reader opens it
+	fwdFh, err := os.OpenFile(fwdPath, os.O_WRONLY, 0644)
+	if err != nil {
+		return errWrap(err, "Could not open file for writing: "+fwdPath)
+	}
+	defer fwdFh.Close()
+
+	inFh, err := os.Open(inPath)
+	if err != nil {
+		return errWrap(err, "Could not open file for reading: "+inPath)
+	}
+	defer inFh.Close()
+
+	if _, err := io.Copy(io.MultiWriter(saveFh, fwdFh), inFh); err != nil {
+		return errWrap(err, "Could not copy "+inPath)
+	}
+	if err := saveFh.Close(); err != nil {
+		return errWrap(err, "Could not close file: "+tmpPath)
+	}
+	return os.Rename(tmpPath, savePath)
+}
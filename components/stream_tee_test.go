@@ -0,0 +1,38 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestStreamTee(t *testing.T) {
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := wf.NewProc("src", "printf 'a\\nb\\nc\\n' > {os:out}")
+	src.SetOut("out", "/tmp/stream_tee_src.txt")
+
+	tee := NewStreamTee(wf, "tee", "/tmp/stream_tee_saved.txt")
+	tee.In().From(src.Out("out"))
+
+	dst := wf.NewProc("dst", "cat {i:in} > {o:out}")
+	dst.In("in").From(tee.Out())
+	dst.SetOut("out", "/tmp/stream_tee_forwarded.txt")
+
+	wf.Run()
+
+	expected := "a\nb\nc\n"
+	for _, path := range []string{"/tmp/stream_tee_saved.txt", "/tmp/stream_tee_forwarded.txt"} {
+		dat, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("Could not read file %s: %v", path, err)
+			continue
+		}
+		if string(dat) != expected {
+			t.Errorf("File %s contained '%s', expected '%s'", path, string(dat), expected)
+		}
+		os.Remove(path)
+		os.Remove(path + ".audit.json")
+	}
+}
@@ -32,12 +32,12 @@ func (p *StreamToSubStream) OutSubStream() *scipipe.OutPort { return p.OutPort("
 func (p *StreamToSubStream) Run() {
 	defer p.CloseAllOutPorts()
 
-	scipipe.Debug.Println("Creating new information packet for the substream...")
+	p.Workflow().DebugLogger().Println("Creating new information packet for the substream...")
 	subStreamIP := scipipe.NewFileIP("")
-	scipipe.Debug.Printf("Setting in-port of process %s to IP substream field\n", p.Name())
+	p.Workflow().DebugLogger().Printf("Setting in-port of process %s to IP substream field\n", p.Name())
 	subStreamIP.SubStream = p.In()
 
-	scipipe.Debug.Printf("Sending sub-stream IP in process %s...\n", p.Name())
+	p.Workflow().DebugLogger().Printf("Sending sub-stream IP in process %s...\n", p.Name())
 	p.OutSubStream().Send(subStreamIP)
-	scipipe.Debug.Printf("Done sending sub-stream IP in process %s.\n", p.Name())
+	p.Workflow().DebugLogger().Printf("Done sending sub-stream IP in process %s.\n", p.Name())
 }
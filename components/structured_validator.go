@@ -0,0 +1,133 @@
+package components
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/scipipe/scipipe"
+)
+
+// StructuredValidator is a process that verifies that each file received on
+// its in-port is well-formed structured data, in the format given to
+// NewStructuredValidator, which can be "json" or "xml". Well-formed files are
+// passed through on the out-port, while the workflow fails on malformed files,
+// unless RouteInvalid is set, in which case they are sent on the invalid
+// out-port instead.
+type StructuredValidator struct {
+	scipipe.BaseProcess
+	format string
+	// RouteInvalid makes malformed files be sent on the invalid out-port,
+	// rather than failing the workflow
+	RouteInvalid bool
+}
+
+// structuredValidators contains the functions for checking well-formedness of
+// data in the formats supported by StructuredValidator
+var structuredValidators = map[string]func(io.Reader) error{
+	"json": validateJSON,
+	"xml":  validateXML,
+}
+
+// NewStructuredValidator returns a new initialized StructuredValidator
+// process, checking that files are well-formed in format ("json" or "xml")
+func NewStructuredValidator(wf *scipipe.Workflow, name string, format string) *StructuredValidator {
+	if _, ok := structuredValidators[format]; !ok {
+		scipipe.Failf("StructuredValidator with name '%s': Unsupported format '%s', must be 'json' or 'xml'", name, format)
+	}
+	p := &StructuredValidator{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		format:      format,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	p.InitOutPort(p, "invalid")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which files to validate are received
+func (p *StructuredValidator) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which well-formed files are sent
+func (p *StructuredValidator) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// OutInvalid returns the out-port on which malformed files are sent, if
+// RouteInvalid is set
+func (p *StructuredValidator) OutInvalid() *scipipe.OutPort { return p.OutPort("invalid") }
+
+// Run runs the StructuredValidator process
+func (p *StructuredValidator) Run() {
+	defer p.CloseAllOutPorts()
+
+	for ip := range p.In().Chan {
+		err := validateStructuredFile(ip.Path(), structuredValidators[p.format])
+		if err == nil {
+			p.Out().Send(ip)
+			continue
+		}
+		if !p.RouteInvalid {
+			scipipe.Failf("StructuredValidator %s: %s\n", p.Name(), err.Error())
+		}
+		p.Workflow().Logger().Printf("| %-32s | Sending malformed file to invalid out-port: %s\n", p.Name(), err.Error())
+		p.OutInvalid().Send(ip)
+	}
+}
+
+// validateStructuredFile returns an error if the content of the file at path
+// is not well-formed according to validate
+func validateStructuredFile(path string, validate func(io.Reader) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return errWrap(err, "Could not open file "+path)
+	}
+	defer f.Close()
+	if err := validate(f); err != nil {
+		return fmt.Errorf("File %s is malformed: %s", path, err.Error())
+	}
+	return nil
+}
+
+// validateJSON returns an error if r does not contain one or more well-formed
+// JSON values, such as a single JSON document, or JSON lines
+func validateJSON(r io.Reader) error {
+	dec := json.NewDecoder(r)
+	for values := 0; ; values++ {
+		var v json.RawMessage
+		err := dec.Decode(&v)
+		if err == io.EOF {
+			if values == 0 {
+				return errors.New("No JSON value found")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// validateXML returns an error if r does not contain a well-formed XML
+// document
+func validateXML(r io.Reader) error {
+	dec := xml.NewDecoder(r)
+	hasRoot := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if !hasRoot {
+				return errors.New("No XML element found")
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			hasRoot = true
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestStructuredValidator(t *testing.T) {
+	dir, err := ioutil.TempDir("", "structured_validator_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	validPath := filepath.Join(dir, "valid.json")
+	err = ioutil.WriteFile(validPath, []byte(`{"sample": "a", "reads": [1, 2, 3]}`+"\n"), 0644)
+	scipipe.Check(err)
+	malformedPath := filepath.Join(dir, "malformed.json")
+	err = ioutil.WriteFile(malformedPath, []byte(`{"sample": "b", "reads": [1, 2`+"\n"), 0644)
+	scipipe.Check(err)
+
+	err = validateStructuredFile(malformedPath, validateJSON)
+	if err == nil {
+		t.Fatalf("Expected malformed JSON file to be rejected, but it was not")
+	}
+	if !strings.Contains(err.Error(), malformedPath) {
+		t.Errorf("Expected error to mention the malformed file, but it was: %s", err.Error())
+	}
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", validPath, malformedPath)
+	validator := NewStructuredValidator(wf, "validator", "json")
+	validator.RouteInvalid = true
+	validator.In().From(src.Out())
+	col := newPortCollector(wf, "collector", "valid", "invalid")
+	col.InPort("valid").From(validator.Out())
+	col.InPort("invalid").From(validator.OutInvalid())
+	wf.Run()
+
+	if !reflect.DeepEqual(col.paths("valid"), []string{validPath}) {
+		t.Errorf("Expected only the valid file to be passed through, but got: %v", col.paths("valid"))
+	}
+	if !reflect.DeepEqual(col.paths("invalid"), []string{malformedPath}) {
+		t.Errorf("Expected the malformed file to be routed to the invalid port, but got: %v", col.paths("invalid"))
+	}
+}
+
+func TestValidateXML(t *testing.T) {
+	for doc, wellFormed := range map[string]bool{
+		`<sample name="a"><reads>3</reads></sample>`: true,
+		`<?xml version="1.0"?><samples/>`:            true,
+		`<sample name="a"><reads>3</sample>`:         false,
+		`<sample name="a">`:                          false,
+		``:                                           false,
+	} {
+		err := validateXML(strings.NewReader(doc))
+		if wellFormed && err != nil {
+			t.Errorf("Expected XML to be accepted, but got error %v: %s", err, doc)
+		}
+		if !wellFormed && err == nil {
+			t.Errorf("Expected XML to be rejected, but it was not: %s", doc)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package components
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/scipipe/scipipe"
+)
+
+// SweepCollector is a process that gathers the results of the runs of a
+// parameter sweep into one table. For each file received on its in-port, it
+// computes metrics with a user-provided function, and when the in-port is
+// closed, writes a TSV file to outPath, with one row per file, containing the
+// values of the parameters named by paramKeys, as found in the audit info of
+// the file, followed by the metrics. The header row has the parameter names,
+// and then the metric names, in alphabetical order. Rows are sorted by the
+// parameter values, in the order of paramKeys, and metrics missing for a file
+// are left empty. The table is sent on the Out() out-port.
+type SweepCollector struct {
+	scipipe.BaseProcess
+	outPath    string
+	paramKeys  []string
+	metricFunc func(*scipipe.FileIP) map[string]float64
+}
+
+// NewSweepCollector returns a new initialized SweepCollector process
+func NewSweepCollector(wf *scipipe.Workflow, name string, outPath string, paramKeys []string, metricFunc func(*scipipe.FileIP) map[string]float64) *SweepCollector {
+	if metricFunc == nil {
+		scipipe.Failf("SweepCollector with name '%s': No metric function given\n", name)
+	}
+	p := &SweepCollector{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		outPath:     outPath,
+		paramKeys:   paramKeys,
+		metricFunc:  metricFunc,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the result files of the sweep are received
+func (p *SweepCollector) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the summary table is sent
+func (p *SweepCollector) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// sweepRun contains the parameter values and metrics of one run of a sweep
+type sweepRun struct {
+	params  []string
+	metrics map[string]float64
+}
+
+// Run runs the SweepCollector process
+func (p *SweepCollector) Run() {
+	defer p.CloseAllOutPorts()
+
+	runs := []sweepRun{}
+	for ip := range p.In().Chan {
+		params := []string{}
+		for _, key := range p.paramKeys {
+			val, ok := ip.AuditInfo().Params[key]
+			if !ok {
+				scipipe.Failf("SweepCollector %s: No parameter %s in audit info of file %s\n", p.Name(), key, ip.Path())
+			}
+			params = append(params, val)
+		}
+		runs = append(runs, sweepRun{params: params, metrics: p.metricFunc(ip)})
+	}
+
+	err := writeFileAtomically(p.outPath, formatDelimited(sweepTable(p.paramKeys, runs), '\t'))
+	scipipe.CheckWithMsg(err, "SweepCollector "+p.Name()+": Could not write file "+p.outPath)
+	p.Out().Send(scipipe.NewFileIP(p.outPath))
+}
+
+// sweepTable returns the rows of the summary table of runs, including the
+// header row
+func sweepTable(paramKeys []string, runs []sweepRun) [][]string {
+	metricNames := []string{}
+	for _, run := range runs {
+		for name := range run.metrics {
+			if !containsString(metricNames, name) {
+				metricNames = append(metricNames, name)
+			}
+		}
+	}
+	sort.Strings(metricNames)
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		for k := range paramKeys {
+			if runs[i].params[k] != runs[j].params[k] {
+				return runs[i].params[k] < runs[j].params[k]
+			}
+		}
+		return false
+	})
+
+	header := append(append([]string{}, paramKeys...), metricNames...)
+	rows := [][]string{header}
+	for _, run := range runs {
+		row := append([]string{}, run.params...)
+		for _, name := range metricNames {
+			if val, ok := run.metrics[name]; ok {
+				row = append(row, strconv.FormatFloat(val, 'g', -1, 64))
+			} else {
+				row = append(row, "")
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
@@ -0,0 +1,54 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestSweepCollector(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sweep_collector_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	sweep := NewParamSweep(wf, "sweep", map[string][]string{
+		"kernel": {"rbf", "linear", "poly"},
+	})
+	train := wf.NewProc("train", "echo {p:kernel} | wc -c > {o:score}")
+	train.InParam("kernel").From(sweep.OutParam("kernel"))
+	train.SetOut("score", filepath.Join(dir, "score_{p:kernel}.txt"))
+
+	tablePath := filepath.Join(dir, "summary.tsv")
+	collector := NewSweepCollector(wf, "collector", tablePath, []string{"kernel"}, func(ip *scipipe.FileIP) map[string]float64 {
+		score, err := strconv.ParseFloat(strings.TrimSpace(string(ip.Read())), 64)
+		scipipe.Check(err)
+		metrics := map[string]float64{"score": score}
+		if ip.Param("kernel") != "poly" {
+			metrics["half"] = score / 2
+		}
+		return metrics
+	})
+	collector.In().From(train.Out("score"))
+	col := newIPCollector(wf, "table_collector")
+	col.In().From(collector.Out())
+	wf.Run()
+
+	if paths := col.paths(); len(paths) != 1 || paths[0] != tablePath {
+		t.Fatalf("Expected the table %s to be sent on the out-port, got: %v", tablePath, paths)
+	}
+	table, err := ioutil.ReadFile(tablePath)
+	scipipe.Check(err)
+	expected := "kernel\thalf\tscore\n" +
+		"linear\t3.5\t7\n" +
+		"poly\t\t5\n" +
+		"rbf\t2\t4\n"
+	if string(table) != expected {
+		t.Errorf("Expected summary table:\n%s\nGot:\n%s", expected, table)
+	}
+}
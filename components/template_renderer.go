@@ -0,0 +1,115 @@
+package components
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/scipipe/scipipe"
+)
+
+// TemplateRenderer is a process that, for each IP received on its in-port,
+// renders a Go text/template (see https://golang.org/pkg/text/template) read
+// from templatePath, and sends the rendered file on its out-port. This is
+// useful for example for generating config files or scripts for tools from the
+// tags and params of IPs. The template is rendered with the following fields:
+//
+//	{{.Path}}     The path of the IP
+//	{{.Tags}}     The tags of the IP, accessed as {{.Tags.sample}}
+//	{{.Params}}   The params of the IP, accessed as {{.Params.genome}}
+//	{{.Content}}  The content of the IP's file
+//
+// Referring to tags or params that the IP does not have, is an error. The
+// workflow fails if the template can not be parsed or rendered. Rendered files
+// are named after the IPs and the template file, without any .tmpl extension,
+// so that rendering config.yaml.tmpl for sample_a.fq results in
+// sample_a.fq.config.yaml. The tags of the IPs are carried over to the
+// rendered files.
+type TemplateRenderer struct {
+	scipipe.BaseProcess
+	templatePath string
+}
+
+// NewTemplateRenderer returns a new initialized TemplateRenderer process,
+// rendering the template in the file at templatePath
+func NewTemplateRenderer(wf *scipipe.Workflow, name string, templatePath string) *TemplateRenderer {
+	p := &TemplateRenderer{
+		BaseProcess:  scipipe.NewBaseProcess(wf, name),
+		templatePath: templatePath,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port on which the IPs to render the template for are
+// received
+func (p *TemplateRenderer) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the rendered files are sent
+func (p *TemplateRenderer) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the TemplateRenderer process
+func (p *TemplateRenderer) Run() {
+	defer p.CloseAllOutPorts()
+
+	tmpl, err := parseTemplateFile(p.templatePath)
+	scipipe.CheckWithMsg(err, "TemplateRenderer "+p.Name()+": Could not parse template "+p.templatePath)
+
+	outSuffix := "." + strings.TrimSuffix(filepath.Base(p.templatePath), ".tmpl")
+	for inIP := range p.In().Chan {
+		rendered, err := renderTemplate(tmpl, inIP)
+		scipipe.CheckWithMsg(err, "TemplateRenderer "+p.Name()+": Could not render template "+p.templatePath+" for "+inIP.Path())
+
+		outPath := inIP.Path() + outSuffix
+		err = writeFileAtomically(outPath, rendered)
+		scipipe.CheckWithMsg(err, "TemplateRenderer "+p.Name()+": Could not write file "+outPath)
+		outIP := scipipe.NewFileIP(outPath)
+		outIP.AddTags(inIP.Tags())
+		outIP.WriteAuditLogToFile()
+		p.Out().Send(outIP)
+	}
+}
+
+// parseTemplateFile parses the template in the file at path, set up to fail on
+// missing keys
+func parseTemplateFile(path string) (*template.Template, error) {
+	tmplText, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(tmplText))
+}
+
+// templateData is the data templates are rendered with by TemplateRenderer
+type templateData struct {
+	ip *scipipe.FileIP
+}
+
+// Path returns the path of the IP
+func (d templateData) Path() string { return d.ip.Path() }
+
+// Tags returns the tags of the IP
+func (d templateData) Tags() map[string]string { return d.ip.Tags() }
+
+// Params returns the params of the IP
+func (d templateData) Params() map[string]string { return d.ip.AuditInfo().Params }
+
+// Content returns the content of the IP's file. It is only read if the
+// template refers to it.
+func (d templateData) Content() (string, error) {
+	content, err := ioutil.ReadFile(d.ip.Path())
+	return string(content), err
+}
+
+// renderTemplate renders tmpl for ip
+func renderTemplate(tmpl *template.Template, ip *scipipe.FileIP) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := tmpl.Execute(buf, templateData{ip: ip}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
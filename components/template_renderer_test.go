@@ -0,0 +1,61 @@
+package components
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestTemplateRenderer(t *testing.T) {
+	tmplPath := "/tmp/template_renderer_test.yaml.tmpl"
+	err := ioutil.WriteFile(tmplPath, []byte("sample: {{.Tags.sample}}\ninput: {{.Path}}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmplPath)
+
+	paths := []string{"/tmp/sample_a.fq", "/tmp/sample_b.fq"}
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", paths...)
+	tagger := NewMapToTags(wf, "tagger", func(ip *scipipe.FileIP) map[string]string {
+		return map[string]string{"sample": strings.TrimSuffix(strings.TrimPrefix(filepath.Base(ip.Path()), "sample_"), ".fq")}
+	})
+	tagger.In().From(src.Out())
+	renderer := NewTemplateRenderer(wf, "renderer", tmplPath)
+	renderer.In().From(tagger.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(renderer.Out())
+	wf.Run()
+
+	if len(col.ips) != len(paths) {
+		t.Fatalf("Expected %d rendered files, got %d", len(paths), len(col.ips))
+	}
+	for _, ip := range col.ips {
+		sample := ip.Tag("sample")
+		inPath := "/tmp/sample_" + sample + ".fq"
+		if ip.Path() != inPath+".template_renderer_test.yaml" {
+			t.Errorf("Unexpected path of rendered file: %s", ip.Path())
+		}
+		expected := "sample: " + sample + "\ninput: " + inPath + "\n"
+		if string(ip.Read()) != expected {
+			t.Errorf("Expected rendered file %s to contain '%s', but was '%s'", ip.Path(), expected, string(ip.Read()))
+		}
+		os.Remove(ip.Path())
+		os.Remove(ip.AuditFilePath())
+	}
+	for _, path := range paths {
+		os.Remove(path + ".audit.json")
+	}
+}
+
+func TestRenderTemplateMissingTag(t *testing.T) {
+	tmpl := template.Must(template.New("test").Option("missingkey=error").Parse("sample: {{.Tags.sample}}\n"))
+	if _, err := renderTemplate(tmpl, scipipe.NewFileIP("/tmp/sample_a.fq")); err == nil {
+		t.Error("Expected an error when rendering a template with a tag missing in the IP")
+	}
+}
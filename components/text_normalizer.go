@@ -0,0 +1,402 @@
+package components
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/scipipe/scipipe"
+)
+
+// TextNormalizer is a process that, for each text file received on its
+// in-port, normalizes its line endings, and optionally re-encodes it, and
+// sends an IP for the normalized file on its out-port, for use with tools that
+// break on files with mixed line endings or unexpected encodings.
+//
+// eol is the line ending to convert all line endings (\r\n, \r or \n) to,
+// which is either "lf" or "crlf". toEncoding is the encoding to re-encode
+// files in, which is either "utf-8" or "latin-1", or an empty string for
+// keeping the encoding, in which case files starting with a UTF-16 or UTF-8
+// byte order mark are written back in that encoding. Files being re-encoded
+// are read as UTF-16 if they start with a UTF-16 byte order mark, as UTF-8 if
+// they are valid UTF-8 (with any byte order mark removed), and as Latin-1
+// otherwise.
+//
+// Normalized files are named after the incoming ones, with ".normalized"
+// inserted before the file extension, and get the tags of the incoming IPs.
+type TextNormalizer struct {
+	scipipe.BaseProcess
+	toEncoding string
+	eol        string
+}
+
+// NewTextNormalizer returns a new initialized TextNormalizer process,
+// converting line endings to eol ("lf" or "crlf") and re-encoding files to
+// toEncoding ("utf-8", "latin-1", or "" for keeping the encoding)
+func NewTextNormalizer(wf *scipipe.Workflow, name string, toEncoding string, eol string) *TextNormalizer {
+	toEncoding = strings.ToLower(toEncoding)
+	switch toEncoding {
+	case "", "utf-8", "latin-1":
+	case "utf8":
+		toEncoding = "utf-8"
+	case "latin1", "iso-8859-1":
+		toEncoding = "latin-1"
+	default:
+		scipipe.Failf("TextNormalizer with name '%s': Unsupported encoding '%s' (supported are 'utf-8' and 'latin-1')\n", name, toEncoding)
+	}
+	eol = strings.ToLower(eol)
+	if eol != "lf" && eol != "crlf" {
+		scipipe.Failf("TextNormalizer with name '%s': Unsupported line ending '%s' (supported are 'lf' and 'crlf')\n", name, eol)
+	}
+	p := &TextNormalizer{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+		toEncoding:  toEncoding,
+		eol:         eol,
+	}
+	p.InitInPort(p, "in")
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+// In returns the in-port for the text files to normalize
+func (p *TextNormalizer) In() *scipipe.InPort { return p.InPort("in") }
+
+// Out returns the out-port on which the normalized files are sent
+func (p *TextNormalizer) Out() *scipipe.OutPort { return p.OutPort("out") }
+
+// Run runs the TextNormalizer process
+func (p *TextNormalizer) Run() {
+	defer p.CloseAllOutPorts()
+
+	for inIP := range p.In().Chan {
+		outPath := pathWithInfix(inIP.Path(), "normalized")
+		if _, err := os.Stat(outPath); err == nil {
+			p.Workflow().Logger().Printf("| %-32s | Normalized file already exists, so skipping: %s\n", p.Name(), outPath)
+		} else {
+			err := normalizeFile(inIP.Path(), outPath, p.toEncoding, p.eol)
+			scipipe.CheckWithMsg(err, "TextNormalizer "+p.Name()+": Could not normalize file "+inIP.Path())
+		}
+		outIP := scipipe.NewFileIP(outPath)
+		outIP.AddTags(inIP.Tags())
+		outIP.WriteAuditLogToFile()
+		p.Out().Send(outIP)
+	}
+}
+
+// normalizeFile normalizes the text file at inPath, writing the result to
+// outPath (see normalizeStream). The file is streamed, rather than read into
+// memory, in one pass for detecting its encoding, and one for normalizing it.
+// Files that are not re-encoded are only checked for a byte order mark.
+func normalizeFile(inPath string, outPath string, toEncoding string, eol string) error {
+	inFile, err := os.Open(inPath)
+	if err != nil {
+		return errWrap(err, "Could not open file: "+inPath)
+	}
+	fromEncoding := ""
+	if toEncoding != "" {
+		fromEncoding, err = detectTextEncoding(inFile)
+	} else {
+		fromEncoding, err = detectBOMEncoding(bufio.NewReader(inFile))
+	}
+	inFile.Close()
+	if err != nil {
+		return errWrap(err, "Could not read file: "+inPath)
+	}
+
+	inFile, err = os.Open(inPath)
+	if err != nil {
+		return errWrap(err, "Could not open file: "+inPath)
+	}
+	defer inFile.Close()
+	return writeStreamAtomically(outPath, func(w io.Writer) error {
+		return normalizeStream(inFile, w, fromEncoding, toEncoding, eol)
+	})
+}
+
+// Text encodings detected by detectTextEncoding
+const (
+	encodingUTF8BOM = "utf-8-bom"
+	encodingUTF8    = "utf-8"
+	encodingUTF16LE = "utf-16le"
+	encodingUTF16BE = "utf-16be"
+	encodingLatin1  = "latin-1"
+)
+
+// utf8ValidChunkSize is the size of the chunks that text is checked for
+// being valid UTF-8 in
+const utf8ValidChunkSize = 64 * 1024
+
+// detectTextEncoding returns the encoding of the text read from r, which is
+// UTF-16 if it starts with a UTF-16 byte order mark, UTF-8 (with or without
+// byte order mark) if it is valid UTF-8, and Latin-1 otherwise
+func detectTextEncoding(r io.Reader) (string, error) {
+	br := bufio.NewReaderSize(r, utf8ValidChunkSize)
+	if bomEncoding, err := detectBOMEncoding(br); err != nil || bomEncoding != "" {
+		return bomEncoding, err
+	}
+	// The text is checked in chunks, where any incomplete character at the
+	// end of a chunk is carried over to the next one
+	chunk := make([]byte, utf8ValidChunkSize)
+	carry := []byte{}
+	for {
+		n, err := br.Read(chunk)
+		dat := append(carry, chunk[:n]...)
+		end := len(dat)
+		for back := 1; back <= utf8.UTFMax && back <= len(dat); back++ {
+			if utf8.RuneStart(dat[len(dat)-back]) {
+				if !utf8.FullRune(dat[len(dat)-back:]) {
+					end = len(dat) - back
+				}
+				break
+			}
+		}
+		if !utf8.Valid(dat[:end]) {
+			return encodingLatin1, nil
+		}
+		carry = append([]byte{}, dat[end:]...)
+		if err == io.EOF {
+			if len(carry) > 0 {
+				return encodingLatin1, nil
+			}
+			return encodingUTF8, nil
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+}
+
+// detectBOMEncoding returns the encoding given by the byte order mark that
+// the text read from br starts with, which is UTF-8 or UTF-16, or an empty
+// string if it has none. Nothing is consumed from br.
+func detectBOMEncoding(br *bufio.Reader) (string, error) {
+	bom, err := br.Peek(3)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	switch {
+	case bytes.HasPrefix(bom, []byte{0xEF, 0xBB, 0xBF}):
+		return encodingUTF8BOM, nil
+	case bytes.HasPrefix(bom, []byte{0xFF, 0xFE}):
+		return encodingUTF16LE, nil
+	case bytes.HasPrefix(bom, []byte{0xFE, 0xFF}):
+		return encodingUTF16BE, nil
+	}
+	return "", nil
+}
+
+// normalizeStream converts the line endings of the text read from r, in
+// fromEncoding (as detected by detectTextEncoding or detectBOMEncoding), to
+// eol, and re-encodes it to toEncoding, writing the result to w. If
+// toEncoding is empty, the text is written in fromEncoding, including any
+// byte order mark, and text without byte order mark is written as is, except
+// for the line endings. The text is processed line by line, so lines of any
+// length are supported, without reading all of it into memory.
+func normalizeStream(r io.Reader, w io.Writer, fromEncoding string, toEncoding string, eol string) error {
+	br := bufio.NewReader(r)
+	// UTF-16 is decoded to UTF-8, so that lines can be split on bytes
+	srcEncoding := fromEncoding
+	switch fromEncoding {
+	case encodingUTF8BOM:
+		br.Discard(3)
+		srcEncoding = encodingUTF8
+	case encodingUTF16LE, encodingUTF16BE:
+		br.Discard(2)
+		br = bufio.NewReader(&utf16Reader{r: br, bigEndian: fromEncoding == encodingUTF16BE})
+		srcEncoding = encodingUTF8
+	}
+
+	// encode encodes text, in srcEncoding, to the output encoding
+	encode := func(text []byte) ([]byte, error) {
+		if toEncoding != "" {
+			return encodeText(decodeText(text, srcEncoding), toEncoding)
+		}
+		switch fromEncoding {
+		case encodingUTF16LE, encodingUTF16BE:
+			return encodeUTF16(string(text), fromEncoding == encodingUTF16BE), nil
+		}
+		return text, nil
+	}
+	if toEncoding == "" {
+		var bom []byte
+		switch fromEncoding {
+		case encodingUTF8BOM:
+			bom = []byte{0xEF, 0xBB, 0xBF}
+		case encodingUTF16LE:
+			bom = []byte{0xFF, 0xFE}
+		case encodingUTF16BE:
+			bom = []byte{0xFE, 0xFF}
+		}
+		if _, err := w.Write(bom); err != nil {
+			return err
+		}
+	}
+	eolText := []byte("\n")
+	if eol == "crlf" {
+		eolText = []byte("\r\n")
+	}
+	eolBytes, err := encode(eolText)
+	if err != nil {
+		return err
+	}
+
+	scanner := bufio.NewScanner(br)
+	scanner.Buffer(make([]byte, 64*1024), maxLineLength)
+	scanner.Split(scanLinesAnyEOL)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		text := bytes.TrimRight(line, "\r\n")
+		hasEOL := len(text) < len(line)
+		encoded, err := encode(text)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(encoded); err != nil {
+			return err
+		}
+		if hasEOL {
+			if _, err := w.Write(eolBytes); err != nil {
+				return err
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// scanLinesAnyEOL is a split function for bufio.Scanner, returning lines
+// ended by \r\n, \r or \n, including the line ending, as well as any last line
+// without line ending
+func scanLinesAnyEOL(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if atEOF && len(data) == 0 {
+		return 0, nil, nil
+	}
+	if i := bytes.IndexAny(data, "\r\n"); i >= 0 {
+		if data[i] == '\n' {
+			return i + 1, data[:i+1], nil
+		}
+		// A \r can be followed by a \n, which needs to be read to know
+		if i+1 < len(data) {
+			if data[i+1] == '\n' {
+				return i + 2, data[:i+2], nil
+			}
+			return i + 1, data[:i+1], nil
+		}
+		if atEOF {
+			return i + 1, data[:i+1], nil
+		}
+		return 0, nil, nil
+	}
+	if atEOF {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// decodeText decodes dat from fromEncoding, which is either "utf-8" or
+// "latin-1"
+func decodeText(dat []byte, fromEncoding string) string {
+	if fromEncoding == encodingUTF8 {
+		return string(dat)
+	}
+	runes := make([]rune, len(dat))
+	for i, b := range dat {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// utf16Reader is an io.Reader decoding the UTF-16 text read from r, in big
+// endian byte order if bigEndian is true, and in little endian byte order
+// otherwise, to UTF-8
+type utf16Reader struct {
+	r         *bufio.Reader
+	bigEndian bool
+	// decoded is the decoded text not yet read
+	decoded []byte
+	// pending is a high surrogate, waiting for the low surrogate following
+	// it, or 0
+	pending uint16
+	eof     bool
+}
+
+// Read reads decoded UTF-8 text into p
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	for len(u.decoded) == 0 {
+		if u.eof {
+			return 0, io.EOF
+		}
+		if err := u.decodeUnits(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, u.decoded)
+	u.decoded = u.decoded[n:]
+	return n, nil
+}
+
+// decodeUnits decodes the next chunk of UTF-16 code units. Surrogate pairs
+// split between chunks are kept together, and any odd last byte is ignored.
+func (u *utf16Reader) decodeUnits() error {
+	units := []uint16{}
+	if u.pending != 0 {
+		units = append(units, u.pending)
+		u.pending = 0
+	}
+	pair := make([]byte, 2)
+	for len(units) < 4096 {
+		if _, err := io.ReadFull(u.r, pair); err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF {
+				return err
+			}
+			u.eof = true
+			break
+		}
+		if u.bigEndian {
+			units = append(units, uint16(pair[0])<<8|uint16(pair[1]))
+		} else {
+			units = append(units, uint16(pair[1])<<8|uint16(pair[0]))
+		}
+	}
+	if last := len(units) - 1; !u.eof && last >= 0 && utf16.IsSurrogate(rune(units[last])) && units[last] < 0xDC00 {
+		u.pending = units[last]
+		units = units[:last]
+	}
+	u.decoded = []byte(string(utf16.Decode(units)))
+	return nil
+}
+
+// encodeUTF16 encodes text in UTF-16, in big endian byte order if bigEndian
+// is true, and in little endian byte order otherwise
+func encodeUTF16(text string, bigEndian bool) []byte {
+	units := utf16.Encode([]rune(text))
+	encoded := make([]byte, 0, 2*len(units))
+	for _, unit := range units {
+		if bigEndian {
+			encoded = append(encoded, byte(unit>>8), byte(unit))
+		} else {
+			encoded = append(encoded, byte(unit), byte(unit>>8))
+		}
+	}
+	return encoded
+}
+
+// encodeText encodes text in toEncoding, which is either "utf-8" or "latin-1"
+func encodeText(text string, toEncoding string) ([]byte, error) {
+	if toEncoding == "utf-8" {
+		return []byte(text), nil
+	}
+	encoded := make([]byte, 0, len(text))
+	for _, r := range text {
+		if r > 0xFF {
+			return nil, fmt.Errorf("Character %q can not be encoded in Latin-1", r)
+		}
+		encoded = append(encoded, byte(r))
+	}
+	return encoded, nil
+}
@@ -0,0 +1,117 @@
+package components
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestTextNormalizer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text_normalizer_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	crlfPath := filepath.Join(dir, "crlf.txt")
+	err = ioutil.WriteFile(crlfPath, []byte("line 1\r\nline 2\r\nline 3\r\n"), 0644)
+	scipipe.Check(err)
+
+	wf := scipipe.NewWorkflow("wf", 4)
+	src := NewFileSource(wf, "src", crlfPath)
+	norm := NewTextNormalizer(wf, "normalize", "", "lf")
+	norm.In().From(src.Out())
+	col := newIPCollector(wf, "collector")
+	col.In().From(norm.Out())
+	wf.Run()
+
+	normalizedPath := filepath.Join(dir, "crlf.normalized.txt")
+	if !reflect.DeepEqual(col.paths(), []string{normalizedPath}) {
+		t.Fatalf("Expected output paths %v, got %v", []string{normalizedPath}, col.paths())
+	}
+	content, err := ioutil.ReadFile(normalizedPath)
+	scipipe.Check(err)
+	expected := "line 1\nline 2\nline 3\n"
+	if string(content) != expected {
+		t.Errorf("Expected normalized file to contain %q, got %q", expected, string(content))
+	}
+}
+
+func TestNormalizeFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text_normalizer_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	inPath := filepath.Join(dir, "in.txt")
+	outPath := filepath.Join(dir, "out.txt")
+	for _, tc := range []struct {
+		in         []byte
+		toEncoding string
+		eol        string
+		expected   []byte
+	}{
+		{[]byte("a\rb\nc\r\n"), "", "crlf", []byte("a\r\nb\r\nc\r\n")},
+		{[]byte("caf\xe9\r\n"), "utf-8", "lf", []byte("café\n")},
+		{[]byte("\xef\xbb\xbfcafé\n"), "latin-1", "lf", []byte("caf\xe9\n")},
+		{[]byte{0xFF, 0xFE, 'h', 0, 'i', 0, '\r', 0, '\n', 0}, "utf-8", "lf", []byte("hi\n")},
+		// A surrogate pair, in big endian UTF-16
+		{[]byte{0xFE, 0xFF, 0xD8, 0x3D, 0xDE, 0x00, 0, '\n'}, "utf-8", "lf", []byte("\U0001F600\n")},
+		// A last line without line ending is kept without one
+		{[]byte("a\r\nb"), "", "lf", []byte("a\nb")},
+		{[]byte("a\r\r\n\nb\r"), "", "crlf", []byte("a\r\n\r\n\r\nb\r\n")},
+		{[]byte(""), "utf-8", "lf", []byte{}},
+		// Files with a byte order mark are kept in their encoding
+		{[]byte{0xFF, 0xFE, 'h', 0, 'i', 0, '\r', 0, '\n', 0, 'x', 0}, "", "lf", []byte{0xFF, 0xFE, 'h', 0, 'i', 0, '\n', 0, 'x', 0}},
+		{[]byte{0xFE, 0xFF, 0xD8, 0x3D, 0xDE, 0x00, 0, '\n'}, "", "crlf", []byte{0xFE, 0xFF, 0xD8, 0x3D, 0xDE, 0x00, 0, '\r', 0, '\n'}},
+		{[]byte("\xef\xbb\xbfa\r\nb"), "", "lf", []byte("\xef\xbb\xbfa\nb")},
+	} {
+		scipipe.Check(ioutil.WriteFile(inPath, tc.in, 0644))
+		scipipe.Check(normalizeFile(inPath, outPath, tc.toEncoding, tc.eol))
+		normalized, err := ioutil.ReadFile(outPath)
+		scipipe.Check(err)
+		if !bytes.Equal(normalized, tc.expected) {
+			t.Errorf("Expected %q normalized to %s with %s line endings to be %q, got %q", tc.in, tc.toEncoding, tc.eol, tc.expected, normalized)
+		}
+	}
+}
+
+func TestNormalizeFileLongLines(t *testing.T) {
+	dir, err := ioutil.TempDir("", "text_normalizer_test")
+	scipipe.Check(err)
+	defer os.RemoveAll(dir)
+
+	// Lines longer than the default max token size of bufio.Scanner (64 KiB),
+	// with the \r\n of the first one likely split between reads
+	longLine := strings.Repeat("A", 200*1024)
+	inPath := filepath.Join(dir, "long.txt")
+	err = ioutil.WriteFile(inPath, []byte(longLine+"\r\n"+longLine+"\xe9\r\n"), 0644)
+	scipipe.Check(err)
+
+	outPath := filepath.Join(dir, "long.normalized.txt")
+	err = normalizeFile(inPath, outPath, "utf-8", "lf")
+	if err != nil {
+		t.Fatalf("Expected file with long lines to be normalized, got: %s", err.Error())
+	}
+	dat, err := ioutil.ReadFile(outPath)
+	scipipe.Check(err)
+	if string(dat) != longLine+"\n"+longLine+"é\n" {
+		t.Errorf("Expected long lines to be normalized, got %d bytes", len(dat))
+	}
+}
+
+func TestScanLinesAnyEOL(t *testing.T) {
+	// A \r at the end of the data read so far could be followed by a \n
+	if advance, token, _ := scanLinesAnyEOL([]byte("a\r"), false); advance != 0 || token != nil {
+		t.Errorf("Expected more data to be requested after a trailing \\r, got advance %d and token %q", advance, token)
+	}
+	if advance, token, _ := scanLinesAnyEOL([]byte("a\r\nb"), false); advance != 3 || string(token) != "a\r\n" {
+		t.Errorf("Expected line ending with \\r\\n, got advance %d and token %q", advance, token)
+	}
+	if advance, token, _ := scanLinesAnyEOL([]byte("a\r"), true); advance != 2 || string(token) != "a\r" {
+		t.Errorf("Expected line ending with \\r at end of input, got advance %d and token %q", advance, token)
+	}
+}
@@ -1,8 +1,14 @@
 package components
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
 func errWrap(err error, msg string) error {
@@ -12,3 +18,98 @@ func errWrap(err error, msg string) error {
 func errWrapf(err error, msg string, v ...interface{}) error {
 	return errors.New(fmt.Sprintf(msg, v...) + "\nOriginal error: " + err.Error())
 }
+
+// writeFileAtomically writes dat to a temporary file next to path, which is
+// renamed to path when done, so that a partially written file never appears
+// at path
+func writeFileAtomically(path string, dat []byte) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return errWrap(err, "Could not create directory: "+dir)
+		}
+	}
+	tmpPath := path + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, dat, 0644); err != nil {
+		return errWrap(err, "Could not write file: "+tmpPath)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// writeStreamAtomically writes to a temporary file next to path with write,
+// through a buffered writer, and renames it to path when done, so that a
+// partially written file never appears at path. The temporary file is
+// removed if write returns an error.
+func writeStreamAtomically(path string, write func(w io.Writer) error) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			return errWrap(err, "Could not create directory: "+dir)
+		}
+	}
+	tmpPath := path + ".tmp"
+	tmpFile, err := os.Create(tmpPath)
+	if err != nil {
+		return errWrap(err, "Could not create file: "+tmpPath)
+	}
+	bw := bufio.NewWriter(tmpFile)
+	err = write(bw)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if closeErr := tmpFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// maxLineLength is the max length of the lines of files read line by line,
+// which is far above the default max of bufio.Scanner (64 KiB), since lines
+// of for example genomics TSV files can be very long
+const maxLineLength = 1024 * 1024 * 1024
+
+// pathWithInfix returns path with infix inserted, after a dot, before the file
+// extension, as in: sample.[infix].txt
+func pathWithInfix(path string, infix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + infix + ext
+}
+
+// readDelimitedFile reads the lines of the file at path, split into fields on
+// delimiter
+func readDelimitedFile(path string, delimiter rune) ([][]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	rows := [][]string{}
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), maxLineLength)
+	for sc.Scan() {
+		rows = append(rows, strings.Split(sc.Text(), string(delimiter)))
+	}
+	return rows, sc.Err()
+}
+
+// formatDelimited joins the fields of rows with delimiter, one row per line
+func formatDelimited(rows [][]string, delimiter rune) []byte {
+	lines := []string{}
+	for _, row := range rows {
+		lines = append(lines, strings.Join(row, string(delimiter))+"\n")
+	}
+	return []byte(strings.Join(lines, ""))
+}
+
+// containsString returns true if strs contains str
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
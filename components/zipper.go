@@ -0,0 +1,65 @@
+package components
+
+import (
+	"github.com/scipipe/scipipe"
+)
+
+// Zipper is a process that pairs up the IPs of two streams by the order they
+// arrive in, such as for pairing forward and reverse read files of
+// paired-end sequencing data. The n:th IP received on In1() is sent on
+// Out1() at the same time as the n:th IP received on In2() is sent on Out2(),
+// so that connecting the out-ports to two in-ports of a downstream process
+// gives one task per pair. The workflow fails if one of the streams ends
+// before the other.
+//
+// Contrary to StreamToSubStream, which gathers all IPs of a single stream
+// into one sub-stream IP, Zipper keeps the IPs of the streams separate, but
+// synchronizes them.
+type Zipper struct {
+	scipipe.BaseProcess
+}
+
+// NewZipper returns a new initialized Zipper process
+func NewZipper(wf *scipipe.Workflow, name string) *Zipper {
+	p := &Zipper{
+		BaseProcess: scipipe.NewBaseProcess(wf, name),
+	}
+	p.InitInPort(p, "in1")
+	p.InitInPort(p, "in2")
+	p.InitOutPort(p, "out1")
+	p.InitOutPort(p, "out2")
+	wf.AddProc(p)
+	return p
+}
+
+// In1 returns the in-port for the IPs coming first in each pair
+func (p *Zipper) In1() *scipipe.InPort { return p.InPort("in1") }
+
+// In2 returns the in-port for the IPs coming second in each pair
+func (p *Zipper) In2() *scipipe.InPort { return p.InPort("in2") }
+
+// Out1 returns the out-port on which the first IP of each pair is sent
+func (p *Zipper) Out1() *scipipe.OutPort { return p.OutPort("out1") }
+
+// Out2 returns the out-port on which the second IP of each pair is sent
+func (p *Zipper) Out2() *scipipe.OutPort { return p.OutPort("out2") }
+
+// Run runs the Zipper process
+func (p *Zipper) Run() {
+	defer p.CloseAllOutPorts()
+	pairNo := 0
+	for ip1 := range p.In1().Chan {
+		pairNo++
+		ip2, ok := <-p.In2().Chan
+		if !ok {
+			scipipe.Failf("Zipper %s: Got IP number %d (%s) on in-port in1, but in-port in2 ended after %d IPs\n", p.Name(), pairNo, ip1.Path(), pairNo-1)
+		}
+		// The out-ports are buffered, and downstream processes receive one IP
+		// from each of them per task, so the sends can not block each other
+		p.Out1().Send(ip1)
+		p.Out2().Send(ip2)
+	}
+	if ip2, ok := <-p.In2().Chan; ok {
+		scipipe.Failf("Zipper %s: Got IP number %d (%s) on in-port in2, but in-port in1 ended after %d IPs\n", p.Name(), pairNo+1, ip2.Path(), pairNo)
+	}
+}
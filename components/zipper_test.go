@@ -0,0 +1,54 @@
+package components
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/scipipe/scipipe"
+)
+
+func TestZipper(t *testing.T) {
+	wf := scipipe.NewWorkflow("wf", 4)
+	fwd := NewFileSource(wf, "fwd", "/tmp/zipper_test_a_R1.fq", "/tmp/zipper_test_b_R1.fq", "/tmp/zipper_test_c_R1.fq")
+	rev := NewFileSource(wf, "rev", "/tmp/zipper_test_a_R2.fq", "/tmp/zipper_test_b_R2.fq", "/tmp/zipper_test_c_R2.fq")
+	zip := NewZipper(wf, "zipper")
+	zip.In1().From(fwd.Out())
+	zip.In2().From(rev.Out())
+	col := newPortCollector(wf, "collector", "first", "second")
+	col.InPort("first").From(zip.Out1())
+	col.InPort("second").From(zip.Out2())
+	wf.Run()
+
+	expectedFirst := []string{"/tmp/zipper_test_a_R1.fq", "/tmp/zipper_test_b_R1.fq", "/tmp/zipper_test_c_R1.fq"}
+	if got := col.paths("first"); !reflect.DeepEqual(got, expectedFirst) {
+		t.Errorf("Expected first IPs of pairs %v, got: %v", expectedFirst, got)
+	}
+	expectedSecond := []string{"/tmp/zipper_test_a_R2.fq", "/tmp/zipper_test_b_R2.fq", "/tmp/zipper_test_c_R2.fq"}
+	if got := col.paths("second"); !reflect.DeepEqual(got, expectedSecond) {
+		t.Errorf("Expected second IPs of pairs %v, got: %v", expectedSecond, got)
+	}
+}
+
+func TestZipperUnequalLengths(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		wf := scipipe.NewWorkflow("wf", 4)
+		fwd := NewFileSource(wf, "fwd", "/tmp/zipper_test_a_R1.fq", "/tmp/zipper_test_b_R1.fq")
+		rev := NewFileSource(wf, "rev", "/tmp/zipper_test_a_R2.fq")
+		zip := NewZipper(wf, "zipper")
+		zip.In1().From(fwd.Out())
+		zip.In2().From(rev.Out())
+		col := newPortCollector(wf, "collector", "first", "second")
+		col.InPort("first").From(zip.Out1())
+		col.InPort("second").From(zip.Out2())
+		wf.Run()
+		return
+	}
+
+	out := runFailingSubprocess(t)
+	if !strings.Contains(out, "in-port in2 ended after 1 IPs") {
+		t.Errorf("Expected error about in-port in2 ending early, got:\n%s", out)
+	}
+}
@@ -0,0 +1,57 @@
+package scipipe
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+)
+
+// DefaultCompressionLevel is the compression level used by processes unless
+// Process.CompressionLevel is set to something else. It is a commonly used
+// trade-off between speed and compression ratio.
+const DefaultCompressionLevel = 6
+
+// GzipFile compresses the file at srcPath with gzip, using the compression
+// level level (0-9), writing the result to dstPath
+func GzipFile(srcPath string, dstPath string, level int) error {
+	srcFh, err := os.Open(srcPath)
+	if err != nil {
+		return errWrap(err, "Could not open file for compression: "+srcPath)
+	}
+	defer srcFh.Close()
+
+	dstFh, err := os.Create(dstPath)
+	if err != nil {
+		return errWrap(err, "Could not create file: "+dstPath)
+	}
+	defer dstFh.Close()
+
+	gzw, err := gzip.NewWriterLevel(dstFh, level)
+	if err != nil {
+		return errWrapf(err, "Could not create gzip writer with compression level %d", level)
+	}
+	if _, err := io.Copy(gzw, srcFh); err != nil {
+		return errWrap(err, "Could not compress file: "+srcPath)
+	}
+	if err := gzw.Close(); err != nil {
+		return errWrap(err, "Could not finish compressing file: "+srcPath)
+	}
+	return dstFh.Close()
+}
+
+// Gzip compresses the file at srcPath with gzip, writing the result to
+// dstPath, using the compression level of the task's process (see
+// Process.CompressionLevel). It is meant to be used in CustomExecute
+// functions.
+func (t *Task) Gzip(srcPath string, dstPath string) error {
+	return GzipFile(srcPath, dstPath, t.compressionLevel())
+}
+
+// compressionLevel returns the compression level of the task's process, or
+// DefaultCompressionLevel if the task has no process
+func (t *Task) compressionLevel() int {
+	if t.Process == nil {
+		return DefaultCompressionLevel
+	}
+	return t.Process.CompressionLevel
+}
@@ -0,0 +1,49 @@
+package scipipe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestCompressionLevel(t *testing.T) {
+	initTestLogs()
+
+	// Create a moderately compressible input file
+	inPath := "/tmp/compression_level_test.txt"
+	rnd := rand.New(rand.NewSource(1))
+	dat := []byte{}
+	for i := 0; i < 10000; i++ {
+		dat = append(dat, []byte(fmt.Sprintf("line %d: value %d\n", i, rnd.Intn(1000)))...)
+	}
+	err := ioutil.WriteFile(inPath, dat, 0644)
+	Check(err)
+	defer os.Remove(inPath)
+
+	wf := NewWorkflow("test_wf", 4)
+	sizes := map[int]int64{}
+	for _, level := range []int{1, 9} {
+		proc := wf.NewProc(fmt.Sprintf("gzip_level_%d", level), "# {o:out}")
+		if proc.CompressionLevel != DefaultCompressionLevel {
+			t.Errorf("Expected default compression level to be %d, got %d", DefaultCompressionLevel, proc.CompressionLevel)
+		}
+		proc.CompressionLevel = level
+		tsk := NewTask(wf, proc, proc.Name(), proc.CommandPattern, map[string]*FileIP{}, proc.PathFuncs, proc.PortInfo, map[string]string{}, map[string]string{}, "", nil, 1)
+
+		outPath := fmt.Sprintf("/tmp/compression_level_test.%d.txt.gz", level)
+		err := tsk.Gzip(inPath, outPath)
+		if err != nil {
+			t.Fatalf("Could not compress file with level %d: %v", level, err)
+		}
+		fi, err := os.Stat(outPath)
+		Check(err)
+		sizes[level] = fi.Size()
+		os.Remove(outPath)
+	}
+
+	if sizes[9] >= sizes[1] {
+		t.Errorf("Expected compression level 9 to produce a smaller output than level 1, but sizes were %d (level 9) and %d (level 1)", sizes[9], sizes[1])
+	}
+}
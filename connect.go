@@ -0,0 +1,141 @@
+package scipipe
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Connecting ports by expression
+// ----------------------------------------------------------------------------
+
+// Connect connects ports of processes in the workflow, as specified by spec,
+// which is a comma-separated list of edges on the form:
+//
+//	procA.y -> procB.x
+//
+// connecting the out-port y of process procA to the in-port x of process
+// procB, the same as procB.In("x").From(procA.Out("y")). Parameter ports are
+// connected the same way, if the named out-port is a parameter out-port. An
+// edge can be a chain of more than one arrow, where the processes in the
+// middle are given with both the in-port they receive on and the out-port
+// they send on, as in:
+//
+//	procA.y -> procB.x.z -> procC.w
+//
+// The workflow fails, listing the available ports, if a named process or port
+// does not exist, which is why Connect should be called after all ports are
+// created, such as by the command patterns of the processes.
+func (wf *Workflow) Connect(spec string) {
+	if err := wf.connect(spec); err != nil {
+		Failf("%s workflow: Could not connect '%s': %s\n", wf.name, spec, err.Error())
+	}
+}
+
+// connectEdge is a connection between the out-port fromPort of the process
+// fromProc, and the in-port toPort of the process toProc
+type connectEdge struct {
+	fromProc string
+	fromPort string
+	toProc   string
+	toPort   string
+}
+
+// connect connects the ports specified by spec (see Connect), or returns an
+// error, without connecting any ports, if any of them does not exist
+func (wf *Workflow) connect(spec string) error {
+	edges, err := parseConnectSpec(spec)
+	if err != nil {
+		return err
+	}
+	for _, e := range edges {
+		if err := wf.checkConnectEdge(e); err != nil {
+			return err
+		}
+	}
+	for _, e := range edges {
+		fromProc := wf.procs[e.fromProc]
+		toProc := wf.procs[e.toProc]
+		if opt, ok := fromProc.OutPorts()[e.fromPort]; ok {
+			opt.To(toProc.InPorts()[e.toPort])
+		} else {
+			fromProc.OutParamPorts()[e.fromPort].To(toProc.InParamPorts()[e.toPort])
+		}
+	}
+	return nil
+}
+
+// checkConnectEdge returns an error if the processes or ports of e do not
+// exist in the workflow, or if a file port would be connected to a parameter
+// port
+func (wf *Workflow) checkConnectEdge(e connectEdge) error {
+	fromProc, ok := wf.procs[e.fromProc]
+	if !ok {
+		return fmt.Errorf("No process named '%s' in the workflow", e.fromProc)
+	}
+	toProc, ok := wf.procs[e.toProc]
+	if !ok {
+		return fmt.Errorf("No process named '%s' in the workflow", e.toProc)
+	}
+	if _, ok := fromProc.OutPorts()[e.fromPort]; ok {
+		if _, ok := toProc.InPorts()[e.toPort]; !ok {
+			return fmt.Errorf("No in-port named '%s' in process '%s' (in-ports: %v)", e.toPort, e.toProc, sortedInPortMapKeys(toProc.InPorts()))
+		}
+		return nil
+	}
+	if _, ok := fromProc.OutParamPorts()[e.fromPort]; ok {
+		if _, ok := toProc.InParamPorts()[e.toPort]; !ok {
+			return fmt.Errorf("No param in-port named '%s' in process '%s' (param in-ports: %v)", e.toPort, e.toProc, sortedInParamPortMapKeys(toProc.InParamPorts()))
+		}
+		return nil
+	}
+	return fmt.Errorf("No out-port or param out-port named '%s' in process '%s' (out-ports: %v, param out-ports: %v)", e.fromPort, e.fromProc, sortedOutPortMapKeys(fromProc.OutPorts()), sortedOutParamPortMapKeys(fromProc.OutParamPorts()))
+}
+
+// parseConnectSpec parses the edges of spec (see Connect)
+func parseConnectSpec(spec string) ([]connectEdge, error) {
+	edges := []connectEdge{}
+	for _, chain := range strings.Split(spec, ",") {
+		elems := strings.Split(chain, "->")
+		if len(elems) < 2 {
+			return nil, fmt.Errorf("Edge '%s' has no arrow (->)", strings.TrimSpace(chain))
+		}
+		var fromProc, fromPort string
+		for i, elem := range elems {
+			elem = strings.TrimSpace(elem)
+			parts := strings.Split(elem, ".")
+			isMiddle := i > 0 && i < len(elems)-1
+			if (isMiddle && len(parts) < 3) || len(parts) < 2 {
+				expected := "process.port"
+				if isMiddle {
+					expected = "process.in_port.out_port"
+				}
+				return nil, fmt.Errorf("Invalid element '%s' in edge '%s', expected: %s", elem, strings.TrimSpace(chain), expected)
+			}
+			for _, part := range parts {
+				if part == "" {
+					return nil, fmt.Errorf("Empty process or port name in '%s'", elem)
+				}
+			}
+			if i == 0 {
+				// Process names may contain dots, but port names can not
+				fromProc = strings.Join(parts[:len(parts)-1], ".")
+				fromPort = parts[len(parts)-1]
+				continue
+			}
+			toPort := parts[len(parts)-1]
+			procParts := parts[:len(parts)-1]
+			if isMiddle {
+				toPort = parts[len(parts)-2]
+				procParts = parts[:len(parts)-2]
+			}
+			toProc := strings.Join(procParts, ".")
+			edges = append(edges, connectEdge{fromProc, fromPort, toProc, toPort})
+			if isMiddle {
+				fromProc = toProc
+				fromPort = parts[len(parts)-1]
+			}
+		}
+	}
+	return edges, nil
+}
@@ -0,0 +1,72 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseConnectSpec(t *testing.T) {
+	edges, err := parseConnectSpec("a.y -> b.x.z -> c.w, d.v->e.u")
+	Check(err)
+	expected := []connectEdge{
+		{"a", "y", "b", "x"},
+		{"b", "z", "c", "w"},
+		{"d", "v", "e", "u"},
+	}
+	if !reflect.DeepEqual(edges, expected) {
+		t.Errorf("Expected edges %v, got %v", expected, edges)
+	}
+
+	for spec, errSubstr := range map[string]string{
+		"a.y":               "has no arrow",
+		"a -> b.x":          "Invalid element 'a'",
+		"a.y -> b.x -> c.w": "expected: process.in_port.out_port",
+		"a. -> b.x":         "Empty process or port name",
+	} {
+		if _, err := parseConnectSpec(spec); err == nil || !strings.Contains(err.Error(), errSubstr) {
+			t.Errorf("Expected error containing %q for spec '%s', got: %v", errSubstr, spec, err)
+		}
+	}
+}
+
+func TestConnect(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	foo := wf.NewProc("foo", "echo foo > {o:out}")
+	foo.SetOut("out", "/tmp/connect_foo.txt")
+	f2b := wf.NewProc("f2b", "sed 's/foo/bar/' {i:in} > {o:out}")
+	f2b.SetOut("out", "{i:in|%.txt}.bar.txt")
+	cpy := wf.NewProc("cpy", "cat {i:in} > {o:out}")
+	cpy.SetOut("out", "{i:in}.copy")
+	wf.Connect("foo.out -> f2b.in.out -> cpy.in")
+	wf.Run()
+	defer cleanFiles("/tmp/connect_foo.txt", "/tmp/connect_foo.bar.txt", "/tmp/connect_foo.bar.txt.copy")
+
+	out, err := ioutil.ReadFile("/tmp/connect_foo.bar.txt.copy")
+	Check(err)
+	if string(out) != "bar\n" {
+		t.Errorf("Expected output 'bar', got %q", out)
+	}
+}
+
+func TestConnectMissingPorts(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	wf.NewProc("foo", "echo foo > {o:out}")
+	wf.NewProc("bar", "cat {i:in} > {o:out}")
+
+	for spec, errSubstr := range map[string]string{
+		"baz.out -> bar.in":   "No process named 'baz'",
+		"foo.typo -> bar.in":  "No out-port or param out-port named 'typo' in process 'foo' (out-ports: [out]",
+		"foo.out -> bar.typo": "No in-port named 'typo' in process 'bar' (in-ports: [in])",
+	} {
+		if err := wf.connect(spec); err == nil || !strings.Contains(err.Error(), errSubstr) {
+			t.Errorf("Expected error containing %q for spec '%s', got: %v", errSubstr, spec, err)
+		}
+	}
+	if len(wf.Proc("bar").InPorts()["in"].RemotePorts) != 0 {
+		t.Errorf("Expected no ports to be connected when connecting fails")
+	}
+}
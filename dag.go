@@ -0,0 +1,256 @@
+package scipipe
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// DAG helpers
+// ----------------------------------------------------------------------------
+
+// upstreamProcNames returns the (sorted) names of the processes connected to
+// any of the in-ports or param in-ports of proc
+func upstreamProcNames(proc WorkflowProcess) []string {
+	names := map[string]bool{}
+	for _, ipt := range proc.InPorts() {
+		for _, rpt := range ipt.RemotePorts {
+			if rpt.Process() != nil {
+				names[rpt.Process().Name()] = true
+			}
+		}
+	}
+	for _, pip := range proc.InParamPorts() {
+		for _, rpt := range pip.RemotePorts {
+			if rpt.Process() != nil {
+				names[rpt.Process().Name()] = true
+			}
+		}
+	}
+	sortedNames := []string{}
+	for name := range names {
+		sortedNames = append(sortedNames, name)
+	}
+	sort.Strings(sortedNames)
+	return sortedNames
+}
+
+// dagLevels groups the processes in procs into levels, where the level of a
+// process is the length of the longest path to it from any process without
+// upstream processes. Processes on the same level do not depend on each
+// other, and can thus run at the same time. Process names are sorted within
+// each level. Connections creating cycles are ignored.
+func dagLevels(procs map[string]WorkflowProcess) [][]string {
+	levelOf := map[string]int{}
+	visiting := map[string]bool{}
+	var level func(name string) int
+	level = func(name string) int {
+		if l, ok := levelOf[name]; ok {
+			return l
+		}
+		visiting[name] = true
+		l := 0
+		for _, upName := range upstreamProcNames(procs[name]) {
+			if _, ok := procs[upName]; !ok || visiting[upName] {
+				continue
+			}
+			if upLevel := level(upName) + 1; upLevel > l {
+				l = upLevel
+			}
+		}
+		visiting[name] = false
+		levelOf[name] = l
+		return l
+	}
+
+	levels := [][]string{}
+	for _, name := range sortedWFProcMapKeys(procs) {
+		l := level(name)
+		for len(levels) <= l {
+			levels = append(levels, []string{})
+		}
+		levels[l] = append(levels[l], name)
+	}
+	for _, names := range levels {
+		sort.Strings(names)
+	}
+	return levels
+}
+
+// ----------------------------------------------------------------------------
+// Printing the DAG
+// ----------------------------------------------------------------------------
+
+// PrintDAG writes a plain text rendering of the DAG of the workflow to w, for
+// quick inspection in a terminal without Graphviz. Processes are listed in
+// topological order, grouped by their level in the DAG (see dagLevels), each
+// followed by the connections from its out-ports, like so:
+//
+//	level 0:
+//	  foo
+//	    out -> bar.in
+//	level 1:
+//	  bar
+//
+// Parameter connections are marked with "(param)".
+func (wf *Workflow) PrintDAG(w io.Writer) {
+	fmt.Fprintf(w, "workflow %s\n", wf.Name())
+	for level, names := range dagLevels(wf.procs) {
+		fmt.Fprintf(w, "level %d:\n", level)
+		for _, name := range names {
+			proc := wf.procs[name]
+			fmt.Fprintf(w, "  %s\n", name)
+			for _, opName := range sortedOutPortMapKeys(proc.OutPorts()) {
+				opt := proc.OutPorts()[opName]
+				for _, rptName := range sortedInPortMapKeys(opt.RemotePorts) {
+					fmt.Fprintf(w, "    %s -> %s\n", opName, rptName)
+				}
+			}
+			for _, popName := range sortedOutParamPortMapKeys(proc.OutParamPorts()) {
+				pop := proc.OutParamPorts()[popName]
+				for _, rptName := range sortedInParamPortMapKeys(pop.RemotePorts) {
+					fmt.Fprintf(w, "    %s -> %s (param)\n", popName, rptName)
+				}
+			}
+		}
+	}
+}
+
+// ----------------------------------------------------------------------------
+// Resource plan
+// ----------------------------------------------------------------------------
+
+// Plan contains an estimate of the resources needed for running a workflow
+type Plan struct {
+	// Depth is the number of levels in the DAG of the workflow
+	Depth int
+	// PeakCores is the estimated max number of cores in use at the same time
+	PeakCores int
+	// PeakMemoryMB is the estimated max amount of memory, in megabytes, in use
+	// at the same time
+	PeakMemoryMB int
+}
+
+// ResourcePlan returns an estimate of the peak concurrent resource usage of
+// the workflow, based on the CoresPerTask and MemoryPerTask (or MaxMemoryMB)
+// fields of its processes, and the structure of its DAG. Processes on the
+// same level of the DAG are assumed to run one task each, at the same time,
+// while the number of cores is capped at the max number of concurrent tasks
+// of the workflow.
+func (wf *Workflow) ResourcePlan() Plan {
+	levels := dagLevels(wf.procs)
+	plan := Plan{Depth: len(levels)}
+	for _, names := range levels {
+		cores := 0
+		memoryMB := 0
+		for _, name := range names {
+			if p, ok := wf.procs[name].(*Process); ok {
+				cores += p.CoresPerTask
+				memoryMB += p.plannedMemoryMB()
+			}
+		}
+		if cores > cap(wf.concurrentTasks) {
+			cores = cap(wf.concurrentTasks)
+		}
+		if cores > plan.PeakCores {
+			plan.PeakCores = cores
+		}
+		if memoryMB > plan.PeakMemoryMB {
+			plan.PeakMemoryMB = memoryMB
+		}
+	}
+	return plan
+}
+
+// ----------------------------------------------------------------------------
+// Critical path
+// ----------------------------------------------------------------------------
+
+// CriticalPath returns the processes on the longest path through the DAG of
+// the workflow, weighted by the expected task duration of each process, in
+// upstream to downstream order. The path decides the minimum time needed for
+// running the workflow, and is thus where to look for bottlenecks. The
+// expected task duration of a process is its EstimatedDuration if set, or
+// otherwise the average duration of its tasks executed so far (see
+// Workflow.TaskStats()), such as in a prior run. Ties are broken by process
+// name, to give a stable result.
+func (wf *Workflow) CriticalPath() []*Process {
+	procs := map[string]WorkflowProcess{}
+	for name, proc := range wf.procs {
+		procs[name] = proc
+	}
+	// The driver process is removed from the procs map when the workflow runs
+	if wf.driver != nil && wf.driver != WorkflowProcess(wf.sink) {
+		procs[wf.driver.Name()] = wf.driver
+	}
+	durations := wf.avgTaskDurations()
+	weight := func(name string) time.Duration {
+		p, ok := procs[name].(*Process)
+		if !ok {
+			return 0
+		}
+		if p.EstimatedDuration > 0 {
+			return p.EstimatedDuration
+		}
+		return durations[name]
+	}
+
+	// For each process, find the length of the longest path ending in it, and
+	// the process before it on that path
+	pathLen := map[string]time.Duration{}
+	prev := map[string]string{}
+	visiting := map[string]bool{}
+	var longest func(name string) time.Duration
+	longest = func(name string) time.Duration {
+		if l, ok := pathLen[name]; ok {
+			return l
+		}
+		visiting[name] = true
+		var upLen time.Duration
+		for _, upName := range upstreamProcNames(procs[name]) {
+			if _, ok := procs[upName]; !ok || visiting[upName] {
+				continue
+			}
+			if l := longest(upName); l > upLen || prev[name] == "" {
+				upLen = l
+				prev[name] = upName
+			}
+		}
+		visiting[name] = false
+		pathLen[name] = upLen + weight(name)
+		return pathLen[name]
+	}
+
+	last := ""
+	for _, name := range sortedWFProcMapKeys(procs) {
+		if l := longest(name); last == "" || l > pathLen[last] {
+			last = name
+		}
+	}
+
+	path := []*Process{}
+	for name := last; name != ""; name = prev[name] {
+		if p, ok := procs[name].(*Process); ok {
+			path = append([]*Process{p}, path...)
+		}
+	}
+	return path
+}
+
+// avgTaskDurations returns the average duration of the tasks executed so far,
+// per process name
+func (wf *Workflow) avgTaskDurations() map[string]time.Duration {
+	totals := map[string]time.Duration{}
+	counts := map[string]int{}
+	for _, ts := range wf.TaskStats() {
+		totals[ts.Process] += ts.Duration()
+		counts[ts.Process]++
+	}
+	avgs := map[string]time.Duration{}
+	for name, total := range totals {
+		avgs[name] = total / time.Duration(counts[name])
+	}
+	return avgs
+}
@@ -0,0 +1,147 @@
+package scipipe
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDagLevels(t *testing.T) {
+	wf := NewWorkflow("test_wf", 8)
+	foo := wf.NewProc("foo", "echo foo > {o:out}")
+	bar := wf.NewProc("bar", "echo bar > {o:out}")
+	cat := wf.NewProc("cat", "cat {i:foo} {i:bar} > {o:out}")
+	cat.In("foo").From(foo.Out("out"))
+	cat.In("bar").From(bar.Out("out"))
+	wc := wf.NewProc("wc", "wc -l {i:in} > {o:out}")
+	wc.In("in").From(cat.Out("out"))
+	// A shortcut from foo to the last level should not change levels
+	last := wf.NewProc("last", "cat {i:wc} {i:foo} > {o:out}")
+	last.In("wc").From(wc.Out("out"))
+	last.In("foo").From(foo.Out("out"))
+
+	levels := dagLevels(wf.Procs())
+	expected := [][]string{{"bar", "foo"}, {"cat"}, {"wc"}, {"last"}}
+	if !reflect.DeepEqual(levels, expected) {
+		t.Errorf("Levels were %v, expected %v", levels, expected)
+	}
+}
+
+func TestResourcePlan(t *testing.T) {
+	wf := NewWorkflow("test_wf", 8)
+	// Three independent processes on the first level use 2+3+4 = 9 cores,
+	// which is capped to the 8 of the workflow, and 1000+2000+3000 MB memory
+	align1 := wf.NewProc("align1", "echo a1 > {o:out}")
+	align1.CoresPerTask = 2
+	align1.MaxMemoryMB = 1000
+	align2 := wf.NewProc("align2", "echo a2 > {o:out}")
+	align2.CoresPerTask = 3
+	align2.MaxMemoryMB = 2000
+	align3 := wf.NewProc("align3", "echo a3 > {o:out}")
+	align3.CoresPerTask = 4
+	align3.MaxMemoryMB = 3000
+	merge := wf.NewProc("merge", "cat {i:a1} {i:a2} {i:a3} > {o:out}")
+	merge.In("a1").From(align1.Out("out"))
+	merge.In("a2").From(align2.Out("out"))
+	merge.In("a3").From(align3.Out("out"))
+	merge.CoresPerTask = 1
+	merge.MaxMemoryMB = 8000
+
+	plan := wf.ResourcePlan()
+	expected := Plan{Depth: 2, PeakCores: 8, PeakMemoryMB: 8000}
+	if plan != expected {
+		t.Errorf("Resource plan was %+v, expected %+v", plan, expected)
+	}
+}
+
+func TestResourcePlanMemoryPerTask(t *testing.T) {
+	wf := NewWorkflow("test_wf", 8)
+	// MemoryPerTask is used instead of MaxMemoryMB when set, rounded up to
+	// whole megabytes: 4096 + 1000 + 2 MB
+	align1 := wf.NewProc("align1", "echo a1 > {o:out}")
+	align1.MemoryPerTask = "4G"
+	align1.MaxMemoryMB = 3000
+	align2 := wf.NewProc("align2", "echo a2 > {o:out}")
+	align2.MaxMemoryMB = 1000
+	align3 := wf.NewProc("align3", "echo a3 > {o:out}")
+	align3.MemoryPerTask = "1025K"
+
+	plan := wf.ResourcePlan()
+	if plan.PeakMemoryMB != 5098 {
+		t.Errorf("Expected peak memory of 5098 MB, got %d", plan.PeakMemoryMB)
+	}
+}
+
+func TestPrintDAG(t *testing.T) {
+	wf := NewWorkflow("test_wf", 4)
+	// Add processes in reverse order, to make sure the order of the output
+	// depends on the DAG, not the order processes were added
+	wc := wf.NewProc("a_wc", "wc -l {i:in} > {o:out}")
+	cat := wf.NewProc("b_cat", "cat {i:in} > {o:out}")
+	src := NewParamSource(wf, "c_src", "foo")
+	echo := wf.NewProc("d_echo", "echo {p:word} > {o:out}")
+	echo.InParam("word").From(src.Out())
+	cat.In("in").From(echo.Out("out"))
+	wc.In("in").From(cat.Out("out"))
+
+	buf := &bytes.Buffer{}
+	wf.PrintDAG(buf)
+	expected := `workflow test_wf
+level 0:
+  c_src
+    out -> d_echo.word (param)
+level 1:
+  d_echo
+    out -> b_cat.in
+level 2:
+  b_cat
+    out -> a_wc.in
+level 3:
+  a_wc
+`
+	if buf.String() != expected {
+		t.Errorf("PrintDAG output was:\n%s\nExpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestCriticalPath(t *testing.T) {
+	wf := NewWorkflow("test_wf", 4)
+	// fetch --> index (30m) --> align (60m) --> report (5m)
+	//       \-> qc (10m) ---------------------/
+	fetch := wf.NewProc("fetch", "echo fetch > {o:out}")
+	fetch.EstimatedDuration = 5 * time.Minute
+	index := wf.NewProc("index", "cat {i:in} > {o:out}")
+	index.In("in").From(fetch.Out("out"))
+	index.EstimatedDuration = 30 * time.Minute
+	align := wf.NewProc("align", "cat {i:in} > {o:out}")
+	align.In("in").From(index.Out("out"))
+	align.EstimatedDuration = 60 * time.Minute
+	qc := wf.NewProc("qc", "cat {i:in} > {o:out}")
+	qc.In("in").From(fetch.Out("out"))
+	qc.EstimatedDuration = 10 * time.Minute
+	report := wf.NewProc("report", "cat {i:aln} {i:qc} > {o:out}")
+	report.In("aln").From(align.Out("out"))
+	report.In("qc").From(qc.Out("out"))
+	report.EstimatedDuration = 5 * time.Minute
+
+	expected := []string{"fetch", "index", "align", "report"}
+	actual := []string{}
+	for _, p := range wf.CriticalPath() {
+		actual = append(actual, p.Name())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Critical path was %v, expected %v", actual, expected)
+	}
+
+	// Making qc the slowest should move the critical path through it
+	qc.EstimatedDuration = 120 * time.Minute
+	expected = []string{"fetch", "qc", "report"}
+	actual = []string{}
+	for _, p := range wf.CriticalPath() {
+		actual = append(actual, p.Name())
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Critical path was %v, expected %v", actual, expected)
+	}
+}
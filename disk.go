@@ -0,0 +1,95 @@
+package scipipe
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Disk hard limits
+// ----------------------------------------------------------------------------
+
+// DiskCheckInterval is how often free disk space is checked against the limits
+// set with Workflow.SetDiskHardLimit
+var DiskCheckInterval = 10 * time.Second
+
+// freeDiskBytes returns the number of free bytes on the file system containing
+// path. It is a variable so that it can be replaced in tests.
+var freeDiskBytes = statfsFreeBytes
+
+// diskHardLimit is a min amount of free space on the file system containing
+// path
+type diskHardLimit struct {
+	path     string
+	minBytes int64
+}
+
+// SetDiskHardLimit makes the workflow fail, with an error saying how much space
+// is left, if the free space on the file system containing path drops below
+// minBytes while the workflow runs. This aborts the run before tasks fill up
+// the disk, and leave half-written outputs behind. Free space is checked when
+// the workflow starts, and then every DiskCheckInterval. Multiple limits can
+// be set, for different paths.
+func (wf *Workflow) SetDiskHardLimit(path string, minBytes int64) {
+	if minBytes < 1 {
+		Failf(wf.name+" workflow: Min free disk space must be at least 1 byte, got %d\n", minBytes)
+	}
+	wf.diskHardLimits = append(wf.diskHardLimits, diskHardLimit{path: path, minBytes: minBytes})
+}
+
+// startDiskHardLimitChecks checks the disk hard limits of the workflow, if any,
+// every DiskCheckInterval in a separate go-routine, and returns a function
+// that stops the checks
+func (wf *Workflow) startDiskHardLimitChecks() (stop func()) {
+	if len(wf.diskHardLimits) == 0 {
+		return func() {}
+	}
+	wf.failOnDiskHardLimitBreach()
+
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		ticker := time.NewTicker(DiskCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				wf.failOnDiskHardLimitBreach()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+// failOnDiskHardLimitBreach makes the workflow fail, if any of its disk hard
+// limits is breached
+func (wf *Workflow) failOnDiskHardLimitBreach() {
+	if err := wf.checkDiskHardLimits(); err != nil {
+		Failf("| workflow:%-23s | Aborting workflow: %s\n", wf.Name(), err.Error())
+	}
+}
+
+// checkDiskHardLimits returns an error describing the first disk hard limit of
+// the workflow that is breached, if any. Limits for which free space can not
+// be checked are logged as warnings, rather than failing the workflow.
+func (wf *Workflow) checkDiskHardLimits() error {
+	for _, limit := range wf.diskHardLimits {
+		free, err := freeDiskBytes(limit.path)
+		if err != nil {
+			wf.WarningLogger().Printf("| workflow:%-23s | Could not check free disk space for %s: %s\n", wf.Name(), limit.path, err.Error())
+			continue
+		}
+		if free < limit.minBytes {
+			return fmt.Errorf("Free disk space for %s is %d bytes, which is below the hard limit of %d bytes", limit.path, free, limit.minBytes)
+		}
+	}
+	return nil
+}
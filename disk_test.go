@@ -0,0 +1,60 @@
+package scipipe
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSetDiskHardLimit(t *testing.T) {
+	initTestLogs()
+	origFreeDiskBytes := freeDiskBytes
+	defer func() { freeDiskBytes = origFreeDiskBytes }()
+
+	free := int64(2000)
+	freeDiskBytes = func(path string) (int64, error) {
+		return free, nil
+	}
+
+	wf := NewWorkflow("test_wf", 4)
+	wf.SetDiskHardLimit("/data", 1000)
+	if err := wf.checkDiskHardLimits(); err != nil {
+		t.Errorf("Expected no error with free space above the hard limit, got: %s", err.Error())
+	}
+
+	free = 999
+	err := wf.checkDiskHardLimits()
+	if err == nil {
+		t.Fatal("Expected an error with free space below the hard limit, got none")
+	}
+	for _, expected := range []string{"/data", "999 bytes", "1000 bytes"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain '%s', got: %s", expected, err.Error())
+		}
+	}
+}
+
+func TestDiskHardLimitAbortsWorkflow(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		InitLogError()
+		freeDiskBytes = func(path string) (int64, error) {
+			return 10, nil
+		}
+		wf := NewWorkflow("test_wf", 4)
+		wf.SetDiskHardLimit("/data", 1000)
+		wf.NewProc("never_run", "echo never run > {o:out}").SetOut("out", "/tmp/disk_hard_limit_never_run.txt")
+		wf.Run()
+		return
+	}
+
+	out := runFailingSubprocess(t)
+	if !strings.Contains(out, "below the hard limit") {
+		t.Errorf("Expected output to describe the breached hard limit, got: %s", out)
+	}
+	if _, err := os.Stat("/tmp/disk_hard_limit_never_run.txt"); !os.IsNotExist(err) {
+		cleanFiles("/tmp/disk_hard_limit_never_run.txt")
+		t.Error("Expected no task to run when the hard limit is already breached")
+	}
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package scipipe
+
+import "syscall"
+
+// statfsFreeBytes returns the number of bytes available to unprivileged users,
+// on the file system containing path
+func statfsFreeBytes(path string) (int64, error) {
+	stat := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
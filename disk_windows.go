@@ -0,0 +1,12 @@
+//go:build windows
+// +build windows
+
+package scipipe
+
+import "errors"
+
+// statfsFreeBytes is not supported on Windows, so disk hard limits are not
+// checked there
+func statfsFreeBytes(path string) (int64, error) {
+	return 0, errors.New("Checking free disk space is not supported on Windows")
+}
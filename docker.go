@@ -0,0 +1,182 @@
+package scipipe
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Docker execution
+// ----------------------------------------------------------------------------
+
+// runInDocker runs the shell command cmd with sh, in a container of the image
+// of the task's process, with docker run. The directories containing the
+// task's temp dir and all its inputs and outputs are bind-mounted at the same
+// absolute paths in the container as on the host, so that the paths in cmd
+// need no translation. The exit code of cmd is the one of docker run.
+func (t *Task) runInDocker(cmd string) ([]byte, error) {
+	if t.Process.Image == "" {
+		return nil, errors.New("Process " + t.Process.Name() + " has exec mode ExecModeDocker, but no Image set")
+	}
+	workDir, err := filepath.Abs(t.TempDir())
+	if err != nil {
+		return nil, errWrap(err, "Could not get absolute path of temp dir "+t.TempDir())
+	}
+	mountDirs, err := t.containerMountDirs()
+	if err != nil {
+		return nil, err
+	}
+	if t.Process.ReuseContainer {
+		containerID, err := t.Process.reusedContainer(mountDirs)
+		if err != nil {
+			return nil, err
+		}
+		return t.runCommand(exec.Command("docker", dockerExecArgs(containerID, workDir, cmd)...))
+	}
+	args := dockerRunArgs(t.Process.Image, t.Process.dockerFlags(), mountDirs, workDir, cmd)
+	return t.runCommand(exec.Command("docker", args...))
+}
+
+// dockerFlags returns the flags to docker run for the containers of the
+// process, which are the DockerFlags, and the memory limit of MemoryPerTask,
+// if set
+func (p *Process) dockerFlags() []string {
+	flags := p.DockerFlags
+	if memory := p.memoryPerTaskBytes(); memory > 0 {
+		flags = append([]string{fmt.Sprintf("--memory=%d", memory)}, flags...)
+	}
+	return flags
+}
+
+// dockerRunArgs returns the arguments to docker for running the shell command
+// cmd with sh in a container of image, with the extra docker flags flags, the
+// directories mountDirs bind-mounted at the same paths as on the host, and
+// workDir as working directory
+func dockerRunArgs(image string, flags []string, mountDirs []string, workDir string, cmd string) []string {
+	args := []string{"run", "--rm"}
+	args = append(args, flags...)
+	for _, dir := range mountDirs {
+		args = append(args, "-v", dir+":"+dir)
+	}
+	args = append(args, "-w", workDir, image, "sh", "-c", cmd)
+	return args
+}
+
+// containerMountDirs returns the absolute paths of the directories to
+// bind-mount in containers running the command of the task, which are the
+// current directory, containing the task's temp dir and any relative paths,
+// and the directories of all absolute input paths and FIFO files. Directories
+// inside other mounted directories are left out.
+func (t *Task) containerMountDirs() ([]string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errWrap(err, "Could not get current directory")
+	}
+	paths := []string{}
+	for ipName, ip := range t.InIPs {
+		if subIPs, ok := t.subStreamIPs[ipName]; ok {
+			for _, subIP := range subIPs {
+				paths = append(paths, subIP.Path())
+			}
+			continue
+		}
+		if ip.doStream {
+			paths = append(paths, ip.FifoPath())
+		} else {
+			paths = append(paths, ip.Path())
+		}
+	}
+	// Outputs are written to the temp dir, except for streaming ones
+	for _, oip := range t.OutIPs {
+		if oip.doStream {
+			paths = append(paths, oip.FifoPath())
+		}
+	}
+
+	dirs := []string{wd}
+	for _, path := range paths {
+		if filepath.IsAbs(path) {
+			dirs = append(dirs, filepath.Dir(path))
+		}
+	}
+	sort.Strings(dirs)
+	mountDirs := []string{}
+	for _, dir := range dirs {
+		if !insideAnyDir(dir, mountDirs) {
+			mountDirs = append(mountDirs, dir)
+		}
+	}
+	return mountDirs, nil
+}
+
+// insideAnyDir tells whether the directory dir is any of dirs, or inside any
+// of them
+func insideAnyDir(dir string, dirs []string) bool {
+	for _, otherDir := range dirs {
+		if dir == otherDir || strings.HasPrefix(dir, strings.TrimSuffix(otherDir, "/")+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// containerKeepAliveCmd is the command that containers reused for all tasks of
+// a process run, to keep them running until they are removed
+const containerKeepAliveCmd = "while true; do sleep 3600; done"
+
+// reusedContainer returns the id of the container that the tasks of the
+// process are run in, with ReuseContainer, which is started with the
+// directories mountDirs mounted, unless it is already running. An error is
+// returned if any of mountDirs is not mounted in an already running
+// container.
+func (p *Process) reusedContainer(mountDirs []string) (string, error) {
+	p.containerMx.Lock()
+	defer p.containerMx.Unlock()
+	if p.containerID == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return "", errWrap(err, "Could not get current directory")
+		}
+		args := dockerRunArgs(p.Image, append([]string{"-d"}, p.dockerFlags()...), mountDirs, wd, containerKeepAliveCmd)
+		out, err := exec.Command("docker", args...).Output()
+		if err != nil {
+			return "", errWrapf(err, "Could not start container of image %s for process %s", p.Image, p.Name())
+		}
+		p.containerID = strings.TrimSpace(string(out))
+		p.containerMountDirs = mountDirs
+		p.workflow.logAuditf(p.Name(), "Started container %s of image %s, to run all tasks in", p.containerID, p.Image)
+	}
+	for _, dir := range mountDirs {
+		if !insideAnyDir(dir, p.containerMountDirs) {
+			return "", errors.New("Directory " + dir + " is not mounted in the container reused for the tasks of process " + p.Name() + ", which has only " + strings.Join(p.containerMountDirs, ", ") + " mounted. Keep the inputs of all tasks in the same directories, or turn off ReuseContainer.")
+		}
+	}
+	return p.containerID, nil
+}
+
+// removeReusedContainer removes the container that the tasks of the process
+// were run in, with ReuseContainer, if it was started
+func (p *Process) removeReusedContainer() {
+	p.containerMx.Lock()
+	defer p.containerMx.Unlock()
+	if p.containerID == "" {
+		return
+	}
+	if out, err := exec.Command("docker", "rm", "-f", p.containerID).CombinedOutput(); err != nil {
+		p.workflow.WarningLogger().Printf("| %-32s | Could not remove container %s: %s (%s)\n", p.Name(), p.containerID, err.Error(), strings.TrimSpace(string(out)))
+	}
+	p.containerID = ""
+	p.containerMountDirs = nil
+}
+
+// dockerExecArgs returns the arguments to docker for running the shell command
+// cmd with sh in the running container with id containerID, with workDir as
+// working directory
+func dockerExecArgs(containerID string, workDir string, cmd string) []string {
+	return []string{"exec", "-w", workDir, containerID, "sh", "-c", cmd}
+}
@@ -0,0 +1,140 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDockerRunArgs(t *testing.T) {
+	args := dockerRunArgs("ubuntu:22.04", []string{"--user", "1000:1000"}, []string{"/data", "/ref"}, "/data/tmp", "cat /ref/a.txt > out.txt")
+	expected := []string{"run", "--rm", "--user", "1000:1000", "-v", "/data:/data", "-v", "/ref:/ref", "-w", "/data/tmp", "ubuntu:22.04", "sh", "-c", "cat /ref/a.txt > out.txt"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected docker args %v, got %v", expected, args)
+	}
+}
+
+func TestExecModeDocker(t *testing.T) {
+	initTestLogs()
+
+	// Fake docker, which records its arguments, and runs the command on the
+	// host, in the working directory given with -w
+	binDir, err := ioutil.TempDir("", "fake_docker")
+	Check(err)
+	defer os.RemoveAll(binDir)
+	argsPath := filepath.Join(binDir, "args.txt")
+	err = ioutil.WriteFile(filepath.Join(binDir, "docker"), []byte("#!/bin/bash\n"+
+		"echo \"$@\" > "+argsPath+"\n"+
+		"while [ \"$1\" != \"-w\" ]; do shift; done\n"+
+		"cd \"$2\" && shift 3 && exec \"$@\"\n"), 0755)
+	Check(err)
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+":"+origPath)
+	defer os.Setenv("PATH", origPath)
+
+	inDir, err := ioutil.TempDir("", "docker_test_in")
+	Check(err)
+	defer os.RemoveAll(inDir)
+	inPath := filepath.Join(inDir, "in.txt")
+	err = ioutil.WriteFile(inPath, []byte("foo\n"), 0644)
+	Check(err)
+
+	wf := NewWorkflow("test_wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	p := wf.NewProc("docker_job", "cat {i:in} > {o:out}")
+	p.In("in").From(src.Out())
+	p.SetOut("out", "docker_test.txt")
+	p.ExecMode = ExecModeDocker
+	p.Image = "ubuntu:22.04"
+	p.DockerFlags = []string{"--network", "none"}
+	wf.Run()
+	defer cleanFiles("docker_test.txt")
+
+	out, err := ioutil.ReadFile("docker_test.txt")
+	Check(err)
+	if string(out) != "foo\n" {
+		t.Errorf("Expected output of docker job to be 'foo', but was: '%s'", string(out))
+	}
+	args, err := ioutil.ReadFile(argsPath)
+	Check(err)
+	wd, err := os.Getwd()
+	Check(err)
+	for _, expected := range []string{"run --rm --network none ", " -v " + wd + ":" + wd + " ", " -v " + inDir + ":" + inDir + " ", " ubuntu:22.04 sh -c "} {
+		if !strings.Contains(string(args), expected) {
+			t.Errorf("Expected docker args to contain %q, but they were: %s", expected, args)
+		}
+	}
+
+	// Exit codes of failing containers should be reported
+	tsk := NewTask(wf, p, "docker_job", "exit 3", map[string]*FileIP{}, nil, nil, map[string]string{}, map[string]string{}, "", nil, 1)
+	tsk.createDirs()
+	defer os.RemoveAll(tsk.TempDir())
+	_, err = tsk.runCommandInExecMode(tsk.Command)
+	if exitErr, ok := err.(interface{ ExitCode() int }); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("Expected container exiting with exit code 3 to be reported, got: %v", err)
+	}
+}
+
+func TestDockerReuseContainer(t *testing.T) {
+	initTestLogs()
+
+	// Fake docker, which logs each invocation, starts no container for
+	// docker run, and runs the command of docker exec on the host, in the
+	// working directory given with -w
+	binDir, err := ioutil.TempDir("", "fake_docker")
+	Check(err)
+	defer os.RemoveAll(binDir)
+	logPath := filepath.Join(binDir, "calls.txt")
+	err = ioutil.WriteFile(filepath.Join(binDir, "docker"), []byte("#!/bin/bash\n"+
+		"echo \"$@\" >> "+logPath+"\n"+
+		"case \"$1\" in\n"+
+		"  run) echo fake_container_id ;;\n"+
+		"  exec) shift; cd \"$2\" && shift 3 && exec \"$@\" ;;\n"+
+		"esac\n"), 0755)
+	Check(err)
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+":"+origPath)
+	defer os.Setenv("PATH", origPath)
+
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("docker_job", "echo {p:x} > {o:out}")
+	p.InParam("x").FromStr("a", "b", "c")
+	p.SetOut("out", "docker_reuse_{p:x}.txt")
+	p.ExecMode = ExecModeDocker
+	p.Image = "ubuntu:22.04"
+	p.ReuseContainer = true
+	wf.Run()
+	defer cleanFiles("docker_reuse_a.txt", "docker_reuse_b.txt", "docker_reuse_c.txt")
+
+	for _, x := range []string{"a", "b", "c"} {
+		out, err := ioutil.ReadFile("docker_reuse_" + x + ".txt")
+		Check(err)
+		if string(out) != x+"\n" {
+			t.Errorf("Expected output of task for %s to be '%s', but was: '%s'", x, x, string(out))
+		}
+	}
+	callsTxt, err := ioutil.ReadFile(logPath)
+	Check(err)
+	calls := map[string][]string{}
+	for _, call := range strings.Split(strings.TrimSpace(string(callsTxt)), "\n") {
+		subCmd := strings.Fields(call)[0]
+		calls[subCmd] = append(calls[subCmd], call)
+	}
+	if len(calls["run"]) != 1 || !strings.HasPrefix(calls["run"][0], "run --rm -d ") {
+		t.Errorf("Expected a single container to be started with docker run -d, got: %v", calls["run"])
+	}
+	if len(calls["exec"]) != 3 {
+		t.Errorf("Expected the container to be reused for all 3 tasks, with docker exec, got: %v", calls["exec"])
+	}
+	for _, call := range calls["exec"] {
+		if !strings.Contains(call, " fake_container_id sh -c ") {
+			t.Errorf("Expected task to be run in the started container, got: %s", call)
+		}
+	}
+	if len(calls["rm"]) != 1 || calls["rm"][0] != "rm -f fake_container_id" {
+		t.Errorf("Expected the container to be removed once the process finished, got: %v", calls["rm"])
+	}
+}
@@ -0,0 +1,55 @@
+package scipipe
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ----------------------------------------------------------------------------
+// Dry runs
+// ----------------------------------------------------------------------------
+
+// SetDryRun sets whether the workflow should be run as a dry run (see
+// Workflow.DryRun)
+func (wf *Workflow) SetDryRun(dryRun bool) {
+	wf.DryRun = dryRun
+}
+
+// dryRunTask contains the info printed about a task in a dry run
+type dryRunTask struct {
+	TaskID   string
+	Process  string
+	Command  string
+	OutFiles map[string]string
+}
+
+// dryRunWriter returns the writer that dry run info is printed to
+func (wf *Workflow) dryRunWriter() io.Writer {
+	if wf.dryRunOut != nil {
+		return wf.dryRunOut
+	}
+	return os.Stdout
+}
+
+// printDryRun prints the formatted command of the task, and the output files
+// it would create, as a single line of JSON, so that the output of dry runs
+// can be parsed and compared
+func (t *Task) printDryRun() {
+	info := dryRunTask{
+		TaskID:   t.ID(),
+		Process:  t.Name,
+		Command:  t.Command,
+		OutFiles: map[string]string{},
+	}
+	for oipName, oip := range t.OutIPs {
+		info.OutFiles[oipName] = oip.Path()
+	}
+	infoJSON, err := json.Marshal(info)
+	CheckWithMsg(err, "Could not marshal dry run info of task "+t.ID())
+
+	t.workflow.dryRunOutMx.Lock()
+	defer t.workflow.dryRunOutMx.Unlock()
+	fmt.Fprintln(t.workflow.dryRunWriter(), string(infoJSON))
+}
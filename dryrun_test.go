@@ -0,0 +1,63 @@
+package scipipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDryRun(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("test_wf", 4)
+	wf.SetDryRun(true)
+	out := &bytes.Buffer{}
+	wf.dryRunOut = out
+
+	src := NewParamSource(wf, "src", "a", "b")
+	hello := wf.NewProc("hello", "echo hello {p:name} > {o:out}")
+	hello.InParam("name").From(src.Out())
+	hello.SetOutFunc("out", func(tsk *Task) string {
+		return "/tmp/dry_run_" + tsk.Param("name") + ".txt"
+	})
+	upper := wf.NewProc("upper", "tr a-z A-Z < {i:in} > {o:out}")
+	upper.In("in").From(hello.Out("out"))
+	upper.SetOut("out", "{i:in|%.txt}.upper.txt")
+	wf.Run()
+
+	tasks := []dryRunTask{}
+	for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n") {
+		task := dryRunTask{}
+		if err := json.Unmarshal([]byte(line), &task); err != nil {
+			t.Fatalf("Could not parse dry run line as JSON: %s", line)
+		}
+		tasks = append(tasks, task)
+	}
+	if len(tasks) != 4 {
+		t.Fatalf("Expected dry run info for 4 tasks, got %d: %s", len(tasks), out.String())
+	}
+
+	expectedOutFiles := map[string]string{
+		"/tmp/dry_run_a.txt":       "echo hello a > /tmp/dry_run_a.txt",
+		"/tmp/dry_run_b.txt":       "echo hello b > /tmp/dry_run_b.txt",
+		"/tmp/dry_run_a.upper.txt": "tr a-z A-Z < /tmp/dry_run_a.txt > /tmp/dry_run_a.upper.txt",
+		"/tmp/dry_run_b.upper.txt": "tr a-z A-Z < /tmp/dry_run_b.txt > /tmp/dry_run_b.upper.txt",
+	}
+	for _, task := range tasks {
+		outFile := task.OutFiles["out"]
+		expectedCmd, ok := expectedOutFiles[outFile]
+		if !ok {
+			t.Errorf("Unexpected output file in dry run: %s", outFile)
+			continue
+		}
+		// Commands are formatted with the paths of their temp outputs
+		if !strings.HasPrefix(task.Command, strings.Split(expectedCmd, " > ")[0]) {
+			t.Errorf("Expected command for %s to start like '%s', got: %s", outFile, expectedCmd, task.Command)
+		}
+		if _, err := os.Stat(outFile); !os.IsNotExist(err) {
+			t.Errorf("Expected no output file to be created in dry run, but found: %s", outFile)
+		}
+	}
+}
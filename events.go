@@ -0,0 +1,76 @@
+package scipipe
+
+import "time"
+
+// ----------------------------------------------------------------------------
+// Lifecycle events
+// ----------------------------------------------------------------------------
+
+// EventType is the type of a lifecycle Event of a workflow
+type EventType string
+
+// Types of lifecycle events
+const (
+	// EventTaskStarted is sent when a task starts executing, after having
+	// waited for resources and a free slot
+	EventTaskStarted EventType = "task_started"
+	// EventTaskFinished is sent when a task has finished successfully, and its
+	// outputs are in place
+	EventTaskFinished EventType = "task_finished"
+	// EventTaskFailed is sent when the command of a task, or the validation of
+	// its outputs, fails
+	EventTaskFailed EventType = "task_failed"
+)
+
+// EventsBufSize is the number of events buffered in the channel returned by
+// Workflow.Events. Events sent while the buffer is full are dropped.
+const EventsBufSize = 1024
+
+// Event is a lifecycle event of a task in a workflow, as delivered on the
+// channel returned by Workflow.Events
+type Event struct {
+	Type    EventType
+	TaskID  string
+	Process string
+	Time    time.Time
+	// Error is the error of failed tasks
+	Error string `json:",omitempty"`
+}
+
+// Events returns a channel on which lifecycle events of the tasks of the
+// workflow are delivered while it runs, such as for showing the progress of a
+// workflow in a user interface. Events are only sent after Events has been
+// called. The channel is buffered (see EventsBufSize), and events are dropped
+// rather than blocking the execution of tasks, if the buffer is full. The
+// channel is never closed, since a workflow can be run more than once.
+func (wf *Workflow) Events() <-chan Event {
+	wf.eventsMx.Lock()
+	defer wf.eventsMx.Unlock()
+	if wf.events == nil {
+		wf.events = make(chan Event, EventsBufSize)
+	}
+	return wf.events
+}
+
+// sendEvent sends an event of type evType for task t, with the error errMsg,
+// if any, on the events channel of the workflow, if Events has been called
+func (wf *Workflow) sendEvent(evType EventType, t *Task, errMsg string) {
+	if wf == nil {
+		return
+	}
+	wf.eventsMx.Lock()
+	events := wf.events
+	wf.eventsMx.Unlock()
+	if events == nil {
+		return
+	}
+	ev := Event{Type: evType, TaskID: t.ID(), Process: t.Name, Time: time.Now(), Error: errMsg}
+	if t.Process != nil {
+		ev.Process = t.Process.Name()
+	}
+	select {
+	case events <- ev:
+	default:
+		t.workflow.DebugLogger().Printf("| %-32s | Events buffer full, so dropping %s event of task %s\n", t.Name, evType, t.ID())
+	}
+}
@@ -0,0 +1,58 @@
+package scipipe
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEvents(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("test_wf", 4)
+	events := wf.Events()
+	nSource := NewParamSource(wf, "numbers", "1", "2")
+	echo := wf.NewProc("echo", "echo {p:number} > {o:out}")
+	echo.InParam("number").From(nSource.Out())
+	echo.SetOut("out", "/tmp/events_{p:number}.txt")
+	// The failure of the task for the first file is tolerated, since the
+	// process stops on the first success, which is delayed, so that the
+	// failing task is not cancelled
+	tryFiles := wf.NewProc("try_files", "if grep -q 2 {i:in}; then sleep 0.3 && cat {i:in} > {o:out}; else false; fi")
+	tryFiles.In("in").From(echo.Out("out"))
+	tryFiles.SetOut("out", "{i:in}.tried")
+	tryFiles.StopOnFirstSuccess = true
+	wf.Run()
+	defer cleanFiles("/tmp/events_1.txt", "/tmp/events_2.txt", "/tmp/events_2.txt.tried")
+
+	eventTypes := map[string][]EventType{}
+	for len(events) > 0 {
+		ev := <-events
+		eventTypes[ev.Process] = append(eventTypes[ev.Process], ev.Type)
+		if ev.TaskID == "" || ev.Time.IsZero() {
+			t.Errorf("Expected event with task id and time, got: %v", ev)
+		}
+		if (ev.Type == EventTaskFailed) != (ev.Error != "") {
+			t.Errorf("Expected an error in failed events only, got: %v", ev)
+		}
+	}
+
+	expected := map[string][]EventType{
+		"echo":      {EventTaskStarted, EventTaskStarted, EventTaskFinished, EventTaskFinished},
+		"try_files": {EventTaskStarted, EventTaskStarted, EventTaskFailed, EventTaskFinished},
+	}
+	for procName, exp := range expected {
+		// Events of different tasks of a process may be interleaved, so only
+		// their numbers are compared
+		if !reflect.DeepEqual(countEventTypes(eventTypes[procName]), countEventTypes(exp)) {
+			t.Errorf("Expected events %v for process %s, got %v", exp, procName, eventTypes[procName])
+		}
+	}
+}
+
+func countEventTypes(types []EventType) map[EventType]int {
+	counts := map[EventType]int{}
+	for _, et := range types {
+		counts[et]++
+	}
+	return counts
+}
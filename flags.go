@@ -0,0 +1,29 @@
+package scipipe
+
+import (
+	"flag"
+)
+
+// FlagParam defines a string command-line flag with the name, default value
+// and usage string provided, in the flag package's default set of flags, and
+// returns an accessor for the value of the flag, to be called after
+// flag.Parse(). This allows exposing parameters of a workflow as command-line
+// flags, such as:
+//
+//	genome := scipipe.FlagParam("genome", "hg38", "Reference genome to align to")
+//	flag.Parse()
+//	align.InParam("genome").FromStr(genome())
+func FlagParam(name string, defaultVal string, usage string) func() string {
+	return flagParam(flag.CommandLine, name, defaultVal, usage)
+}
+
+// flagParam is like FlagParam, but defines the flag in the flag set fs
+func flagParam(fs *flag.FlagSet, name string, defaultVal string, usage string) func() string {
+	val := fs.String(name, defaultVal, usage)
+	return func() string {
+		if !fs.Parsed() {
+			Failf("Can't get the value of flag -%s, since flags have not been parsed yet (call flag.Parse() first)\n", name)
+		}
+		return *val
+	}
+}
@@ -0,0 +1,37 @@
+package scipipe
+
+import (
+	"flag"
+	"io/ioutil"
+	"testing"
+)
+
+func TestFlagParam(t *testing.T) {
+	initTestLogs()
+
+	fs := flag.NewFlagSet("test_flags", flag.ContinueOnError)
+	genome := flagParam(fs, "genome", "hg19", "Reference genome")
+	sample := flagParam(fs, "sample", "sample_1", "Sample name")
+	err := fs.Parse([]string{"-genome", "hg38"})
+	Check(err)
+
+	if sample() != "sample_1" {
+		t.Errorf("Expected flag without value to get its default value sample_1, got %s", sample())
+	}
+
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("print_genome", "echo {p:genome} > {o:out}")
+	p.InParam("genome").FromStr(genome())
+	p.SetOut("out", "/tmp/flag_param_{p:genome}.txt")
+	wf.Run()
+
+	outFile := "/tmp/flag_param_hg38.txt"
+	out, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Could not read output file %s, for param from flag: %v", outFile, err)
+	}
+	if string(out) != "hg38\n" {
+		t.Errorf("Expected output file to contain 'hg38\\n' from flag, got '%s'", string(out))
+	}
+	cleanFiles(outFile)
+}
@@ -2,6 +2,8 @@ package scipipe
 
 import (
 	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
 	"encoding/json"
 	"io/ioutil"
 	"os"
@@ -83,6 +85,7 @@ type FileIP struct {
 	*BaseIP
 	buffer    *bytes.Buffer
 	doStream  bool
+	fifoDir   string
 	lock      *sync.Mutex
 	SubStream *InPort
 }
@@ -142,8 +145,14 @@ func (ip *FileIP) TempPath() string {
 const FSRootPlaceHolder = "__fsroot__"
 
 // FifoPath returns the path to use when a FIFO file is used instead of a
-// normal file
+// normal file. FIFO files are created next to the normal file, unless a FIFO
+// dir is set for the workflow (see Workflow.SetFifoDir), in which case they
+// are created there, with a short file name unique to the normal file.
 func (ip *FileIP) FifoPath() string {
+	if ip.fifoDir != "" {
+		hash := sha1.Sum([]byte(ip.path))
+		return filepath.Join(ip.fifoDir, hex.EncodeToString(hash[:8])+".fifo")
+	}
 	return ip.path + ".fifo"
 }
 
@@ -226,9 +235,25 @@ func (ip *FileIP) OpenWriteTemp() *os.File {
 // FIFO-specific stuff
 // ------------------------------------------------------------------------
 
+// IsStreaming returns true if the file is streamed through a FIFO file
+// (named pipe), at FifoPath(), rather than written to disk
+func (ip *FileIP) IsStreaming() bool {
+	return ip.doStream
+}
+
+// SetStreaming sets whether the file should be streamed through a FIFO file
+// (named pipe), rather than written to disk
+func (ip *FileIP) SetStreaming(doStream bool) {
+	ip.doStream = doStream
+}
+
 // CreateFifo creates a FIFO file for the FileIP
 func (ip *FileIP) CreateFifo() {
 	ip.createDirs()
+	if ip.fifoDir != "" {
+		err := os.MkdirAll(ip.fifoDir, 0777)
+		CheckWithMsg(err, "Could not create FIFO dir: "+ip.fifoDir)
+	}
 	ip.lock.Lock()
 	cmd := "mkfifo " + ip.FifoPath()
 	Debug.Println("Now creating FIFO with command:", cmd)
@@ -236,8 +261,11 @@ func (ip *FileIP) CreateFifo() {
 	if _, err := os.Stat(ip.FifoPath()); err == nil {
 		Warning.Println("FIFO already exists, so not creating a new one:", ip.FifoPath())
 	} else {
-		_, err := exec.Command("bash", "-c", cmd).Output()
-		CheckWithMsg(err, "Could not execute command: "+cmd)
+		out, err := exec.Command("bash", "-c", cmd).CombinedOutput()
+		if err != nil {
+			ip.lock.Unlock()
+			Failf("Could not create FIFO file %s (for %s), with command: %s\nOutput: %s\nOriginal error: %s\nIf the file system does not support FIFO files, or the path is too long, use Workflow.SetFifoDir() to create FIFO files in another directory.\n", ip.FifoPath(), ip.Path(), cmd, strings.TrimSpace(string(out)), err.Error())
+		}
 	}
 
 	ip.lock.Unlock()
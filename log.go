@@ -1,12 +1,16 @@
 package scipipe
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
 )
 
 var (
@@ -36,34 +40,146 @@ func InitLog(
 	errorHandle io.Writer) {
 
 	if !logExists {
-		Trace = log.New(traceHandle,
-			"TRACE   ",
-			log.Ldate|log.Ltime|log.Lshortfile)
+		Trace = newLevelLogger(traceHandle, "TRACE")
+		Debug = newLevelLogger(debugHandle, "DEBUG")
+		Info = newLevelLogger(infoHandle, "INFO")
+		// This level is the one suggested to use when running scientific workflows, to retain audit
+		// information
+		Audit = newLevelLogger(auditHandle, "AUDIT")
+		Warning = newLevelLogger(warningHandle, "WARNING")
+		Error = newLevelLogger(errorHandle, "ERROR")
 
-		Debug = log.New(debugHandle,
-			"DEBUG   ",
-			log.Ldate|log.Ltime|log.Lshortfile)
+		logExists = true
+	}
+}
 
-		Info = log.New(infoHandle,
-			"INFO    ",
-			log.Ldate|log.Ltime)
+// newLevelLogger returns a new logger for the log level with name level,
+// writing to handle in the current log format
+func newLevelLogger(handle io.Writer, level string) *log.Logger {
+	logger := log.New(handle, "", 0)
+	applyLogFormat(logger, level)
+	return logger
+}
 
-		// This level is the one suggested to use when running scientific workflows, to retain audit
-		// information
-		Audit = log.New(auditHandle,
-			"AUDIT   ",
-			log.Ldate|log.Ltime)
+// ----------------------------------------------------------------------------
+// Log formats
+// ----------------------------------------------------------------------------
 
-		Warning = log.New(warningHandle,
-			"WARNING ",
-			log.Ldate|log.Ltime)
+// LogFormat is the format in which the package's loggers write log messages
+type LogFormat int
 
-		Error = log.New(errorHandle,
-			"ERROR   ",
-			log.Ldate|log.Ltime)
+const (
+	// LogFormatText writes log messages as human-readable lines, prefixed by
+	// their level and time, which is the default
+	LogFormatText LogFormat = iota
+	// LogFormatJSON writes log messages as JSON objects, one per line, for
+	// ingestion into log management systems (see JSONLogEntry)
+	LogFormatJSON
+)
 
-		logExists = true
+var logFormat = LogFormatText
+
+// textLogFlags are the flags of the loggers of each log level, in the text
+// log format
+var textLogFlags = map[string]int{
+	"TRACE":   log.Ldate | log.Ltime | log.Lshortfile,
+	"DEBUG":   log.Ldate | log.Ltime | log.Lshortfile,
+	"INFO":    log.Ldate | log.Ltime,
+	"AUDIT":   log.Ldate | log.Ltime,
+	"WARNING": log.Ldate | log.Ltime,
+	"ERROR":   log.Ldate | log.Ltime,
+}
+
+// SetLogFormat sets the format in which the Trace, Debug, Info, Audit, Warning
+// and Error loggers write log messages, which defaults to LogFormatText. It
+// applies both to loggers already initialized, and ones initialized later, and
+// should be set before the workflow is run.
+func SetLogFormat(format LogFormat) {
+	logFormat = format
+	levelLoggers := map[string]*log.Logger{
+		"TRACE":   Trace,
+		"DEBUG":   Debug,
+		"INFO":    Info,
+		"AUDIT":   Audit,
+		"WARNING": Warning,
+		"ERROR":   Error,
+	}
+	for level, logger := range levelLoggers {
+		if logger != nil {
+			applyLogFormat(logger, level)
+		}
+	}
+}
+
+// applyLogFormat sets up logger, for the log level with name level, to write
+// in the current log format
+func applyLogFormat(logger *log.Logger, level string) {
+	handle := logger.Writer()
+	if jsonWriter, ok := handle.(*jsonLogWriter); ok {
+		handle = jsonWriter.handle
+	}
+	if logFormat == LogFormatJSON {
+		logger.SetPrefix("")
+		logger.SetFlags(0)
+		logger.SetOutput(&jsonLogWriter{handle: handle, level: level})
+		return
+	}
+	logger.SetPrefix(fmt.Sprintf("%-8s", level))
+	logger.SetFlags(textLogFlags[level])
+	logger.SetOutput(handle)
+}
+
+// JSONLogEntry is a log message in the JSON log format. Process is the name of
+// the process, task or workflow that the message is about, and Task and
+// Command are set for messages about executing the command of a task.
+type JSONLogEntry struct {
+	Timestamp string `json:"timestamp"`
+	Level     string `json:"level"`
+	Process   string `json:"process,omitempty"`
+	Task      string `json:"task,omitempty"`
+	Command   string `json:"command,omitempty"`
+	Message   string `json:"message"`
+}
+
+var (
+	// componentMessagePtn matches messages in the format of auditLogPattern
+	componentMessagePtn = regexp.MustCompile(`^\| (.*?) *\| (.*)$`)
+	// taskCommandPtn matches messages about executing the command of a task
+	taskCommandPtn = regexp.MustCompile(`^(?:Executing|Finished) task (\S+): (.*)$`)
+)
+
+// jsonLogWriter writes each log message written to it as a JSONLogEntry, to
+// handle
+type jsonLogWriter struct {
+	handle io.Writer
+	level  string
+}
+
+func (w *jsonLogWriter) Write(msg []byte) (int, error) {
+	entry := JSONLogEntry{
+		Timestamp: time.Now().Format(time.RFC3339Nano),
+		Level:     w.level,
+		Message:   strings.TrimRight(string(msg), "\n"),
+	}
+	if m := componentMessagePtn.FindStringSubmatch(entry.Message); m != nil {
+		entry.Process = m[1]
+		entry.Message = m[2]
+	}
+	if m := taskCommandPtn.FindStringSubmatch(entry.Message); m != nil {
+		entry.Task = m[1]
+		// Tasks executing custom Go functions have no command
+		if !strings.HasPrefix(m[2], "Custom Go function") {
+			entry.Command = m[2]
+		}
+	}
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.handle.Write(append(entryJSON, '\n')); err != nil {
+		return 0, err
 	}
+	return len(msg), nil
 }
 
 // InitLogDebug initiates logging with level=DEBUG
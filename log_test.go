@@ -0,0 +1,51 @@
+package scipipe
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestSetLogFormat(t *testing.T) {
+	initTestLogs()
+	origAudit := Audit
+	defer func() {
+		SetLogFormat(LogFormatText)
+		Audit = origAudit
+	}()
+	buf := &bytes.Buffer{}
+	Audit = newLevelLogger(buf, "AUDIT")
+
+	SetLogFormat(LogFormatJSON)
+	LogAuditf("align", "Executing task %s: %s", "align.sample_1.fq.3f2a9c1", "bwa mem ref.fa sample_1.fq")
+	entry := JSONLogEntry{}
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Could not parse log message as JSON: %s", buf.String())
+	}
+	expected := JSONLogEntry{
+		Timestamp: entry.Timestamp,
+		Level:     "AUDIT",
+		Process:   "align",
+		Task:      "align.sample_1.fq.3f2a9c1",
+		Command:   "bwa mem ref.fa sample_1.fq",
+		Message:   "Executing task align.sample_1.fq.3f2a9c1: bwa mem ref.fa sample_1.fq",
+	}
+	if entry != expected {
+		t.Errorf("Expected JSON log entry %+v, got %+v", expected, entry)
+	}
+	if entry.Timestamp == "" {
+		t.Error("Expected JSON log entry to have a timestamp")
+	}
+
+	// Switching back to text should restore the prefix of the level
+	buf.Reset()
+	SetLogFormat(LogFormatText)
+	Audit.Println("plain message")
+	if !strings.HasPrefix(buf.String(), "AUDIT   ") || !strings.HasSuffix(buf.String(), "plain message\n") {
+		t.Errorf("Expected text log message prefixed by its level, got: %s", buf.String())
+	}
+	if Audit.Writer() != buf {
+		t.Error("Expected the original writer to be restored")
+	}
+}
@@ -0,0 +1,102 @@
+package scipipe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Task output manifests
+// ----------------------------------------------------------------------------
+
+// manifestExt is the extension of the manifest files written for tasks of
+// processes with Process.WriteManifests set
+const manifestExt = ".manifest.json"
+
+// TaskManifest lists all the files a task created, for use in cleaning up
+// after workflows, or in discovering the outputs of tasks downstream
+type TaskManifest struct {
+	TaskID  string
+	Process string
+	// OutFiles contains the paths of the outputs of the task, by out-port
+	OutFiles map[string]string
+	// Files contains the paths of all files the task created, including any
+	// extra files, that are not outputs on any out-port, such as index files
+	// created next to outputs
+	Files []string
+}
+
+// WriteManifest writes a manifest, listing all the files the task created, as
+// JSON to the file at path (see TaskManifest). For tasks of processes with
+// WriteManifests set, it is called automatically when the task has finished.
+func (t *Task) WriteManifest(path string) {
+	manifest := TaskManifest{
+		TaskID:   t.ID(),
+		Process:  t.Name,
+		OutFiles: map[string]string{},
+		Files:    []string{},
+	}
+	files := map[string]bool{}
+	for oipName, oip := range t.OutIPs {
+		manifest.OutFiles[oipName] = oip.Path()
+		if !oip.doStream {
+			files[oip.Path()] = true
+		}
+	}
+	for _, path := range t.extraFiles {
+		files[path] = true
+	}
+	for path := range files {
+		manifest.Files = append(manifest.Files, path)
+	}
+	sort.Strings(manifest.Files)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "    ")
+	CheckWithMsg(err, "Could not marshal manifest of task "+t.ID())
+	err = os.MkdirAll(filepath.Dir(path), 0777)
+	CheckWithMsg(err, "Could not create directory for manifest: "+path)
+	err = ioutil.WriteFile(path, manifestJSON, 0644)
+	CheckWithMsg(err, "Could not write manifest: "+path)
+}
+
+// writesManifest tells whether a manifest should be written for the task
+func (t *Task) writesManifest() bool {
+	return t.Process != nil && t.Process.WriteManifests && len(t.OutIPs) > 0
+}
+
+// manifestPath returns the path of the manifest written for the task, when
+// its process has WriteManifests set, which is named after the task's id, and
+// placed next to its first (by out-port name) output
+func (t *Task) manifestPath() string {
+	oipNames := sortedFileIPMapKeys(t.OutIPs)
+	return filepath.Join(filepath.Dir(t.OutIPs[oipNames[0]].Path()), t.ID()+manifestExt)
+}
+
+// collectExtraFiles records the final paths of the files in the task's temp
+// dir that are not outputs on any out-port, for listing them in the task's
+// manifest. It must be called before the files are moved out of the temp dir.
+func (t *Task) collectExtraFiles() {
+	tempPaths := map[string]bool{}
+	for _, oip := range t.OutIPs {
+		tempPaths[filepath.Join(t.TempDir(), oip.TempPath())] = true
+	}
+	filepath.Walk(t.TempDir(), func(tempPath string, fileInfo os.FileInfo, err error) error {
+		if err != nil || fileInfo.IsDir() {
+			return nil
+		}
+		// Files inside directory outputs are part of the outputs
+		for outTempPath := range tempPaths {
+			if tempPath == outTempPath || strings.HasPrefix(tempPath, outTempPath+"/") {
+				return nil
+			}
+		}
+		path := strings.Replace(tempPath, t.TempDir()+"/", "", 1)
+		path = strings.Replace(path, FSRootPlaceHolder+"/", "/", 1)
+		t.extraFiles = append(t.extraFiles, path)
+		return nil
+	})
+}
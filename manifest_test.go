@@ -0,0 +1,43 @@
+package scipipe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestWriteManifests(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("index", "echo data > {o:data}; echo index > {o:data}.idx; echo log > {o:log}")
+	p.SetOut("log", "/tmp/manifest_test.log")
+	p.WriteManifests = true
+
+	var taskID string
+	p.SetOutFunc("data", func(tsk *Task) string {
+		taskID = tsk.ID()
+		return "/tmp/manifest_test.txt"
+	})
+	wf.Run()
+	defer cleanFiles("/tmp/manifest_test.txt", "/tmp/manifest_test.txt.idx", "/tmp/manifest_test.log")
+
+	manifestPath := "/tmp/" + taskID + manifestExt
+	defer cleanFiles(manifestPath)
+	manifestJSON, err := ioutil.ReadFile(manifestPath)
+	if err != nil {
+		t.Fatalf("Could not read manifest %s: %s", manifestPath, err.Error())
+	}
+	manifest := TaskManifest{}
+	err = json.Unmarshal(manifestJSON, &manifest)
+	Check(err)
+
+	expectedFiles := []string{"/tmp/manifest_test.log", "/tmp/manifest_test.txt", "/tmp/manifest_test.txt.idx"}
+	if !reflect.DeepEqual(manifest.Files, expectedFiles) {
+		t.Errorf("Expected manifest to list files %v, got %v", expectedFiles, manifest.Files)
+	}
+	expectedOutFiles := map[string]string{"data": "/tmp/manifest_test.txt", "log": "/tmp/manifest_test.log"}
+	if !reflect.DeepEqual(manifest.OutFiles, expectedOutFiles) {
+		t.Errorf("Expected manifest to list outputs %v, got %v", expectedOutFiles, manifest.OutFiles)
+	}
+}
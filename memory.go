@@ -0,0 +1,86 @@
+package scipipe
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Memory limits
+// ----------------------------------------------------------------------------
+
+// memorySizeUnits are the multipliers of the units allowed in memory sizes,
+// which are binary, as in most schedulers
+var memorySizeUnits = map[string]int64{
+	"K": 1 << 10,
+	"M": 1 << 20,
+	"G": 1 << 30,
+	"T": 1 << 40,
+}
+
+// memorySizeRegex matches memory sizes such as "512M", "8G" or "8GB"
+var memorySizeRegex = regexp.MustCompile(`^([0-9]+)([KMGTkmgt])[Bb]?$`)
+
+// parseMemorySize returns the number of bytes of the memory size size, which
+// is a whole number followed by one of the units K, M, G or T (optionally
+// followed by B, as in "8GB")
+func parseMemorySize(size string) (int64, error) {
+	m := memorySizeRegex.FindStringSubmatch(strings.TrimSpace(size))
+	if m == nil {
+		return 0, fmt.Errorf("Malformed memory size '%s': must be a whole number followed by one of the units K, M, G or T, such as \"8G\"", size)
+	}
+	num, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil || num < 1 {
+		return 0, fmt.Errorf("Malformed memory size '%s': must be a positive whole number followed by a unit", size)
+	}
+	return num * memorySizeUnits[strings.ToUpper(m[2])], nil
+}
+
+// memoryPerTaskBytes returns the number of bytes of MemoryPerTask of the
+// process, or 0 if it is not set. MemoryPerTask is checked when the workflow
+// is validated, so a malformed size makes the workflow fail here only if it
+// was changed after that.
+func (p *Process) memoryPerTaskBytes() int64 {
+	if p.MemoryPerTask == "" {
+		return 0
+	}
+	bytes, err := parseMemorySize(p.MemoryPerTask)
+	if err != nil {
+		Failf("%s: %s\n", p.Name(), err.Error())
+	}
+	return bytes
+}
+
+// plannedMemoryMB returns the amount of memory, in megabytes, that a single
+// task of the process is planned to use, which is MemoryPerTask, rounded up
+// to whole megabytes, if set and valid, or otherwise MaxMemoryMB
+func (p *Process) plannedMemoryMB() int {
+	if p.MemoryPerTask == "" {
+		return p.MaxMemoryMB
+	}
+	bytes, err := parseMemorySize(p.MemoryPerTask)
+	if err != nil {
+		return p.MaxMemoryMB
+	}
+	return int((bytes + memorySizeUnits["M"] - 1) / memorySizeUnits["M"])
+}
+
+// ulimitMemoryCommand returns cmd prefixed with a ulimit command capping the
+// virtual memory of the shell running it, and thereby of all processes it
+// starts, at bytes (rounded down to whole kilobytes, as ulimit takes them)
+func ulimitMemoryCommand(bytes int64, cmd string) string {
+	return fmt.Sprintf("ulimit -v %d && %s", bytes>>10, cmd)
+}
+
+// pbsMemory formats bytes as a PBS memory size, in the largest unit that
+// represents it exactly
+func pbsMemory(bytes int64) string {
+	for _, unit := range []string{"T", "G", "M"} {
+		if bytes%memorySizeUnits[unit] == 0 {
+			return fmt.Sprintf("%d%sb", bytes/memorySizeUnits[unit], strings.ToLower(unit))
+		}
+	}
+	return fmt.Sprintf("%dkb", bytes>>10)
+}
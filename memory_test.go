@@ -0,0 +1,96 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestParseMemorySize(t *testing.T) {
+	for size, expected := range map[string]int64{
+		"512K": 512 << 10,
+		"8G":   8 << 30,
+		"8gb":  8 << 30,
+		"100M": 100 << 20,
+		"2T":   2 << 40,
+	} {
+		bytes, err := parseMemorySize(size)
+		if err != nil {
+			t.Errorf("Expected memory size %s to be valid, got: %s", size, err.Error())
+		} else if bytes != expected {
+			t.Errorf("Expected memory size %s to be %d bytes, got %d", size, expected, bytes)
+		}
+	}
+	for _, size := range []string{"", "8", "G", "0G", "1.5G", "8X", "-1G", "8 GiB"} {
+		if _, err := parseMemorySize(size); err == nil {
+			t.Errorf("Expected memory size %q to be malformed", size)
+		}
+	}
+}
+
+func TestPBSMemory(t *testing.T) {
+	for bytes, expected := range map[int64]string{
+		8 << 30:    "8gb",
+		1536 << 20: "1536mb",
+		2 << 40:    "2tb",
+		1025 << 10: "1025kb",
+	} {
+		if actual := pbsMemory(bytes); actual != expected {
+			t.Errorf("Expected PBS memory %s for %d bytes, got %s", expected, bytes, actual)
+		}
+	}
+}
+
+func TestValidateMemoryPerTask(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	foo := wf.NewProc("foo", "echo foo > {o:out}")
+	foo.SetOut("out", "/tmp/memory_foo.txt")
+	foo.MemoryPerTask = "8 gigs"
+
+	err := wf.Validate()
+	if err == nil || !strings.Contains(err.Error(), "MemoryPerTask of process 'foo' is not valid") {
+		t.Errorf("Expected workflow with malformed MemoryPerTask not to be valid, got: %v", err)
+	}
+	foo.MemoryPerTask = "8G"
+	if err := wf.Validate(); err != nil {
+		t.Errorf("Expected workflow with valid MemoryPerTask to be valid, got: %s", err.Error())
+	}
+
+	// Tasks should not be expected to use more memory than they may
+	foo.MaxMemoryMB = 9000
+	err = wf.Validate()
+	if err == nil || !strings.Contains(err.Error(), "MaxMemoryMB (9000) of process 'foo' is larger than its MemoryPerTask (8G)") {
+		t.Errorf("Expected workflow with MaxMemoryMB larger than MemoryPerTask not to be valid, got: %v", err)
+	}
+	foo.MaxMemoryMB = 8192
+	if err := wf.Validate(); err != nil {
+		t.Errorf("Expected workflow with MaxMemoryMB within MemoryPerTask to be valid, got: %s", err.Error())
+	}
+}
+
+func TestEnforceMemoryPerTask(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	limited := wf.NewProc("limited", "ulimit -v > {o:out}")
+	limited.SetOut("out", "/tmp/memory_limited.txt")
+	limited.MemoryPerTask = "2G"
+	limited.EnforceMemoryPerTask = true
+	// Without EnforceMemoryPerTask, MemoryPerTask is not enforced locally
+	unlimited := wf.NewProc("unlimited", "ulimit -v > {o:out}")
+	unlimited.SetOut("out", "/tmp/memory_unlimited.txt")
+	unlimited.MemoryPerTask = "2G"
+	wf.Run()
+	defer cleanFiles("/tmp/memory_limited.txt", "/tmp/memory_unlimited.txt")
+
+	out, err := ioutil.ReadFile("/tmp/memory_limited.txt")
+	Check(err)
+	if strings.TrimSpace(string(out)) != "2097152" {
+		t.Errorf("Expected command to be limited to 2097152 KB of virtual memory, got: %s", out)
+	}
+	out, err = ioutil.ReadFile("/tmp/memory_unlimited.txt")
+	Check(err)
+	if strings.TrimSpace(string(out)) == "2097152" {
+		t.Errorf("Expected memory limit not to be enforced without EnforceMemoryPerTask")
+	}
+}
@@ -2,6 +2,7 @@ package scipipe
 
 import (
 	"os"
+	"os/exec"
 	"path/filepath"
 	"reflect"
 	"sync"
@@ -73,3 +74,28 @@ func assertEqualValues(t *testing.T, expected interface{}, actual interface{}, m
 		t.Errorf("Values are not equal (Expected: %v, Actual: %v)\n", expected, actual)
 	}
 }
+
+// subprocessTestEnvVar is set to the name of the test being run in a
+// sub-process of the test binary, by runFailingSubprocess
+const subprocessTestEnvVar = "SCIPIPE_TEST_SUBPROCESS"
+
+// inSubprocess tells whether the test t is being run in a sub-process of the
+// test binary, by runFailingSubprocess
+func inSubprocess(t *testing.T) bool {
+	return os.Getenv(subprocessTestEnvVar) == t.Name()
+}
+
+// runFailingSubprocess runs the test t in a sub-process of the test binary,
+// for testing failures that exit the process, such as failing workflows, and
+// returns its combined output. The test fails if the sub-process does not exit
+// with a non-zero exit code. Extra environment variables, on the form
+// KEY=value, can be passed to the sub-process in env.
+func runFailingSubprocess(t *testing.T, env ...string) string {
+	cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$")
+	cmd.Env = append(append(os.Environ(), subprocessTestEnvVar+"="+t.Name()), env...)
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.Success() {
+		t.Fatalf("Expected %s to fail with a non-zero exit code in a sub-process, got error: %v\nOutput: %s", t.Name(), err, out)
+	}
+	return string(out)
+}
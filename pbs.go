@@ -0,0 +1,205 @@
+package scipipe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// PBS/Torque execution
+// ----------------------------------------------------------------------------
+
+// DefaultPBSPollInterval is how often the status of jobs submitted to PBS is
+// checked, unless Process.PBSPollInterval is set to something else
+const DefaultPBSPollInterval = 10 * time.Second
+
+// jobExitError is the error for batch jobs whose command exited with a
+// non-zero exit code
+type jobExitError struct {
+	jobID    string
+	exitCode int
+}
+
+func (e *jobExitError) Error() string {
+	return fmt.Sprintf("Job %s exited with exit code %d", e.jobID, e.exitCode)
+}
+
+// ExitCode returns the exit code of the command of the job
+func (e *jobExitError) ExitCode() int {
+	return e.exitCode
+}
+
+// runOnPBS runs the shell command cmd as a job submitted to PBS/Torque with
+// qsub, and waits for it to finish, by polling qstat. The stdout and stderr of
+// the job are written to files named after the task id, next to the task's
+// first output, and their contents are returned as the output.
+func (t *Task) runOnPBS(cmd string) ([]byte, error) {
+	tempDir, err := filepath.Abs(t.TempDir())
+	if err != nil {
+		return nil, errWrap(err, "Could not get absolute path of temp dir "+t.TempDir())
+	}
+	jobFilesPrefix := filepath.Join(tempDir, t.ID())
+	for _, oipName := range sortedFileIPMapKeys(t.OutIPs) {
+		if oip := t.OutIPs[oipName]; !oip.doStream {
+			jobFilesPrefix = filepath.Join(tempDir, filepath.Dir(oip.TempPath()), t.ID())
+			break
+		}
+	}
+	scriptPath := filepath.Join(tempDir, "pbs_job.sh")
+	exitCodePath := filepath.Join(tempDir, "pbs_job.exitcode")
+	stdoutPath := jobFilesPrefix + ".pbs.out"
+	stderrPath := jobFilesPrefix + ".pbs.err"
+	// The job script and exit code file are removed, so that they are not
+	// moved along with the outputs of the task
+	defer os.Remove(scriptPath)
+	defer os.Remove(exitCodePath)
+
+	script := pbsJobScript(pbsJobName(t.Name), t.cores, t.Process.memoryPerTaskBytes(), t.Process.Timeout, stdoutPath, stderrPath, tempDir, cmd, exitCodePath)
+	if err := ioutil.WriteFile(scriptPath, []byte(script), 0755); err != nil {
+		return nil, errWrap(err, "Could not write PBS job script "+scriptPath)
+	}
+
+	qsubOut, err := exec.Command("qsub", scriptPath).CombinedOutput()
+	if err != nil {
+		return qsubOut, errWrap(err, "Could not submit PBS job with qsub")
+	}
+	jobID := strings.TrimSpace(string(qsubOut))
+	t.workflow.logAuditf(t.logName(), "Submitted task %s as PBS job %s", t.ID(), jobID)
+
+	pollInterval := t.Process.PBSPollInterval
+	if pollInterval <= 0 {
+		pollInterval = DefaultPBSPollInterval
+	}
+	statusFailures := 0
+	for {
+		finished, err := pbsJobFinished(jobID)
+		if err != nil {
+			// Errors that don't say the job is unknown, such as when the
+			// scheduler is temporarily unreachable, don't mean that the job
+			// has finished, so the job is polled again, unless qstat could
+			// not be run at all, or has failed too many times in a row
+			if _, ok := err.(*qstatTransientError); !ok {
+				return nil, errWrapf(err, "Could not get status of PBS job %s", jobID)
+			}
+			statusFailures++
+			if statusFailures >= pbsMaxStatusFailures {
+				return nil, errWrapf(err, "Could not get status of PBS job %s in %d attempts in a row, so giving up on it (it might still be running)", jobID, statusFailures)
+			}
+			t.workflow.WarningLogger().Printf("| %-32s | Could not get status of PBS job %s, so polling again in %v: %s\n", t.Name, jobID, pollInterval, err.Error())
+		} else if finished {
+			break
+		} else {
+			statusFailures = 0
+		}
+		time.Sleep(pollInterval)
+	}
+
+	stdout, _ := ioutil.ReadFile(stdoutPath)
+	stderr, _ := ioutil.ReadFile(stderrPath)
+	out := append(stdout, stderr...)
+	exitCodeStr, err := ioutil.ReadFile(exitCodePath)
+	if err != nil {
+		return out, errWrapf(err, "PBS job %s finished without exit code, so it was probably killed", jobID)
+	}
+	exitCode, err := strconv.Atoi(strings.TrimSpace(string(exitCodeStr)))
+	if err != nil {
+		return out, errWrapf(err, "Could not parse exit code of PBS job %s", jobID)
+	}
+	if exitCode != 0 {
+		return out, &jobExitError{jobID: jobID, exitCode: exitCode}
+	}
+	return out, nil
+}
+
+// pbsJobScript returns a PBS job script, with directives for the job name,
+// cores, memory and walltime (if larger than zero), and files for stdout and
+// stderr, that runs cmd in workDir, and writes its exit code to exitCodePath
+func pbsJobScript(jobName string, cores int, memory int64, walltime time.Duration, stdoutPath string, stderrPath string, workDir string, cmd string, exitCodePath string) string {
+	script := "#!/bin/bash\n"
+	script += "#PBS -N " + jobName + "\n"
+	script += fmt.Sprintf("#PBS -l nodes=1:ppn=%d\n", cores)
+	if memory > 0 {
+		script += "#PBS -l mem=" + pbsMemory(memory) + "\n"
+	}
+	if walltime > 0 {
+		script += "#PBS -l walltime=" + formatWalltime(walltime) + "\n"
+	}
+	script += "#PBS -o " + stdoutPath + "\n"
+	script += "#PBS -e " + stderrPath + "\n"
+	script += "cd " + shellQuote(workDir) + " && bash -c " + shellQuote(cmd) + "\n"
+	script += "echo $? > " + shellQuote(exitCodePath) + "\n"
+	return script
+}
+
+// formatWalltime formats d as a PBS walltime, on the form HH:MM:SS, rounded up
+// to whole seconds
+func formatWalltime(d time.Duration) string {
+	secs := int(math.Ceil(d.Seconds()))
+	return fmt.Sprintf("%02d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+}
+
+// pbsJobNameMaxLen is the max length of job names in (older versions of) PBS
+const pbsJobNameMaxLen = 15
+
+// pbsJobName returns a valid PBS job name, derived from the process name
+// procName, with characters not allowed in job names replaced, and cut to the
+// max length of job names
+func pbsJobName(procName string) string {
+	name := regexp.MustCompile("[^A-Za-z0-9_]").ReplaceAllString(procName, "_")
+	if name == "" || !strings.ContainsAny(name[:1], "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") {
+		name = "j" + name
+	}
+	if len(name) > pbsJobNameMaxLen {
+		name = name[:pbsJobNameMaxLen]
+	}
+	return name
+}
+
+// qstatUnknownJobExitCode is the exit code of qstat for job ids it does not
+// know, such as for jobs that finished long enough ago
+const qstatUnknownJobExitCode = 153
+
+// pbsMaxStatusFailures is the number of times in a row that getting the
+// status of a PBS job with qstat may fail, before the task is failed
+const pbsMaxStatusFailures = 10
+
+// qstatTransientError is the error for qstat exiting with an error that does
+// not say that the job is unknown, such as when the scheduler is temporarily
+// unreachable, so that getting the status of the job may succeed if retried
+type qstatTransientError struct {
+	jobID string
+	err   error
+	out   string
+}
+
+func (e *qstatTransientError) Error() string {
+	return fmt.Sprintf("qstat failed for PBS job %s: %s: %s", e.jobID, e.err.Error(), e.out)
+}
+
+// pbsJobFinished tells whether the PBS job with id jobID has finished, which is
+// when qstat no longer knows it, or reports it as completed (C) or finished
+// (F). A qstatTransientError is returned if qstat exits with an error for any
+// other reason, in which case it is not known whether the job has finished,
+// and another error if qstat could not be run at all.
+func pbsJobFinished(jobID string) (bool, error) {
+	qstatOut, err := exec.Command("qstat", "-f", jobID).CombinedOutput()
+	if err != nil {
+		exitErr, ok := err.(*exec.ExitError)
+		if !ok {
+			return false, errWrap(err, "Could not run qstat")
+		}
+		if exitErr.ExitCode() == qstatUnknownJobExitCode || strings.Contains(string(qstatOut), "Unknown Job Id") {
+			return true, nil
+		}
+		return false, &qstatTransientError{jobID: jobID, err: err, out: strings.TrimSpace(string(qstatOut))}
+	}
+	return regexp.MustCompile(`job_state = [CF]\b`).Match(qstatOut), nil
+}
@@ -0,0 +1,180 @@
+package scipipe
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPBSJobScript(t *testing.T) {
+	script := pbsJobScript(pbsJobName("align samples"), 4, 8<<30, 90*time.Minute+500*time.Millisecond, "/data/x.out", "/data/x.err", "/data/tmp", "bwa mem ref.fa 'a b.fq' > out.sam", "/data/tmp/exitcode")
+	for _, expected := range []string{
+		"#PBS -N align_samples\n",
+		"#PBS -l nodes=1:ppn=4\n",
+		"#PBS -l mem=8gb\n",
+		"#PBS -l walltime=01:30:01\n",
+		"#PBS -o /data/x.out\n",
+		"#PBS -e /data/x.err\n",
+		"cd '/data/tmp' && bash -c 'bwa mem ref.fa '\\''a b.fq'\\'' > out.sam'\n",
+		"echo $? > '/data/tmp/exitcode'\n",
+	} {
+		if !strings.Contains(script, expected) {
+			t.Errorf("Expected PBS job script to contain %q, but it was:\n%s", expected, script)
+		}
+	}
+	if strings.Contains(pbsJobScript("job", 1, 0, 0, "x.out", "x.err", ".", "true", "exitcode"), "walltime") {
+		t.Error("Expected no walltime directive for jobs without timeout")
+	}
+
+	for procName, expected := range map[string]string{
+		"align":                    "align",
+		"1st-step":                 "j1st_step",
+		"a_very_long_process_name": "a_very_long_pro",
+	} {
+		if actual := pbsJobName(procName); actual != expected {
+			t.Errorf("Expected job name %s for process %s, got %s", expected, procName, actual)
+		}
+	}
+}
+
+func TestExecModePBS(t *testing.T) {
+	initTestLogs()
+
+	// Fake qsub, which runs the job script right away, and qstat, which
+	// knows no jobs, meaning that they have finished
+	binDir, err := ioutil.TempDir("", "fake_pbs")
+	Check(err)
+	defer os.RemoveAll(binDir)
+	err = ioutil.WriteFile(filepath.Join(binDir, "qsub"), []byte("#!/bin/bash\n"+
+		"out=$(sed -n 's/^#PBS -o //p' \"$1\")\n"+
+		"err=$(sed -n 's/^#PBS -e //p' \"$1\")\n"+
+		"bash \"$1\" > \"$out\" 2> \"$err\"\n"+
+		"echo 1234.fakeserver\n"), 0755)
+	Check(err)
+	err = ioutil.WriteFile(filepath.Join(binDir, "qstat"), []byte("#!/bin/bash\nexit 153\n"), 0755)
+	Check(err)
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+":"+origPath)
+	defer os.Setenv("PATH", origPath)
+
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("pbs_job", "echo foo > {o:out}; echo bar >&2")
+	p.SetOut("out", "pbs_test.txt")
+	p.ExecMode = ExecModePBS
+	p.PBSPollInterval = 10 * time.Millisecond
+	wf.Run()
+	defer cleanFiles("pbs_test.txt")
+
+	out, err := ioutil.ReadFile("pbs_test.txt")
+	Check(err)
+	if string(out) != "foo\n" {
+		t.Errorf("Expected output of PBS job to be 'foo', but was: '%s'", string(out))
+	}
+	errPaths, err := filepath.Glob("pbs_job.*.pbs.err")
+	Check(err)
+	if len(errPaths) != 1 {
+		t.Fatalf("Expected one stderr file of the PBS job next to the output, found: %v", errPaths)
+	}
+	stderr, err := ioutil.ReadFile(errPaths[0])
+	Check(err)
+	if string(stderr) != "bar\n" {
+		t.Errorf("Expected stderr of PBS job to be captured, but was: '%s'", string(stderr))
+	}
+	cleanFiles(errPaths...)
+	cleanFiles(strings.TrimSuffix(errPaths[0], ".err") + ".out")
+
+	// Exit codes of failing jobs should be reported
+	tsk := NewTask(wf, p, "pbs_job", "exit 3", map[string]*FileIP{}, nil, nil, map[string]string{}, map[string]string{}, "", nil, 1)
+	tsk.createDirs()
+	defer os.RemoveAll(tsk.TempDir())
+	_, err = tsk.runCommandInExecMode(tsk.Command)
+	if exitErr, ok := err.(*jobExitError); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("Expected PBS job exiting with exit code 3 to be reported, got: %v", err)
+	}
+}
+
+func TestPBSJobFinished(t *testing.T) {
+	binDir, err := ioutil.TempDir("", "fake_pbs")
+	Check(err)
+	defer os.RemoveAll(binDir)
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+":"+origPath)
+	defer os.Setenv("PATH", origPath)
+
+	for _, tc := range []struct {
+		qstat        string
+		wantFinished bool
+		wantErr      bool
+	}{
+		{"echo '    job_state = R'", false, false},
+		{"echo '    job_state = C'", true, false},
+		{"echo '    job_state = F'", true, false},
+		{"exit 153", true, false},
+		{"echo 'qstat: Unknown Job Id 1234.fakeserver' >&2; exit 1", true, false},
+		// Transient errors, such as the server being unreachable, should
+		// not be taken as the job having finished
+		{"echo 'Connection refused' >&2; echo 'qstat: cannot connect to server fakeserver (errno=111)' >&2; exit 1", false, true},
+		{"exit 15", false, true},
+	} {
+		err = ioutil.WriteFile(filepath.Join(binDir, "qstat"), []byte("#!/bin/bash\n"+tc.qstat+"\n"), 0755)
+		Check(err)
+		finished, err := pbsJobFinished("1234.fakeserver")
+		_, transient := err.(*qstatTransientError)
+		if finished != tc.wantFinished || (err != nil) != tc.wantErr || (err != nil && !transient) {
+			t.Errorf("Expected finished %v and transient error %v for qstat script %q, got finished %v and error: %v", tc.wantFinished, tc.wantErr, tc.qstat, finished, err)
+		}
+	}
+
+	// A qstat that can not be run is not a transient error
+	err = os.Chmod(filepath.Join(binDir, "qstat"), 0644)
+	Check(err)
+	_, err = pbsJobFinished("1234.fakeserver")
+	if _, transient := err.(*qstatTransientError); err == nil || transient {
+		t.Errorf("Expected non-transient error for qstat that can not be run, got: %v", err)
+	}
+}
+
+func TestExecModePBSStatusFailures(t *testing.T) {
+	initTestLogs()
+
+	// Fake qsub, which submits nothing, and qstat, which always fails
+	binDir, err := ioutil.TempDir("", "fake_pbs")
+	Check(err)
+	defer os.RemoveAll(binDir)
+	err = ioutil.WriteFile(filepath.Join(binDir, "qsub"), []byte("#!/bin/bash\necho 1234.fakeserver\n"), 0755)
+	Check(err)
+	qstatPath := filepath.Join(binDir, "qstat")
+	err = ioutil.WriteFile(qstatPath, []byte("#!/bin/bash\necho 'cannot connect to server' >&2\nexit 1\n"), 0755)
+	Check(err)
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+":"+origPath)
+	defer os.Setenv("PATH", origPath)
+
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("pbs_job", "echo foo")
+	p.ExecMode = ExecModePBS
+	p.PBSPollInterval = time.Millisecond
+	tsk := NewTask(wf, p, "pbs_job", "echo foo", map[string]*FileIP{}, nil, nil, map[string]string{}, map[string]string{}, "", nil, 1)
+	tsk.createDirs()
+	defer os.RemoveAll(tsk.TempDir())
+
+	// Failing qstat calls should make the task fail after a number of
+	// attempts, rather than hang
+	_, err = tsk.runCommandInExecMode(tsk.Command)
+	if err == nil || !strings.Contains(err.Error(), fmt.Sprintf("in %d attempts in a row", pbsMaxStatusFailures)) {
+		t.Errorf("Expected task to fail after %d failed qstat calls, got: %v", pbsMaxStatusFailures, err)
+	}
+
+	// A qstat that can not be run should make the task fail right away
+	err = os.Remove(qstatPath)
+	Check(err)
+	os.Setenv("PATH", binDir)
+	_, err = tsk.runCommandInExecMode(tsk.Command)
+	if err == nil || !strings.Contains(err.Error(), "Could not run qstat") {
+		t.Errorf("Expected task to fail when qstat can not be run, got: %v", err)
+	}
+}
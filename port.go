@@ -1,7 +1,12 @@
 package scipipe
 
 import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
 	"strconv"
+	"strings"
 	"sync"
 )
 
@@ -19,6 +24,9 @@ type InPort struct {
 	RemotePorts map[string]*OutPort
 	ready       bool
 	closeLock   sync.Mutex
+	schema      []string
+	delimiter   rune
+	optional    bool
 }
 
 // NewInPort returns a new InPort struct
@@ -90,12 +98,38 @@ func (pt *InPort) Ready() bool {
 	return pt.ready
 }
 
+// SetOptional marks the in-port as optional, so that it may be left
+// unconnected, such as for sources or sinks that do not always need input.
+// Unconnected optional in-ports are closed when the workflow runs, so that
+// their processes do not wait for IPs on them.
+func (pt *InPort) SetOptional(optional bool) {
+	pt.optional = optional
+}
+
+// Optional tells whether the in-port may be left unconnected (see
+// SetOptional)
+func (pt *InPort) Optional() bool {
+	return pt.optional
+}
+
 // Send sends IPs to the in-port, and is supposed to be called from the remote
-// (out-) port, to send to this in-port
+// (out-) port, to send to this in-port. The workflow fails if the file of the
+// IP does not match the schema of the in-port, if any (see SetSchema).
 func (pt *InPort) Send(ip *FileIP) {
+	if err := pt.validateSchema(ip); err != nil && !pt.inDryRun() {
+		Failf("Process %s: %s\n", pt.Process().Name(), err.Error())
+	}
 	pt.Chan <- ip
 }
 
+// inDryRun tells whether the process of the in-port is part of a workflow
+// being dry run, in which case files are not created, so there is nothing to
+// validate
+func (pt *InPort) inDryRun() bool {
+	wfProc, ok := pt.process.(interface{ Workflow() *Workflow })
+	return ok && wfProc.Workflow() != nil && wfProc.Workflow().DryRun
+}
+
 // Recv receives IPs from the port
 func (pt *InPort) Recv() *FileIP {
 	return <-pt.Chan
@@ -112,6 +146,50 @@ func (pt *InPort) CloseConnection(rptName string) {
 	pt.closeLock.Unlock()
 }
 
+// SetSchema sets the expected columns of tabular files received on the
+// in-port, separated by delimiter. The header (first line) of every file
+// received on the in-port is validated against the columns when it is sent to
+// the in-port, and the workflow fails with a descriptive error on mismatch.
+// Streaming IPs, and IPs written directly to Chan rather than sent with Send,
+// are not validated.
+func (pt *InPort) SetSchema(columns []string, delimiter rune) {
+	pt.schema = columns
+	pt.delimiter = delimiter
+}
+
+// Schema returns the expected columns of files received on the in-port, or
+// nil if no schema is set
+func (pt *InPort) Schema() []string {
+	return pt.schema
+}
+
+// validateSchema checks the header of the file of ip against the schema of the
+// in-port, if any, and returns a descriptive error on mismatch
+func (pt *InPort) validateSchema(ip *FileIP) error {
+	if pt.schema == nil || ip.IsStreaming() {
+		return nil
+	}
+	f, err := os.Open(ip.Path())
+	if err != nil {
+		return errWrapf(err, "Could not open file %s to validate it against the schema of in-port %s", ip.Path(), pt.name)
+	}
+	defer f.Close()
+	header, err := bufio.NewReader(f).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return errWrapf(err, "Could not read header of file %s", ip.Path())
+	}
+	columns := strings.Split(strings.TrimRight(header, "\r\n"), string(pt.delimiter))
+	if len(columns) != len(pt.schema) {
+		return fmt.Errorf("Header of file %s does not match the schema of in-port %s: Expected %d columns %v, but found %d columns %v", ip.Path(), pt.name, len(pt.schema), pt.schema, len(columns), columns)
+	}
+	for i, col := range columns {
+		if col != pt.schema[i] {
+			return fmt.Errorf("Header of file %s does not match the schema of in-port %s: Expected column %d to be '%s', but found '%s'", ip.Path(), pt.name, i+1, pt.schema[i], col)
+		}
+	}
+	return nil
+}
+
 // ------------------------------------------------------------------------
 // OutPort
 // ------------------------------------------------------------------------
@@ -124,6 +202,9 @@ type OutPort struct {
 	process     WorkflowProcess
 	RemotePorts map[string]*InPort
 	ready       bool
+	// postProcessor is run on every non-streaming IP sent on the port, as set
+	// with Workflow.SetIPPostProcessor
+	postProcessor func(*FileIP)
 }
 
 // NewOutPort returns a new OutPort struct
@@ -199,6 +280,9 @@ func (pt *OutPort) Ready() bool {
 
 // Send sends an FileIP to all the in-ports connected to the OutPort
 func (pt *OutPort) Send(ip *FileIP) {
+	if pt.postProcessor != nil && !ip.doStream {
+		pt.postProcessor(ip)
+	}
 	for _, rpt := range pt.RemotePorts {
 		Debug.Printf("Sending on out-port %s connected to in-port %s", pt.Name(), rpt.Name())
 		rpt.Send(ip)
@@ -1,8 +1,10 @@
 package scipipe
 
 import (
+	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -63,6 +65,61 @@ func TestInPortSendRecv(t *testing.T) {
 	}
 }
 
+func TestInPortSchema(t *testing.T) {
+	initTestLogs()
+
+	inp := NewInPort("in_table")
+	inp.process = NewBogusProcess("bogus_process")
+	inp.SetSchema([]string{"id", "name", "score"}, '\t')
+
+	goodFile := "/tmp/schema_good.tsv"
+	err := ioutil.WriteFile(goodFile, []byte("id\tname\tscore\n1\tfoo\t0.5\n"), 0644)
+	Check(err)
+	if err := inp.validateSchema(NewFileIP(goodFile)); err != nil {
+		t.Errorf("Expected file with correct header to be accepted, but got error: %v", err)
+	}
+
+	badFile := "/tmp/schema_bad.tsv"
+	err = ioutil.WriteFile(badFile, []byte("id\tlabel\tscore\n1\tfoo\t0.5\n"), 0644)
+	Check(err)
+	err = inp.validateSchema(NewFileIP(badFile))
+	if err == nil {
+		t.Fatalf("Expected file with wrong header to be rejected, but it was not")
+	}
+	for _, expected := range []string{badFile, "in_table", "column 2", "'name'", "'label'"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error message to contain %s, but it was: %s", expected, err.Error())
+		}
+	}
+
+	cleanFiles(goodFile, badFile)
+}
+
+func TestInPortSchemaAbortsWorkflow(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		InitLogError()
+		wf := NewWorkflow("test_wf", 4)
+		writer := wf.NewProc("writer", "echo 'id,label' > {o:out}")
+		writer.SetOut("out", "/tmp/in_port_schema_bad.csv")
+		// The consumer reads directly from the channel of its in-port, rather
+		// than via BaseProcess, which must not bypass the validation
+		consumer := newSlowConsumer(wf, "consumer", &inFlightCounter{})
+		consumer.InPort("in").SetSchema([]string{"id", "name"}, ',')
+		consumer.InPort("in").From(writer.Out("out"))
+		wf.Run()
+		return
+	}
+	initTestLogs()
+	defer cleanFiles("/tmp/in_port_schema_bad.csv")
+
+	out := runFailingSubprocess(t)
+	if !strings.Contains(out, "does not match the schema of in-port in") {
+		t.Errorf("Expected output to describe the schema mismatch, got: %s", out)
+	}
+}
+
 func TestOutPortName(t *testing.T) {
 	initTestLogs()
 
@@ -0,0 +1,77 @@
+package scipipe
+
+// ----------------------------------------------------------------------------
+// Process status
+// ----------------------------------------------------------------------------
+
+// ProcStatus contains the numbers of tasks of a process that completed, failed
+// and were skipped, in the last run of a workflow
+type ProcStatus struct {
+	Completed int
+	// Failed is the number of tasks whose command failed. Failed tasks make
+	// the workflow fail, unless the process stops on the first success (see
+	// Process.StopOnFirstSuccess).
+	Failed int
+	// Skipped is the number of tasks that were not executed, because their
+	// outputs already existed, they were done according to a restored run
+	// state, they were not marked as failed when re-running failed tasks
+	// only, or another task of the process already succeeded
+	Skipped int
+}
+
+// Outcomes of tasks, as recorded in the process status
+const (
+	taskCompleted = iota
+	taskFailed
+	taskSkipped
+)
+
+// ProcessStatus returns the numbers of completed, failed and skipped tasks of
+// each process in the last run of the workflow, keyed by process name.
+// Processes without any tasks, such as components, are not included.
+func (wf *Workflow) ProcessStatus() map[string]ProcStatus {
+	wf.procStatusesMx.Lock()
+	defer wf.procStatusesMx.Unlock()
+	statuses := map[string]ProcStatus{}
+	for procName, status := range wf.procStatuses {
+		statuses[procName] = *status
+	}
+	return statuses
+}
+
+// resetProcessStatus clears the process status, at the start of a run
+func (wf *Workflow) resetProcessStatus() {
+	wf.procStatusesMx.Lock()
+	wf.procStatuses = map[string]*ProcStatus{}
+	wf.procStatusesMx.Unlock()
+}
+
+// recordTaskOutcome counts the outcome (taskCompleted, taskFailed or
+// taskSkipped) of task t in the status of its process
+func (wf *Workflow) recordTaskOutcome(t *Task, outcome int) {
+	if wf == nil {
+		return
+	}
+	procName := t.Name
+	if t.Process != nil {
+		procName = t.Process.Name()
+	}
+	wf.procStatusesMx.Lock()
+	defer wf.procStatusesMx.Unlock()
+	if wf.procStatuses == nil {
+		wf.procStatuses = map[string]*ProcStatus{}
+	}
+	status, ok := wf.procStatuses[procName]
+	if !ok {
+		status = &ProcStatus{}
+		wf.procStatuses[procName] = status
+	}
+	switch outcome {
+	case taskCompleted:
+		status.Completed++
+	case taskFailed:
+		status.Failed++
+	case taskSkipped:
+		status.Skipped++
+	}
+}
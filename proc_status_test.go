@@ -0,0 +1,37 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"reflect"
+	"testing"
+)
+
+func TestProcessStatus(t *testing.T) {
+	initTestLogs()
+	// The output of the first task exists already, so that it is skipped
+	err := ioutil.WriteFile("/tmp/proc_status_1.txt", []byte("1\n"), 0644)
+	Check(err)
+	defer cleanFiles("/tmp/proc_status_1.txt", "/tmp/proc_status_2.txt", "/tmp/proc_status_3.txt", "/tmp/proc_status_3.txt.tried")
+
+	wf := NewWorkflow("test_wf", 4)
+	nSource := NewParamSource(wf, "numbers", "1", "2", "3")
+	echo := wf.NewProc("echo", "echo {p:number} > {o:out}")
+	echo.InParam("number").From(nSource.Out())
+	echo.SetOut("out", "/tmp/proc_status_{p:number}.txt")
+	// The tasks for the first two files fail, which is tolerated since the
+	// process stops on the first success, which is delayed, so that the
+	// failing tasks are not cancelled
+	tryFiles := wf.NewProc("try_files", "if grep -q 3 {i:in}; then sleep 0.3 && cat {i:in} > {o:out}; else false; fi")
+	tryFiles.In("in").From(echo.Out("out"))
+	tryFiles.SetOut("out", "{i:in}.tried")
+	tryFiles.StopOnFirstSuccess = true
+	wf.Run()
+
+	expected := map[string]ProcStatus{
+		"echo":      {Completed: 2, Failed: 0, Skipped: 1},
+		"try_files": {Completed: 1, Failed: 2, Skipped: 0},
+	}
+	if status := wf.ProcessStatus(); !reflect.DeepEqual(status, expected) {
+		t.Errorf("Expected process status %v, got %v", expected, status)
+	}
+}
@@ -1,11 +1,16 @@
 package scipipe
 
 import (
+	"bytes"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
+	"sync"
+	"text/template"
+	"time"
 )
 
 // Process is the central component in SciPipe after Workflow. Processes are
@@ -20,12 +25,153 @@ type Process struct {
 	Prepend        string
 	Spawn          bool
 	PortInfo       map[string]*PortInfo
+	// ValidateOutput, if set, is run for each (non-streaming) output file of
+	// every task, after execution but before the file is moved to its final
+	// location. If it returns an error, the workflow fails.
+	ValidateOutput func(path string) error
+	// MaxTasks, if larger than zero, caps the number of tasks the process will
+	// create. Any further inputs are discarded.
+	MaxTasks int
+	// MaxMemoryMB is the max amount of memory, in megabytes, that a single
+	// task of the process is expected to use. It is used for estimating the
+	// resource usage of the workflow, in Workflow.ResourcePlan(), unless
+	// MemoryPerTask is set, and can not be larger than MemoryPerTask.
+	MaxMemoryMB int
+	// MemoryPerTask, if set, is the amount of memory each task of the process
+	// may use, as a whole number with one of the units K, M, G or T, such as
+	// "8G". It is requested for the jobs of tasks with ExecModePBS, and set as
+	// the memory limit of containers with ExecModeDocker. With ExecModeLocal,
+	// it is enforced only if EnforceMemoryPerTask is set. Malformed sizes make
+	// the workflow fail before any tasks are run. When set, it is used instead
+	// of MaxMemoryMB for estimating the resource usage of the workflow, in
+	// Workflow.ResourcePlan().
+	MemoryPerTask string
+	// EnforceMemoryPerTask makes commands run with ExecModeLocal be limited
+	// to MemoryPerTask of virtual memory, with ulimit, so that commands using
+	// more fail, rather than slowing down the machine. Note that some
+	// programs reserve much more virtual memory than they use.
+	EnforceMemoryPerTask bool
+	// EstimatedDuration is the expected time a single task of the process
+	// takes to run. It is used for finding the critical path of the
+	// workflow, in Workflow.CriticalPath().
+	EstimatedDuration time.Duration
+	// CompressionLevel is the compression level (from 0, for no compression,
+	// to 9, for best compression) used by compression helpers, such as
+	// Task.Gzip(). It defaults to DefaultCompressionLevel.
+	CompressionLevel int
+	// StopOnFirstSuccess makes the process stop as soon as any of its tasks
+	// has succeeded, cancelling all remaining tasks, and sending on only the
+	// outputs of the successful task. Failing tasks don't make the workflow
+	// fail, unless all tasks fail. This is useful for trying multiple
+	// candidate parameters, to find one that works.
+	StopOnFirstSuccess bool
+	// Priority decides which tasks get to run first, when tasks from multiple
+	// processes are waiting for the workflow's concurrency budget, where tasks
+	// of processes with higher priority run first. It defaults to 0.
+	Priority int
+	// AuditEnv makes the environment variables that tasks of the process run
+	// with be included in the audit info of their outputs. The values of
+	// secrets registered with Workflow.RegisterSecret() are redacted.
+	AuditEnv bool
+	// MaxRetries is the number of times the command of a task is re-run, if
+	// it fails, before the workflow fails. It defaults to 0, for no retries.
+	MaxRetries int
+	// RetryableExitCodes, if set, limits retries to commands failing with one
+	// of these exit codes, while commands failing with other exit codes make
+	// the workflow fail immediately. By default, commands are retried on any
+	// non-zero exit code.
+	RetryableExitCodes []int
+	// RetryableExitCode, if set, is a predicate deciding whether a command
+	// failing with a certain exit code is retried. If RetryableExitCodes is
+	// also set, exit codes have to pass both.
+	RetryableExitCode func(exitCode int) bool
+	// RetryBackoff is the time to wait before the first retry of a failed
+	// command, which is doubled for each further retry
+	RetryBackoff time.Duration
+	// Timeout, if larger than zero, is the max time the command of a task is
+	// allowed to run. Commands running longer are killed, together with any
+	// processes they started, and the workflow fails, with an error saying
+	// the command timed out. It defaults to 0, for no timeout.
+	Timeout time.Duration
+	// ExecMode is how the commands of tasks are executed. It defaults to
+	// ExecModeLocal.
+	ExecMode ExecMode
+	// PBSPollInterval is how often the status of jobs submitted to PBS is
+	// checked, with ExecModePBS. It defaults to DefaultPBSPollInterval.
+	PBSPollInterval time.Duration
+	// Image is the container image that commands are run in, with
+	// ExecModeDocker or ExecModeSingularity, such as
+	// "biocontainers/samtools:v1.9-4-deb_cv1" with Docker, or
+	// "/images/samtools.sif" with Singularity
+	Image string
+	// DockerFlags are extra flags passed to docker run with ExecModeDocker,
+	// such as []string{"--user", "1000:1000"} for creating files owned by
+	// the user running the workflow, rather than by root
+	DockerFlags []string
+	// ReuseContainer makes the tasks of a process with ExecModeDocker be run
+	// in one long-lived container, with docker exec, rather than in a new
+	// container each, which saves the startup time of containers for many
+	// small tasks. The container is started with the directories needed by
+	// the first task mounted, so inputs of later tasks must be in the same
+	// directories (or in the current directory), and is removed when the
+	// process has finished. Tasks still count towards the concurrency limits
+	// of the workflow while they are run in the container.
+	ReuseContainer bool
+	// SingularityBinary is the container runtime used with
+	// ExecModeSingularity, such as "apptainer". It defaults to
+	// DefaultSingularityBinary.
+	SingularityBinary string
+	// HealthCheckCommand, if set, is a shell command that is run once, before
+	// the process creates any tasks, to check that external services the
+	// tasks depend on, such as databases or license servers, are available.
+	// If it exits with a non-zero exit code, the workflow fails right away,
+	// rather than every task failing.
+	HealthCheckCommand string
+	// WriteManifests makes every task of the process write a manifest,
+	// listing all the files it created, including extra files that are not
+	// outputs on any out-port, next to its first output (see
+	// Task.WriteManifest)
+	WriteManifests     bool
+	umask              os.FileMode
+	umaskSet           bool
+	stage              string
+	resources          map[string]int
+	succeededTask      *Task
+	succeededTaskMx    sync.Mutex
+	containerID        string
+	containerMountDirs []string
+	containerMx        sync.Mutex
+	progressParser     func(line string) (fraction float64, ok bool)
+	expectedDuration   time.Duration
 }
 
 // ------------------------------------------------------------------------
 // Factory method(s)
 // ------------------------------------------------------------------------
 
+// ExecMode decides how the commands of the tasks of a process are executed
+type ExecMode int
+
+const (
+	// ExecModeLocal executes commands on the local machine
+	ExecModeLocal ExecMode = iota
+	// ExecModePBS submits commands as jobs to a PBS/Torque cluster, with qsub
+	// (see Process.PBSPollInterval). The Timeout of the process is used as
+	// the walltime of the jobs.
+	ExecModePBS
+	// ExecModeDocker runs commands in containers of the image set in
+	// Process.Image, with docker run, with the directories of all inputs and
+	// outputs bind-mounted at the same paths as on the host (see also
+	// Process.DockerFlags)
+	ExecModeDocker
+	// ExecModeSingularity runs commands in containers of the image set in
+	// Process.Image, with singularity exec (or the runtime set in
+	// Process.SingularityBinary), with the directories of all inputs and
+	// outputs bound at the same paths as on the host. Unlike Docker, it is
+	// usually allowed on shared HPC nodes.
+	ExecModeSingularity
+)
+
 // NewProc returns a new Process, and initializes its ports based on the
 // command pattern.
 func NewProc(workflow *Workflow, name string, cmd string) *Process {
@@ -34,11 +180,13 @@ func NewProc(workflow *Workflow, name string, cmd string) *Process {
 			workflow,
 			name,
 		),
-		CommandPattern: cmd,
-		PathFuncs:      make(map[string]func(*Task) string),
-		Spawn:          true,
-		CoresPerTask:   1,
-		PortInfo:       map[string]*PortInfo{},
+		CommandPattern:   cmd,
+		PathFuncs:        make(map[string]func(*Task) string),
+		Spawn:            true,
+		CoresPerTask:     1,
+		CompressionLevel: DefaultCompressionLevel,
+		PortInfo:         map[string]*PortInfo{},
+		resources:        map[string]int{},
 	}
 	workflow.AddProc(p)
 	p.initPortsFromCmdPattern(cmd, nil)
@@ -48,23 +196,27 @@ func NewProc(workflow *Workflow, name string, cmd string) *Process {
 
 // PortInfo is a container for various information about process ports
 type PortInfo struct {
-	portType  string
-	extension string
-	doStream  bool
-	join      bool
-	joinSep   string
+	portType   string
+	extension  string
+	doStream   bool
+	join       bool
+	joinSep    string
+	collectAll bool
+	quote      bool
 }
 
 // initPortsFromCmdPattern is a helper function for NewProc, that sets up in-
 // and out-ports based on the shell command pattern used to create the Process.
 // Ports are set up in this way:
 // `{i:PORTNAME}` specifies an in-port
+// `{i*:PORTNAME}` specifies an in-port, on which all IPs are received before
+// creating a task, and expanded to space-separated quoted paths
 // `{o:PORTNAME}` specifies an out-port
 // `{os:PORTNAME}` specifies an out-port that streams via a FIFO file
 // `{p:PORTNAME}` a "parameter (in-)port", which means a port where parameters can be "streamed"
 func (p *Process) initPortsFromCmdPattern(cmd string, params map[string]string) {
 	// Find in/out port names and params and set up ports
-	r := getShellCommandPlaceHolderRegex()
+	r := p.workflow.placeHolderRegex()
 	ms := r.FindAllStringSubmatch(cmd, -1)
 
 	for _, m := range ms {
@@ -73,7 +225,19 @@ func (p *Process) initPortsFromCmdPattern(cmd string, params map[string]string)
 		splitParts := strings.Split(portRest, "|")
 		portName := splitParts[0]
 
-		p.PortInfo[portName] = &PortInfo{portType: portType}
+		// The same port can occur in multiple placeholders (such as with
+		// different modifiers), so make sure not to overwrite earlier info
+		if _, ok := p.PortInfo[portName]; !ok {
+			p.PortInfo[portName] = &PortInfo{portType: strings.TrimSuffix(portType, "*")}
+		}
+		// All IPs received on in-ports of {i*:PORTNAME} placeholders are
+		// collected in a sub-stream, to be joined into one string
+		if portType == "i*" {
+			p.PortInfo[portName].collectAll = true
+			p.PortInfo[portName].join = true
+			p.PortInfo[portName].joinSep = " "
+			p.PortInfo[portName].quote = true
+		}
 
 		for _, part := range splitParts[1:] {
 			// If the |-separated part starts with a dot, treat it as a
@@ -221,6 +385,10 @@ func (p *Process) OutParam(portName string) *OutParamPort {
 // {i:inport_name}
 // {p:param_name}
 // {t:tag_name}
+// Collecting {i*:inport_name} placeholders can not be used, since there is no
+// single path for them, and make the workflow fail.
+// Placeholders can be followed by |-separated modifiers, as described for
+// applyPathModifiers, such as: {i:foo|basename}
 // An example might be: {i:foo}.replace_with_{p:replacement}.txt
 // ... given that the process contains an in-port named 'foo', and a parameter
 // named 'replacement'.
@@ -228,10 +396,17 @@ func (p *Process) OutParam(portName string) *OutParamPort {
 // This allows to create out-ports for filenames that are created without explicitly
 // stating a filename on the commandline, such as when only submitting a prefix.
 func (p *Process) SetOut(outPortName string, pathPattern string) {
+	// Collecting in-ports receive many IPs per task, so they have no single
+	// path to name outputs after
+	for _, match := range p.workflow.placeHolderRegex().FindAllStringSubmatch(pathPattern, -1) {
+		if match[1] == "i*" {
+			Failf("%s: Placeholder %s in path pattern '%s' of out-port %s refers to a collecting in-port, which can not be used in path patterns. Use a parameter or tag, or a static path, instead.\n", p.Name(), match[0], pathPattern, outPortName)
+		}
+	}
 	p.SetOutFunc(outPortName, func(t *Task) string {
 		path := pathPattern // Avoiding reusing the same variable in multiple instances of this func
 
-		r := getShellCommandPlaceHolderRegex()
+		r := p.workflow.placeHolderRegex()
 		matches := r.FindAllStringSubmatch(path, -1)
 		for _, match := range matches {
 			var replacement string
@@ -260,38 +435,7 @@ func (p *Process) SetOut(outPortName string, pathPattern string) {
 				Fail("Replace failed for placeholder ", portName, " for path patterh '", path, "'")
 			}
 
-			if len(restParts) > 0 {
-				substPtn := regexp.MustCompile("s\\/([^\\/]+)\\/([^\\/]*)\\/")
-				trimEndPtn := regexp.MustCompile("%(.*)")
-				basenamePtn := regexp.MustCompile(`.*\/`)
-
-				for _, restPart := range restParts {
-					// If the |-separated part looks like a search/replace
-					// pattern on the form of: s/SEARCHSTRING/REPLACESTRING/
-					// then execute the replacement.
-					if substPtn.MatchString(restPart) {
-						mbits := substPtn.FindStringSubmatch(restPart)
-						search := mbits[1]
-						replace := mbits[2]
-						replacement = strings.Replace(replacement, search, replace, 1)
-					}
-					// If the |-separated part starts iwth a %-character, then
-					// trim everything following that character, from the end
-					// of the path.
-					if trimEndPtn.MatchString(restPart) {
-						mbits := trimEndPtn.FindStringSubmatch(restPart)
-						end := mbits[1]
-						if end == replacement[len(replacement)-len(end):] {
-							replacement = replacement[:len(replacement)-len(end)]
-						}
-					}
-					// If the |-separated part is "basename", then remove all leading
-					// folders up to the actual file name.
-					if restPart == "basename" {
-						replacement = basenamePtn.ReplaceAllString(replacement, "")
-					}
-				}
-			}
+			replacement = applyPathModifiers(replacement, restParts)
 
 			// Replace placeholder with concrete value
 			path = strings.Replace(path, placeHolder, replacement, -1)
@@ -300,8 +444,41 @@ func (p *Process) SetOut(outPortName string, pathPattern string) {
 	})
 }
 
+// applyPathModifiers applies the |-separated modifiers following the port name
+// in a placeholder, to the path (or value) it is replaced with. Available
+// modifiers are:
+// s/SEARCHSTRING/REPLACESTRING/ - replaces the first occurrence of SEARCHSTRING
+// %SUFFIX - trims SUFFIX from the end of the path, if it ends with it
+// basename - removes all leading folders up to the actual file name
+// dir - removes the file name, keeping only the leading folders
+// noext - removes the file extension (the part from the last dot)
+// Other parts, such as file extensions for out-ports, are ignored.
+func applyPathModifiers(path string, modifiers []string) string {
+	substPtn := regexp.MustCompile("s\\/([^\\/]+)\\/([^\\/]*)\\/")
+	for _, modifier := range modifiers {
+		switch {
+		case substPtn.MatchString(modifier):
+			mbits := substPtn.FindStringSubmatch(modifier)
+			path = strings.Replace(path, mbits[1], mbits[2], 1)
+		case strings.HasPrefix(modifier, "%"):
+			path = strings.TrimSuffix(path, modifier[1:])
+		case modifier == "basename":
+			path = filepath.Base(path)
+		case modifier == "dir":
+			path = filepath.Dir(path)
+		case modifier == "noext":
+			path = strings.TrimSuffix(path, filepath.Ext(path))
+		}
+	}
+	return path
+}
+
 // SetOutFunc takes a function which produces a file path based on data
-// available in *Task, such as concrete file paths and parameter values,
+// available in *Task, such as concrete file paths and parameter values. The
+// in-IPs, parameters and tags of the task are all set before the function is
+// called, so they can be read with Task.InPath(), Task.Param() and
+// Task.Tag(), or the Task.Params map, as in:
+// p.SetOutFunc("out", func(t *Task) string { return "out.t" + t.Param("threshold") + ".txt" })
 func (p *Process) SetOutFunc(outPortName string, pathFmtFunc func(task *Task) (path string)) {
 	if _, ok := p.outPorts[outPortName]; !ok {
 		p.InitOutPort(p, outPortName)
@@ -309,6 +486,179 @@ func (p *Process) SetOutFunc(outPortName string, pathFmtFunc func(task *Task) (p
 	p.PathFuncs[outPortName] = pathFmtFunc
 }
 
+// SetPathMirror sets the path of the out-port outPortName to mirror the path of
+// the input on the in-port inPortName, such that an input path under srcRoot
+// gives an output path at the same relative location under destRoot, with the
+// intermediate directories preserved. For example, with srcRoot "data" and
+// destRoot "results", the input data/batch1/sample.txt gives the output
+// results/batch1/sample.txt. Any missing directories are created when the
+// output is moved in place. The workflow fails for inputs not under srcRoot.
+func (p *Process) SetPathMirror(inPortName string, outPortName string, srcRoot string, destRoot string) {
+	if _, ok := p.inPorts[inPortName]; !ok {
+		Failf("%s: No in-port named '%s' to mirror the path of, for out-port '%s'\n", p.Name(), inPortName, outPortName)
+	}
+	p.SetOutFunc(outPortName, func(t *Task) string {
+		return mirrorPath(t.InPath(inPortName), srcRoot, destRoot)
+	})
+}
+
+// mirrorPath returns the path at the same location under destRoot as path is
+// under srcRoot
+func mirrorPath(path string, srcRoot string, destRoot string) string {
+	relPath, err := filepath.Rel(srcRoot, path)
+	if err != nil || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		Failf("Path %s is not under the source root %s, so can't be mirrored into %s\n", path, srcRoot, destRoot)
+	}
+	return filepath.Join(destRoot, relPath)
+}
+
+// SetPathTemplate sets the path of the out-port outPortName to be formatted
+// with the Go text/template tmpl (see https://golang.org/pkg/text/template),
+// for output names combining multiple parameter values and inputs. The
+// template is executed for each task, with the following fields:
+//
+//	{{.Params}}   The parameter values of the task, as {{.Params.genome}}
+//	{{.InPaths}}  The paths of the inputs of the task, as {{.InPaths.reads}}
+//	{{.Tags}}     The tags of the task, as {{.Tags.sample}}
+//
+// and the functions basename, dirname and noext, such as in
+// {{.InPaths.reads | basename | noext}}.{{.Params.genome}}.bam
+// Templates that can not be parsed make the workflow fail right away, while
+// referring to parameters, inputs or tags that the task does not have makes
+// it fail when the task is created.
+func (p *Process) SetPathTemplate(outPortName string, tmpl string) {
+	pathTmpl, err := template.New(outPortName).Option("missingkey=error").Funcs(pathTemplateFuncs).Parse(tmpl)
+	if err != nil {
+		Failf("%s: Could not parse path template for out-port '%s': %s\n", p.Name(), outPortName, err.Error())
+	}
+	p.SetOutFunc(outPortName, func(t *Task) string {
+		data := pathTemplateData{
+			Params:  t.Params,
+			InPaths: map[string]string{},
+			Tags:    t.Tags,
+		}
+		for ipName, ip := range t.InIPs {
+			data.InPaths[ipName] = ip.Path()
+		}
+		path := &bytes.Buffer{}
+		if err := pathTmpl.Execute(path, data); err != nil {
+			Failf("%s: Could not format path for out-port '%s' with template '%s': %s\n", p.Name(), outPortName, tmpl, err.Error())
+		}
+		return path.String()
+	})
+}
+
+// pathTemplateData is the data that path templates set with SetPathTemplate
+// are executed with
+type pathTemplateData struct {
+	Params  map[string]string
+	InPaths map[string]string
+	Tags    map[string]string
+}
+
+// pathTemplateFuncs are the functions available in path templates set with
+// SetPathTemplate
+var pathTemplateFuncs = template.FuncMap{
+	"basename": filepath.Base,
+	"dirname":  filepath.Dir,
+	"noext": func(path string) string {
+		return strings.TrimSuffix(path, filepath.Ext(path))
+	},
+}
+
+// SetProgressParser sets a function for parsing the progress of tasks of the
+// process, from the lines their commands write to stderr, such as for tools
+// that print their percent complete. For lines that contain progress, parser
+// should return the completed fraction, from 0.0 to 1.0, and true. Parsed
+// progress is reported to the progress handler of the workflow (see
+// Workflow.SetProgressHandler).
+func (p *Process) SetProgressParser(parser func(line string) (fraction float64, ok bool)) {
+	p.progressParser = parser
+}
+
+// SetExpectedDuration sets the duration that tasks of the process are expected
+// to finish within, such as for a service level agreement (SLA). A warning is
+// logged for tasks running longer than that, without failing them. Whether
+// tasks exceeded it is also recorded in the task statistics of the workflow
+// (see TaskStats.SLABreached).
+func (p *Process) SetExpectedDuration(d time.Duration) {
+	p.expectedDuration = d
+}
+
+// SetUmask sets the umask that the commands of tasks of the process are run
+// with, such as 0002 for files writable by the group, or 0000 for files
+// writable by everyone, which decides the permissions of the files they
+// create. It is set in the shell running each command, so that concurrent
+// tasks of other processes are not affected. By default, commands are run
+// with the umask of the workflow.
+func (p *Process) SetUmask(umask os.FileMode) {
+	if umask > 0777 {
+		Failf("%s: Umask must be between 0000 and 0777, but was %04o\n", p.Name(), umask)
+	}
+	p.umask = umask
+	p.umaskSet = true
+}
+
+// isRetryable tells whether the command of a task of the process, that failed
+// with err, should be retried, based on its exit code
+func (p *Process) isRetryable(err error) bool {
+	// Both commands run locally and jobs submitted to clusters have exit codes
+	exitErr, ok := err.(interface{ ExitCode() int })
+	if !ok {
+		return false
+	}
+	exitCode := exitErr.ExitCode()
+	if p.RetryableExitCode != nil && !p.RetryableExitCode(exitCode) {
+		return false
+	}
+	if p.RetryableExitCodes == nil {
+		return true
+	}
+	for _, code := range p.RetryableExitCodes {
+		if exitCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// retryBackoff returns the time to wait before retrying a command that failed
+// on attempt number attempt (counting from 1)
+func (p *Process) retryBackoff(attempt int) time.Duration {
+	return p.RetryBackoff * time.Duration(1<<uint(attempt-1))
+}
+
+// ------------------------------------------------------------------------
+// Main API methods: Stages
+// ------------------------------------------------------------------------
+
+// SetStage puts the process in the stage with name stageName, for grouping
+// processes into logical stages (such as QC or alignment) in logs and
+// statistics
+func (p *Process) SetStage(stageName string) {
+	p.stage = stageName
+}
+
+// Stage returns the name of the stage the process belongs to, or an empty
+// string if it has not been put in any stage
+func (p *Process) Stage() string {
+	return p.stage
+}
+
+// ------------------------------------------------------------------------
+// Main API methods: Resources
+// ------------------------------------------------------------------------
+
+// RequireResource makes each task of the process require amount units of the
+// shared resource named resourceName, defined with Workflow.DefineResource(),
+// while it executes. Tasks wait until enough units are available.
+func (p *Process) RequireResource(resourceName string, amount int) {
+	if amount < 1 {
+		Failf("%s: Amount of resource '%s' required must be at least 1, got %d\n", p.Name(), resourceName, amount)
+	}
+	p.resources[resourceName] = amount
+}
+
 // ------------------------------------------------------------------------
 // Run method
 // ------------------------------------------------------------------------
@@ -320,10 +670,28 @@ func (p *Process) SetOutFunc(outPortName string, pathFmtFunc func(task *Task) (p
 // Task.Execute, not here.
 func (p *Process) Run() {
 	defer p.CloseOutPorts()
+	defer p.removeReusedContainer()
 	// Check that CoresPerTask is a sane number
 	if p.CoresPerTask > cap(p.workflow.concurrentTasks) {
 		Failf("%s: CoresPerTask (%d) can't be greater than maxConcurrentTasks of workflow (%d)\n", p.Name(), p.CoresPerTask, cap(p.workflow.concurrentTasks))
 	}
+	// Check that required resources are defined, with enough capacity
+	for resName, amount := range p.resources {
+		res, ok := p.workflow.resources[resName]
+		if !ok {
+			Failf("%s: Required resource '%s' is not defined in workflow (use Workflow.DefineResource())\n", p.Name(), resName)
+		}
+		if amount > cap(res.slots) {
+			Failf("%s: Required amount (%d) of resource '%s' can't be greater than its capacity (%d)\n", p.Name(), amount, resName, cap(res.slots))
+		}
+	}
+	if !p.workflow.executesTasks(p.Name()) {
+		p.sendExistingOutputs()
+		return
+	}
+	if err := p.runHealthCheck(); err != nil {
+		Failf("%s: Health check failed, so not running any tasks: %s\n", p.Name(), err.Error())
+	}
 
 	// Using a slice to store unprocessed tasks allows us to receive tasks as
 	// they are produced and to maintain the correct order of IPs. This select
@@ -334,13 +702,19 @@ func (p *Process) Run() {
 	startedTasks := taskQueue{}
 
 	var nextTask *Task
+	tasksCreated := 0
 	tasks := p.createTasks()
 	for tasks != nil || len(startedTasks) > 0 {
 		select {
 		case t, ok := <-tasks:
 			if !ok {
 				tasks = nil
+			} else if p.StopOnFirstSuccess && p.hasSucceeded() {
+				// Keep receiving tasks, for upstream processes to finish, but
+				// don't execute them
+				p.workflow.Logger().Printf("| %-32s | Another task already succeeded, so not executing task %s\n", p.Name(), t.ID())
 			} else {
+				tasksCreated++
 				// Sending FIFOs for the task
 				for oname, oip := range t.OutIPs {
 					if oip.doStream {
@@ -359,8 +733,9 @@ func (p *Process) Run() {
 			}
 		case <-startedTasks.NextTaskDone():
 			nextTask, startedTasks = startedTasks[0], startedTasks[1:]
+			sendOutputs := !p.StopOnFirstSuccess || nextTask == p.getSucceededTask()
 			for oname, oip := range nextTask.OutIPs {
-				if !oip.doStream { // Streaming (FIFO) outputs have been sent earlier
+				if !oip.doStream && sendOutputs { // Streaming (FIFO) outputs have been sent earlier
 					p.Out(oname).Send(oip)
 				}
 				// Remove any FIFO file
@@ -370,6 +745,72 @@ func (p *Process) Run() {
 			}
 		}
 	}
+	if p.StopOnFirstSuccess && tasksCreated > 0 && !p.hasSucceeded() {
+		Failf("%s: None of the %d tasks succeeded\n", p.Name(), tasksCreated)
+	}
+}
+
+// sendExistingOutputs sends IPs for the outputs of all tasks of the process,
+// without executing them, when running the workflow from certain processes
+// (see Workflow.RunFrom). If the process is one of those, the workflow fails,
+// before any IPs are sent, if any of the outputs do not exist.
+func (p *Process) sendExistingOutputs() {
+	tasks := []*Task{}
+	missing := []string{}
+	for t := range p.createTasks() {
+		tasks = append(tasks, t)
+		for _, oipName := range sortedFileIPMapKeys(t.OutIPs) {
+			if oip := t.OutIPs[oipName]; !oip.doStream && !oip.Exists() {
+				missing = append(missing, oip.Path())
+			}
+		}
+	}
+	if p.workflow.runFromProcs[p.Name()] && len(missing) > 0 {
+		Failf("%s: Can't run workflow from process, since %d of its outputs do not exist:\n%s\n", p.Name(), len(missing), strings.Join(missing, "\n"))
+	}
+	p.workflow.Logger().Printf("| %-32s | Not executing %d tasks when running workflow with RunFrom(), so just sending their outputs\n", p.Name(), len(tasks))
+	for _, t := range tasks {
+		for _, oname := range sortedFileIPMapKeys(t.OutIPs) {
+			p.Out(oname).Send(t.OutIPs[oname])
+		}
+	}
+}
+
+// runHealthCheck runs the health check command of the process, if set, and
+// returns an error with its output if it fails
+func (p *Process) runHealthCheck() error {
+	if p.HealthCheckCommand == "" {
+		return nil
+	}
+	p.workflow.Logger().Printf("| %-32s | Running health check: %s\n", p.Name(), p.HealthCheckCommand)
+	out, err := exec.Command("bash", "-c", p.HealthCheckCommand).CombinedOutput()
+	if err != nil {
+		return errWrapf(err, "Command: %s\nOutput: %s", p.HealthCheckCommand, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// setSucceeded records t as the first successful task of the process, unless
+// another task has already succeeded
+func (p *Process) setSucceeded(t *Task) {
+	p.succeededTaskMx.Lock()
+	if p.succeededTask == nil {
+		p.succeededTask = t
+	}
+	p.succeededTaskMx.Unlock()
+}
+
+// getSucceededTask returns the first successful task of the process, or nil
+// if no task has succeeded yet
+func (p *Process) getSucceededTask() *Task {
+	p.succeededTaskMx.Lock()
+	defer p.succeededTaskMx.Unlock()
+	return p.succeededTask
+}
+
+// hasSucceeded returns true if any task of the process has succeeded
+func (p *Process) hasSucceeded() bool {
+	return p.getSucceededTask() != nil
 }
 
 // createTasks is a helper method for Run that creates tasks based on incoming
@@ -384,16 +825,29 @@ func (p *Process) createTasks() (ch chan *Task) {
 
 		inPortsOpen := true
 		paramPortsOpen := true
+		tasksCreated := 0
+		// IPs on in-ports of {i*:PORTNAME} placeholders are all received up
+		// front, to be used in every task
+		collectedIPs := p.receiveAllOnCollectingInPorts()
+		collectingPorts := map[string]bool{}
+		for iname := range collectedIPs {
+			collectingPorts[iname] = true
+		}
 		for {
 			// Tags need to be per Task, otherwise they are overwritten by future IPs
 			tags := map[string]string{}
 			// Only read on in-ports if we have any
-			if len(p.inPorts) > 0 {
-				inIPs, inPortsOpen = p.receiveOnInPorts()
+			if len(p.inPorts) > len(collectedIPs) {
+				inIPs, inPortsOpen = p.receiveOnInPorts(collectingPorts)
 				// If in-port is closed, that means we got the last params on last iteration, so break
 				if !inPortsOpen {
 					break
 				}
+			} else {
+				inIPs = map[string]*FileIP{}
+			}
+			for iname, ips := range collectedIPs {
+				inIPs[iname] = newSubStreamIP(ips)
 			}
 			// Only read on param in-ports if we have any
 			if len(p.inParamPorts) > 0 {
@@ -412,9 +866,19 @@ func (p *Process) createTasks() (ch chan *Task) {
 
 			// Create task and send on the channel we are about to return
 			ch <- NewTask(p.workflow, p, p.Name(), p.CommandPattern, inIPs, p.PathFuncs, p.PortInfo, params, tags, p.Prepend, p.CustomExecute, p.CoresPerTask)
+			tasksCreated++
+
+			// If we have reached the max number of tasks, discard any further
+			// inputs, so that upstream processes can finish
+			if p.MaxTasks > 0 && tasksCreated >= p.MaxTasks {
+				p.workflow.Logger().Printf("| %-32s | Reached max number of tasks (%d), discarding further inputs\n", p.Name(), p.MaxTasks)
+				p.drainInPorts()
+				break
+			}
 
-			// If we have no in-ports nor param in-ports, we should break after the first iteration
-			if len(p.inPorts) == 0 && len(p.inParamPorts) == 0 {
+			// If we have no in-ports (other than collecting ones) nor param
+			// in-ports, we should break after the first iteration
+			if len(p.inPorts) == len(collectedIPs) && len(p.inParamPorts) == 0 {
 				break
 			}
 		}
@@ -422,6 +886,52 @@ func (p *Process) createTasks() (ch chan *Task) {
 	return ch
 }
 
+// receiveAllOnCollectingInPorts receives all IPs on the in-ports of
+// {i*:PORTNAME} placeholders, until they are closed, and returns them by port
+// name
+func (p *Process) receiveAllOnCollectingInPorts() map[string][]*FileIP {
+	collected := map[string][]*FileIP{}
+	mx := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for portName, portInfo := range p.PortInfo {
+		if !portInfo.collectAll {
+			continue
+		}
+		collected[portName] = []*FileIP{}
+		wg.Add(1)
+		// Receive concurrently on all ports, so that upstream processes
+		// sending to multiple of them don't block
+		go func(portName string, inPort *InPort) {
+			defer wg.Done()
+			ips := []*FileIP{}
+			for ip := range inPort.Chan {
+				ips = append(ips, ip)
+			}
+			mx.Lock()
+			collected[portName] = ips
+			mx.Unlock()
+		}(portName, p.In(portName))
+	}
+	wg.Wait()
+	return collected
+}
+
+// newSubStreamIP returns a new IP with ips on its (closed) sub-stream
+func newSubStreamIP(ips []*FileIP) *FileIP {
+	subStream := NewInPort("in_substream")
+	subStream.Chan = make(chan *FileIP, len(ips))
+	for _, ip := range ips {
+		subStream.Chan <- ip
+	}
+	close(subStream.Chan)
+	// The IP has no path of its own, so NewFileIP() can't be used
+	return &FileIP{
+		BaseIP:    NewBaseIP(""),
+		lock:      &sync.Mutex{},
+		SubStream: subStream,
+	}
+}
+
 type taskQueue []*Task
 
 // NextTaskDone allows us to wait for the next task to be done if it's
@@ -1,10 +1,15 @@
 package scipipe
 
 import (
+	"bytes"
 	"io/ioutil"
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 func TestNewProc(t *testing.T) {
@@ -39,6 +44,9 @@ func TestSetOut(t *testing.T) {
 		"{i:foo|%.txt}.bar.txt":    "data/foo.bar.txt", // Bash style strip from end of string
 		"{i:foo|%oo.txt}.txt":      "data/f.txt",       // Bash style strip from end of string
 		"{i:foo|basename}":         "foo.txt",
+		"{i:foo|dir}":              "data",
+		"{i:foo|noext}.bar.txt":    "data/foo.bar.txt",
+		"{i:foo|basename|noext}":   "foo",
 	}
 	for pathPattern, expectedPath := range inputsAndOutputs {
 		// Set a path format for the "bar" out-port
@@ -53,6 +61,29 @@ func TestSetOut(t *testing.T) {
 	}
 }
 
+func TestSetPathTemplate(t *testing.T) {
+	wf := NewWorkflow("test_wf", 16)
+	p := wf.NewProc("align", "align {i:reads} {i:ref} > {o:bam} # {p:genome} {p:mode}")
+
+	mockTask := NewTask(wf, p, "align_task", "",
+		map[string]*FileIP{"reads": NewFileIP("data/sample_1.fq"), "ref": NewFileIP("refs/hg38.fa")},
+		nil, nil, map[string]string{"genome": "hg38", "mode": "fast"}, map[string]string{"sample": "s1"}, "", nil, 1)
+
+	templatesAndPaths := map[string]string{
+		"{{.InPaths.reads}}.bam": "data/sample_1.fq.bam",
+		"{{.InPaths.reads | dirname}}/{{.InPaths.reads | basename | noext}}.bam": "data/sample_1.bam",
+		"{{.InPaths.reads | noext}}.{{.InPaths.ref | basename | noext}}.bam":     "data/sample_1.hg38.bam",
+		"out/{{.Tags.sample}}.{{.Params.genome}}_{{.Params.mode}}.bam":           "out/s1.hg38_fast.bam",
+	}
+	for tmpl, expectedPath := range templatesAndPaths {
+		p.SetPathTemplate("bam", tmpl)
+		actualPath := p.PathFuncs["bam"](mockTask)
+		if actualPath != expectedPath {
+			t.Errorf("Wrong path for template %s. Got: %s Expected: %s", tmpl, actualPath, expectedPath)
+		}
+	}
+}
+
 func TestDefaultPattern(t *testing.T) {
 	wf := NewWorkflow("test_wf", 16)
 	p := wf.NewProc("cat_foo", "cat {i:foo} > {o:bar|.txt} # {p:p1}")
@@ -129,3 +160,295 @@ func TestProcTaskBuffering(t *testing.T) {
 		wf.Run()
 	}
 }
+
+func TestMaxTasks(t *testing.T) {
+	initTestLogs()
+
+	// Use more inputs than the port buffer size, to make sure that upstream
+	// does not block when inputs are discarded
+	numbers := []string{}
+	for i := 0; i < BUFSIZE+10; i++ {
+		numbers = append(numbers, strconv.Itoa(i))
+	}
+
+	wf := NewWorkflow("test_wf", 4)
+	nSource := NewParamSource(wf, "numbers", numbers...)
+
+	makeFiles := wf.NewProc("make_files", "echo {p:number} > {o:out}")
+	makeFiles.InParam("number").From(nSource.Out())
+	makeFiles.SetOut("out", "/tmp/maxtasks_{p:number}.txt")
+	makeFiles.MaxTasks = 3
+
+	mx := sync.Mutex{}
+	received := []string{}
+	counter := wf.NewProc("counter", "# {i:in}")
+	counter.In("in").From(makeFiles.Out("out"))
+	counter.CustomExecute = func(tsk *Task) {
+		mx.Lock()
+		received = append(received, tsk.InPath("in"))
+		mx.Unlock()
+	}
+
+	wf.Run()
+
+	if len(received) != 3 {
+		t.Errorf("Expected 3 tasks to be created, but downstream received %d files: %v", len(received), received)
+	}
+	cleanFiles(received...)
+}
+
+func TestStopOnFirstSuccess(t *testing.T) {
+	initTestLogs()
+
+	// Run one task at a time, so that only one task can succeed
+	wf := NewWorkflow("test_wf", 1)
+	nSource := NewParamSource(wf, "numbers", "1", "2", "3", "4", "5")
+
+	tryNumbers := wf.NewProc("try_numbers", "[ {p:number} -ge 3 ] && echo {p:number} > {o:out}")
+	tryNumbers.InParam("number").From(nSource.Out())
+	tryNumbers.SetOut("out", "/tmp/stop_on_first_success_{p:number}.txt")
+	tryNumbers.StopOnFirstSuccess = true
+
+	mx := sync.Mutex{}
+	received := []string{}
+	counter := wf.NewProc("counter", "# {i:in}")
+	counter.In("in").From(tryNumbers.Out("out"))
+	counter.CustomExecute = func(tsk *Task) {
+		mx.Lock()
+		received = append(received, tsk.InPath("in"))
+		mx.Unlock()
+	}
+
+	wf.Run()
+
+	if len(received) != 1 {
+		t.Errorf("Expected downstream to receive the output of exactly one task, but got %d: %v", len(received), received)
+	}
+	existing := []string{}
+	for _, n := range []string{"1", "2", "3", "4", "5"} {
+		path := "/tmp/stop_on_first_success_" + n + ".txt"
+		if _, err := os.Stat(path); err == nil {
+			existing = append(existing, path)
+		}
+	}
+	if len(existing) != 1 || existing[0] < "/tmp/stop_on_first_success_3.txt" {
+		t.Errorf("Expected only the output of one succeeding task to exist, but found: %v", existing)
+	}
+	cleanFiles(existing...)
+}
+
+func TestCollectAllInputsPlaceholder(t *testing.T) {
+	initTestLogs()
+
+	for _, numbers := range [][]string{{"1", "2"}, {"1", "2", "3", "4"}} {
+		wf := NewWorkflow("test_wf", 4)
+		nSource := NewParamSource(wf, "numbers", numbers...)
+
+		makeFiles := wf.NewProc("make_files", "echo {p:number} > {o:out}")
+		makeFiles.InParam("number").From(nSource.Out())
+		makeFiles.SetOut("out", "/tmp/collect_all_{p:number}.txt")
+
+		catAll := wf.NewProc("cat_all", "cat {i*:in} | sort -n | tr -d '\\n' > {o:out}")
+		catAll.In("in").From(makeFiles.Out("out"))
+		catAll.SetOut("out", "/tmp/collect_all.txt")
+
+		wf.Run()
+
+		out, err := ioutil.ReadFile("/tmp/collect_all.txt")
+		Check(err)
+		expected := ""
+		for _, n := range numbers {
+			expected += n
+		}
+		if string(out) != expected {
+			t.Errorf("Expected all %d inputs to be concatenated into '%s', but got '%s'", len(numbers), expected, string(out))
+		}
+
+		files := []string{"/tmp/collect_all.txt"}
+		for _, n := range numbers {
+			files = append(files, "/tmp/collect_all_"+n+".txt")
+		}
+		cleanFiles(files...)
+	}
+}
+
+func TestSetOutCollectAllInputsPlaceholder(t *testing.T) {
+	// Failing the workflow exits the process, so the out-port is set in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		InitLogError()
+		wf := NewWorkflow("test_wf", 4)
+		p := wf.NewProc("cat_all", "cat {i*:in} > {o:out}")
+		p.SetOut("out", "{i*:in}.merged")
+		return
+	}
+
+	out := runFailingSubprocess(t)
+	if !strings.Contains(out, "refers to a collecting in-port") {
+		t.Errorf("Expected output to describe the invalid placeholder, got: %s", out)
+	}
+}
+
+func TestFormatCommandCollectAllInputs(t *testing.T) {
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("tool", "tool {i*:in} -o {o:out}")
+	if !p.PortInfo["in"].collectAll {
+		t.Fatalf("Expected in-port of {i*:in} placeholder to collect all inputs")
+	}
+
+	subStreamIPs := map[string][]*FileIP{"in": {NewFileIP("in1.txt"), NewFileIP("/data/in 2.txt"), NewFileIP("in3.txt")}}
+	inIPs := map[string]*FileIP{"in": newSubStreamIP(nil)}
+	outIPs := map[string]*FileIP{"out": NewFileIP("out.txt")}
+	actual := formatCommand(getShellCommandPlaceHolderRegex("{", "}"), p.CommandPattern, p.PortInfo, inIPs, subStreamIPs, outIPs, nil, nil, "")
+	expected := "tool '../in1.txt' '/data/in 2.txt' '../in3.txt' -o out.txt"
+	if actual != expected {
+		t.Errorf("Wrong command formatted. Got: '%s' Expected: '%s'", actual, expected)
+	}
+}
+
+func TestSetPathMirror(t *testing.T) {
+	initTestLogs()
+
+	inDir := "/tmp/path_mirror_src/batch1/lane2"
+	err := os.MkdirAll(inDir, 0777)
+	Check(err)
+	inPath := inDir + "/sample.txt"
+	err = ioutil.WriteFile(inPath, []byte("sample\n"), 0644)
+	Check(err)
+	defer os.RemoveAll("/tmp/path_mirror_src")
+	defer os.RemoveAll("/tmp/path_mirror_dest")
+
+	wf := NewWorkflow("test_wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	upper := wf.NewProc("upper", "tr a-z A-Z < {i:in} > {o:out}")
+	upper.In("in").From(src.Out())
+	upper.SetPathMirror("in", "out", "/tmp/path_mirror_src", "/tmp/path_mirror_dest")
+	wf.Run()
+
+	outPath := "/tmp/path_mirror_dest/batch1/lane2/sample.txt"
+	out, err := ioutil.ReadFile(outPath)
+	if err != nil {
+		t.Fatalf("Could not read mirrored output file %s: %v", outPath, err)
+	}
+	if string(out) != "SAMPLE\n" {
+		t.Errorf("Expected mirrored output file to contain 'SAMPLE\\n', got '%s'", string(out))
+	}
+}
+
+func TestSetExpectedDuration(t *testing.T) {
+	initTestLogs()
+	// The warnings are logged from timer goroutines, so they are written
+	// with a lock
+	warnings := &lockedBuffer{}
+	origWarning := Warning
+	Warning = log.New(warnings, "WARNING ", 0)
+	defer func() { Warning = origWarning }()
+
+	wf := NewWorkflow("test_wf", 4)
+	slow := wf.NewProc("slow", "sleep 0.3; echo slow > {o:out}")
+	slow.SetOut("out", "/tmp/sla_slow.txt")
+	slow.SetExpectedDuration(50 * time.Millisecond)
+	fast := wf.NewProc("fast", "echo fast > {o:out}")
+	fast.SetOut("out", "/tmp/sla_fast.txt")
+	fast.SetExpectedDuration(time.Minute)
+	wf.Run()
+	defer cleanFiles("/tmp/sla_slow.txt", "/tmp/sla_fast.txt")
+
+	if !strings.Contains(warnings.String(), "SLA breached") || !strings.Contains(warnings.String(), "slow") {
+		t.Errorf("Expected an SLA breach warning for the slow task, but got warnings: %s", warnings.String())
+	}
+	if strings.Contains(warnings.String(), "fast") {
+		t.Errorf("Expected no SLA breach warning for the fast task, but got warnings: %s", warnings.String())
+	}
+	for _, ts := range wf.TaskStats() {
+		if ts.SLABreached() != (ts.Process == "slow") {
+			t.Errorf("Expected SLA breached to be recorded only for the slow task, but was %v for %s", ts.SLABreached(), ts.Process)
+		}
+	}
+}
+
+// lockedBuffer is a bytes.Buffer that can be written and read concurrently
+type lockedBuffer struct {
+	mx  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	return b.buf.String()
+}
+
+func TestHealthCheckCommand(t *testing.T) {
+	initTestLogs()
+	// A passing health check should let tasks run
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("healthy", "echo healthy > {o:out}")
+	p.SetOut("out", "/tmp/health_check_healthy.txt")
+	p.HealthCheckCommand = "true"
+	wf.Run()
+	defer cleanFiles("/tmp/health_check_healthy.txt")
+	if _, err := os.Stat("/tmp/health_check_healthy.txt"); err != nil {
+		t.Errorf("Expected task to run after a passing health check: %s", err.Error())
+	}
+
+	unhealthy := wf.NewProc("unhealthy", "echo unhealthy > {o:out}")
+	unhealthy.HealthCheckCommand = "echo license server down; exit 1"
+	if err := unhealthy.runHealthCheck(); err == nil || !strings.Contains(err.Error(), "license server down") {
+		t.Errorf("Expected failing health check to return an error with its output, got: %v", err)
+	}
+}
+
+func TestHealthCheckCommandAbortsWorkflow(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		InitLogError()
+		wf := NewWorkflow("test_wf", 4)
+		p := wf.NewProc("unhealthy", "echo never run > {o:out}")
+		p.SetOut("out", "/tmp/health_check_never_run.txt")
+		p.HealthCheckCommand = "echo license server down; exit 1"
+		wf.Run()
+		return
+	}
+
+	out := runFailingSubprocess(t)
+	if !strings.Contains(out, "Health check failed") || !strings.Contains(out, "license server down") {
+		t.Errorf("Expected output to describe the failed health check, got: %s", out)
+	}
+	if _, err := os.Stat("/tmp/health_check_never_run.txt"); !os.IsNotExist(err) {
+		cleanFiles("/tmp/health_check_never_run.txt")
+		t.Error("Expected no task to run when the health check fails")
+	}
+}
+
+func TestSetOutFuncWithParam(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	thresholds := NewParamSource(wf, "thresholds", "5", "10")
+	filter := wf.NewProc("filter", "echo {p:threshold} > {o:out}")
+	filter.InParam("threshold").From(thresholds.Out())
+	filter.SetOutFunc("out", func(tsk *Task) string {
+		return "/tmp/out.t" + tsk.Param("threshold") + ".txt"
+	})
+	wf.Run()
+
+	for _, threshold := range []string{"5", "10"} {
+		path := "/tmp/out.t" + threshold + ".txt"
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			t.Errorf("Expected output file named after parameter value: %s", err.Error())
+			continue
+		}
+		if string(content) != threshold+"\n" {
+			t.Errorf("Expected %s to contain %s, got: %s", path, threshold, string(content))
+		}
+		cleanFiles(path)
+	}
+}
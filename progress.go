@@ -0,0 +1,93 @@
+package scipipe
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// Progress reporting
+// ----------------------------------------------------------------------------
+
+// ProgressEvent contains the progress of a running task, as parsed from the
+// output of its command (see Process.SetProgressParser)
+type ProgressEvent struct {
+	TaskID  string
+	Process string
+	// Fraction is the completed fraction of the task, from 0.0 to 1.0
+	Fraction float64
+}
+
+// SetProgressHandler sets a function to which the progress events of all
+// tasks in the workflow are reported. By default, progress events are logged
+// to the audit log.
+func (wf *Workflow) SetProgressHandler(handler func(ProgressEvent)) {
+	wf.progressHandler = handler
+}
+
+// reportProgress reports ev to the progress handler of the workflow, or logs
+// it if there is none
+func (wf *Workflow) reportProgress(ev ProgressEvent) {
+	if wf != nil && wf.progressHandler != nil {
+		wf.progressHandler(ev)
+		return
+	}
+	wf.logAuditf(ev.Process, "Progress of task %s: %.1f%%", ev.TaskID, ev.Fraction*100)
+}
+
+// progressWriter is an io.Writer that splits what is written to it into lines,
+// and reports the progress parsed from each line with parser. Both newlines
+// and carriage returns end lines, as the latter are commonly used by tools for
+// updating progress in place.
+type progressWriter struct {
+	parser func(line string) (fraction float64, ok bool)
+	report func(fraction float64)
+	buf    []byte
+}
+
+// Write writes p to the progressWriter, parsing progress from any complete
+// lines
+func (pw *progressWriter) Write(p []byte) (int, error) {
+	pw.buf = append(pw.buf, p...)
+	for {
+		i := bytes.IndexAny(pw.buf, "\r\n")
+		if i < 0 {
+			break
+		}
+		pw.parseLine(string(pw.buf[:i]))
+		pw.buf = pw.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush parses progress from any remaining incomplete line
+func (pw *progressWriter) Flush() {
+	if len(pw.buf) > 0 {
+		pw.parseLine(string(pw.buf))
+		pw.buf = nil
+	}
+}
+
+func (pw *progressWriter) parseLine(line string) {
+	if line == "" {
+		return
+	}
+	if fraction, ok := pw.parser(line); ok {
+		pw.report(fraction)
+	}
+}
+
+// syncWriter is an io.Writer that serializes writes to w, so that it can be
+// written to from multiple go-routines, such as for both stdout and stderr of
+// a command
+type syncWriter struct {
+	mx sync.Mutex
+	w  io.Writer
+}
+
+func (sw *syncWriter) Write(p []byte) (int, error) {
+	sw.mx.Lock()
+	defer sw.mx.Unlock()
+	return sw.w.Write(p)
+}
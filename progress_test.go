@@ -0,0 +1,76 @@
+package scipipe
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// parsePercent parses lines on the form "NN%" into fractions
+func parsePercent(line string) (float64, bool) {
+	if !strings.HasSuffix(line, "%") {
+		return 0, false
+	}
+	percent, err := strconv.ParseFloat(strings.TrimSuffix(line, "%"), 64)
+	if err != nil {
+		return 0, false
+	}
+	return percent / 100, true
+}
+
+func TestProgressWriter(t *testing.T) {
+	fractions := []float64{}
+	pw := &progressWriter{
+		parser: parsePercent,
+		report: func(fraction float64) { fractions = append(fractions, fraction) },
+	}
+	// Lines split across writes, ended by both newlines and carriage
+	// returns, mixed with lines without progress
+	for _, chunk := range []string{"starting\n1", "0%\r25", "%\rsome warning\n\n50%\n7", "5%"} {
+		pw.Write([]byte(chunk))
+	}
+	pw.Flush()
+
+	expected := []float64{0.1, 0.25, 0.5, 0.75}
+	if len(fractions) != len(expected) {
+		t.Fatalf("Expected fractions %v, got %v", expected, fractions)
+	}
+	for i := range expected {
+		if fractions[i] != expected[i] {
+			t.Errorf("Expected fraction %v at position %d, got %v", expected[i], i, fractions[i])
+		}
+	}
+}
+
+func TestSetProgressParser(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("test_wf", 4)
+	events := []ProgressEvent{}
+	eventsMx := sync.Mutex{}
+	wf.SetProgressHandler(func(ev ProgressEvent) {
+		eventsMx.Lock()
+		events = append(events, ev)
+		eventsMx.Unlock()
+	})
+
+	p := wf.NewProc("progress", "printf 'starting\\n10%%\\n50%%\\n100%%\\n' >&2; echo done > {o:out}")
+	p.SetOut("out", "/tmp/progress.txt")
+	p.SetProgressParser(parsePercent)
+	wf.Run()
+
+	expected := []float64{0.1, 0.5, 1.0}
+	if len(events) != len(expected) {
+		t.Fatalf("Expected %d progress events, got %d: %v", len(expected), len(events), events)
+	}
+	for i, ev := range events {
+		if ev.Fraction != expected[i] {
+			t.Errorf("Expected fraction %v in event %d, got %v", expected[i], i, ev.Fraction)
+		}
+		if ev.Process != "progress" || ev.TaskID == "" {
+			t.Errorf("Expected event for a task of process 'progress', got: %v", ev)
+		}
+	}
+	cleanFiles("/tmp/progress.txt")
+}
@@ -0,0 +1,66 @@
+package scipipe
+
+import (
+	"sort"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// Shared resources
+// ----------------------------------------------------------------------------
+
+// resource is a shared resource with a limited capacity, such as seats on a
+// license server, that tasks of multiple processes can require
+type resource struct {
+	name    string
+	slots   chan struct{}
+	slotsMx sync.Mutex
+}
+
+// DefineResource defines a shared resource with name name, with capacity
+// number of units available. Processes can require units of the resource with
+// Process.RequireResource(), which makes sure that tasks requiring more units
+// than are currently available wait until enough units are released. This can
+// be used to keep processes from running simultaneously, by making them
+// require a resource with capacity 1.
+func (wf *Workflow) DefineResource(name string, capacity int) {
+	if capacity < 1 {
+		Failf(wf.name+" workflow: Capacity of resource '%s' must be at least 1, got %d\n", name, capacity)
+	}
+	if _, ok := wf.resources[name]; ok {
+		Failf(wf.name+" workflow: A resource with name '%s' is already defined\n", name)
+	}
+	wf.resources[name] = &resource{
+		name:  name,
+		slots: make(chan struct{}, capacity),
+	}
+}
+
+// acquire acquires amount units of the resource, blocking until they are
+// available
+func (r *resource) acquire(amount int) {
+	// We must lock so that multiple tasks don't end up with partially "filled slots"
+	r.slotsMx.Lock()
+	for i := 0; i < amount; i++ {
+		r.slots <- struct{}{}
+	}
+	r.slotsMx.Unlock()
+	Debug.Printf("Acquired %d units of resource %s\n", amount, r.name)
+}
+
+// release releases amount units of the resource
+func (r *resource) release(amount int) {
+	for i := 0; i < amount; i++ {
+		<-r.slots
+	}
+	Debug.Printf("Released %d units of resource %s\n", amount, r.name)
+}
+
+func sortedIntMapKeys(kv map[string]int) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,57 @@
+package scipipe
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRequireResource(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("test_wf", 4)
+	wf.DefineResource("license", 1)
+
+	mx := sync.Mutex{}
+	running := 0
+	maxRunning := 0
+	executed := 0
+	useLicense := func(tsk *Task) {
+		mx.Lock()
+		running++
+		executed++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mx.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mx.Lock()
+		running--
+		mx.Unlock()
+		tsk.OutIP("out").Write([]byte("done\n"))
+	}
+
+	for _, procName := range []string{"tool_a", "tool_b"} {
+		src := NewParamSource(wf, procName+"_src", "1", "2", "3")
+		proc := wf.NewProc(procName, "# {p:n} {o:out}")
+		proc.InParam("n").From(src.Out())
+		proc.SetOut("out", "require_resource_"+procName+"_{p:n}.txt")
+		proc.CustomExecute = useLicense
+		proc.RequireResource("license", 1)
+	}
+
+	wf.Run()
+
+	if executed != 6 {
+		t.Errorf("Expected 6 tasks to be executed, got %d", executed)
+	}
+	if maxRunning != 1 {
+		t.Errorf("Expected at most 1 task to run at a time with a capacity-1 resource, but %d did", maxRunning)
+	}
+
+	for _, procName := range []string{"tool_a", "tool_b"} {
+		for _, n := range []string{"1", "2", "3"} {
+			cleanFiles("require_resource_" + procName + "_" + n + ".txt")
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package scipipe
+
+import (
+	"errors"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Singularity/Apptainer execution
+// ----------------------------------------------------------------------------
+
+// DefaultSingularityBinary is the container runtime used with
+// ExecModeSingularity, unless Process.SingularityBinary is set to something
+// else, such as "apptainer"
+const DefaultSingularityBinary = "singularity"
+
+// runInSingularity runs the shell command cmd with sh, in a container of the
+// image of the task's process, with singularity exec (or the runtime set in
+// Process.SingularityBinary). As with ExecModeDocker, the directories
+// containing the task's temp dir and all its inputs and outputs are bound at
+// the same absolute paths in the container as on the host.
+func (t *Task) runInSingularity(cmd string) ([]byte, error) {
+	if t.Process.Image == "" {
+		return nil, errors.New("Process " + t.Process.Name() + " has exec mode ExecModeSingularity, but no Image set")
+	}
+	workDir, err := filepath.Abs(t.TempDir())
+	if err != nil {
+		return nil, errWrap(err, "Could not get absolute path of temp dir "+t.TempDir())
+	}
+	bindDirs, err := t.containerMountDirs()
+	if err != nil {
+		return nil, err
+	}
+	binary := t.Process.SingularityBinary
+	if binary == "" {
+		binary = DefaultSingularityBinary
+	}
+	args := singularityExecArgs(t.Process.Image, bindDirs, workDir, cmd)
+	return t.runCommand(exec.Command(binary, args...))
+}
+
+// singularityExecArgs returns the arguments to singularity for running the
+// shell command cmd with sh in a container of image, with the directories
+// bindDirs bound at the same paths as on the host, and workDir as working
+// directory
+func singularityExecArgs(image string, bindDirs []string, workDir string, cmd string) []string {
+	return []string{"exec", "--bind", strings.Join(bindDirs, ","), "--pwd", workDir, image, "sh", "-c", cmd}
+}
@@ -0,0 +1,83 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSingularityExecArgs(t *testing.T) {
+	args := singularityExecArgs("/images/ubuntu.sif", []string{"/data", "/ref"}, "/data/tmp", "cat /ref/a.txt > out.txt")
+	expected := []string{"exec", "--bind", "/data,/ref", "--pwd", "/data/tmp", "/images/ubuntu.sif", "sh", "-c", "cat /ref/a.txt > out.txt"}
+	if !reflect.DeepEqual(args, expected) {
+		t.Errorf("Expected singularity args %v, got %v", expected, args)
+	}
+}
+
+func TestExecModeSingularity(t *testing.T) {
+	initTestLogs()
+
+	// Fake apptainer, which records its arguments, and runs the command on
+	// the host, in the working directory given with --pwd
+	binDir, err := ioutil.TempDir("", "fake_apptainer")
+	Check(err)
+	defer os.RemoveAll(binDir)
+	argsPath := filepath.Join(binDir, "args.txt")
+	err = ioutil.WriteFile(filepath.Join(binDir, "apptainer"), []byte("#!/bin/bash\n"+
+		"echo \"$@\" > "+argsPath+"\n"+
+		"while [ \"$1\" != \"--pwd\" ]; do shift; done\n"+
+		"cd \"$2\" && shift 3 && exec \"$@\"\n"), 0755)
+	Check(err)
+	origPath := os.Getenv("PATH")
+	os.Setenv("PATH", binDir+":"+origPath)
+	defer os.Setenv("PATH", origPath)
+
+	inDir, err := ioutil.TempDir("", "singularity_test_in")
+	Check(err)
+	defer os.RemoveAll(inDir)
+	inPath := filepath.Join(inDir, "in.txt")
+	err = ioutil.WriteFile(inPath, []byte("foo\n"), 0644)
+	Check(err)
+
+	wf := NewWorkflow("test_wf", 4)
+	src := NewFileSource(wf, "src", inPath)
+	p := wf.NewProc("singularity_job", "cat {i:in} > {o:out}")
+	p.In("in").From(src.Out())
+	p.SetOut("out", "singularity_test.txt")
+	p.ExecMode = ExecModeSingularity
+	p.Image = "/images/ubuntu.sif"
+	p.SingularityBinary = "apptainer"
+	wf.Run()
+	defer cleanFiles("singularity_test.txt")
+
+	out, err := ioutil.ReadFile("singularity_test.txt")
+	Check(err)
+	if string(out) != "foo\n" {
+		t.Errorf("Expected output of singularity job to be 'foo', but was: '%s'", string(out))
+	}
+	args, err := ioutil.ReadFile(argsPath)
+	Check(err)
+	wd, err := os.Getwd()
+	Check(err)
+	expectedBinds := strings.Join([]string{wd, inDir}, ",")
+	if wd > inDir {
+		expectedBinds = strings.Join([]string{inDir, wd}, ",")
+	}
+	for _, expected := range []string{"exec --bind " + expectedBinds + " --pwd ", " /images/ubuntu.sif sh -c "} {
+		if !strings.Contains(string(args), expected) {
+			t.Errorf("Expected singularity args to contain %q, but they were: %s", expected, args)
+		}
+	}
+
+	// Exit codes of failing containers should be reported
+	tsk := NewTask(wf, p, "singularity_job", "exit 3", map[string]*FileIP{}, nil, nil, map[string]string{}, map[string]string{}, "", nil, 1)
+	tsk.createDirs()
+	defer os.RemoveAll(tsk.TempDir())
+	_, err = tsk.runCommandInExecMode(tsk.Command)
+	if exitErr, ok := err.(interface{ ExitCode() int }); !ok || exitErr.ExitCode() != 3 {
+		t.Errorf("Expected container exiting with exit code 3 to be reported, got: %v", err)
+	}
+}
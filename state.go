@@ -0,0 +1,138 @@
+package scipipe
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+)
+
+// ----------------------------------------------------------------------------
+// Run state
+// ----------------------------------------------------------------------------
+
+// Statuses of tasks in the run state of a workflow
+const (
+	// TaskPending is the status of tasks waiting to be executed
+	TaskPending = "pending"
+	// TaskRunning is the status of tasks being executed
+	TaskRunning = "running"
+	// TaskDone is the status of tasks that have finished successfully, or
+	// whose outputs already existed
+	TaskDone = "done"
+)
+
+// RunState contains the state of all tasks of a workflow run, as saved by
+// Workflow.SaveState
+type RunState struct {
+	Workflow string
+	Tasks    []TaskState
+}
+
+// TaskState contains the state of a task in a workflow run
+type TaskState struct {
+	TaskID  string
+	Process string
+	TempDir string
+	Status  string
+}
+
+// setTaskStatus records the status of task t in the run state of the workflow
+func (wf *Workflow) setTaskStatus(t *Task, status string) {
+	if wf == nil {
+		return
+	}
+	wf.taskStatesMx.Lock()
+	defer wf.taskStatesMx.Unlock()
+	ts, ok := wf.taskStates[t.ID()]
+	if !ok {
+		ts = &TaskState{TaskID: t.ID(), Process: t.Name, TempDir: t.TempDir()}
+		wf.taskStates[t.ID()] = ts
+	}
+	ts.Status = status
+}
+
+// forgetTask removes task t from the run state of the workflow, such as when
+// it has been cancelled
+func (wf *Workflow) forgetTask(t *Task) {
+	if wf == nil {
+		return
+	}
+	wf.taskStatesMx.Lock()
+	delete(wf.taskStates, t.ID())
+	wf.taskStatesMx.Unlock()
+}
+
+// State returns the current run state of the workflow, with tasks sorted by
+// their ids
+func (wf *Workflow) State() RunState {
+	wf.taskStatesMx.Lock()
+	defer wf.taskStatesMx.Unlock()
+	state := RunState{Workflow: wf.Name(), Tasks: []TaskState{}}
+	for _, ts := range wf.taskStates {
+		state.Tasks = append(state.Tasks, *ts)
+	}
+	sort.Slice(state.Tasks, func(i, j int) bool {
+		return state.Tasks[i].TaskID < state.Tasks[j].TaskID
+	})
+	return state
+}
+
+// SaveState saves the current run state of the workflow, that is, which tasks
+// are done, running and pending, as JSON to the file at path. It can be
+// called while the workflow is running, such as periodically, to checkpoint
+// the run state, so that it can be restored with LoadState, after the
+// workflow was interrupted.
+func (wf *Workflow) SaveState(path string) {
+	stateJSON, err := json.MarshalIndent(wf.State(), "", "    ")
+	CheckWithMsg(err, "Could not marshal run state of workflow "+wf.Name())
+	// Write to a temporary file first, so that an interruption while saving
+	// does not leave a truncated state file behind
+	tmpPath := path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, stateJSON, 0644)
+	CheckWithMsg(err, "Could not write run state to file "+tmpPath)
+	err = os.Rename(tmpPath, path)
+	CheckWithMsg(err, "Could not rename run state file "+tmpPath+" to "+path)
+}
+
+// LoadState restores the run state of the workflow, saved with SaveState to
+// the file at path, before running the workflow again. When the workflow is
+// run, tasks that were done are skipped, while tasks that were running or
+// pending are executed. The temp dirs left behind by tasks that were running
+// when the state was saved are removed, so that they can be re-executed.
+func (wf *Workflow) LoadState(path string) {
+	state, err := readRunState(path)
+	CheckWithMsg(err, "Could not load run state from file "+path)
+	wf.restoredStatuses = map[string]string{}
+	for _, ts := range state.Tasks {
+		wf.restoredStatuses[ts.TaskID] = ts.Status
+		if ts.Status == TaskRunning {
+			err := os.RemoveAll(ts.TempDir)
+			CheckWithMsg(err, "Could not remove temp dir of interrupted task: "+ts.TempDir)
+		}
+	}
+	wf.Logger().Printf("| workflow:%-23s | Restored run state of %d tasks from %s", wf.Name(), len(state.Tasks), path)
+}
+
+// restoredStatus returns the status of task t in the run state restored with
+// LoadState, if any
+func (wf *Workflow) restoredStatus(t *Task) string {
+	if wf == nil || wf.restoredStatuses == nil {
+		return ""
+	}
+	return wf.restoredStatuses[t.ID()]
+}
+
+// readRunState reads a run state saved with Workflow.SaveState, from the file
+// at path
+func readRunState(path string) (RunState, error) {
+	state := RunState{}
+	stateJSON, err := ioutil.ReadFile(path)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(stateJSON, &state); err != nil {
+		return state, errWrap(err, "Could not unmarshal run state in "+path)
+	}
+	return state, nil
+}
@@ -0,0 +1,96 @@
+package scipipe
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSaveAndLoadState(t *testing.T) {
+	initTestLogs()
+	statePath := "/tmp/scipipe_run_state.json"
+	outPath := func(num string) string { return "run_state_" + num + ".txt" }
+	defer cleanFiles(statePath, outPath("1"), outPath("2"), outPath("3"), outPath("4"))
+
+	executed := []string{}
+	mx := sync.Mutex{}
+	newWf := func(saveStateAt string) *Workflow {
+		// Run one task at a time, so that exactly one task is running when
+		// the state is saved
+		wf := NewWorkflow("test_wf", 1)
+		src := NewParamSource(wf, "src", "1", "2", "3", "4")
+		mk := wf.NewProc("mk", "echo {p:num} > {o:out}")
+		mk.InParam("num").From(src.Out())
+		mk.SetOut("out", "run_state_{p:num}.txt")
+		mk.CustomExecute = func(tsk *Task) {
+			mx.Lock()
+			executed = append(executed, tsk.Param("num"))
+			mx.Unlock()
+			if tsk.Param("num") == saveStateAt {
+				wf.SaveState(statePath)
+			}
+			tsk.OutIP("out").Write([]byte(tsk.Param("num") + "\n"))
+		}
+		return wf
+	}
+
+	wf := newWf("3")
+	wf.Run()
+
+	state, err := readRunState(statePath)
+	Check(err)
+	statuses := map[string]int{}
+	for _, ts := range state.Tasks {
+		statuses[ts.Status]++
+	}
+	if statuses[TaskRunning] != 1 {
+		t.Fatalf("Expected exactly one running task in saved state, got: %v", state.Tasks)
+	}
+
+	// Simulate that the run was interrupted when the state was saved, by
+	// removing the outputs of tasks not done, and leaving behind the temp
+	// dir of the running task
+	resumeNums := []string{}
+	for _, num := range []string{"1", "2", "3", "4"} {
+		done := false
+		for _, ts := range state.Tasks {
+			if strings.HasPrefix(ts.TaskID, "mk.num_"+num+".") {
+				done = ts.Status == TaskDone
+				if ts.Status == TaskRunning {
+					Check(os.MkdirAll(ts.TempDir, 0777))
+				}
+			}
+		}
+		if !done {
+			resumeNums = append(resumeNums, num)
+			cleanFiles(outPath(num))
+		}
+	}
+
+	executed = []string{}
+	wf = newWf("")
+	wf.LoadState(statePath)
+	wf.Run()
+
+	sort.Strings(executed)
+	if len(executed) != len(resumeNums) {
+		t.Fatalf("Expected only the %d tasks not done to be executed on resume, got: %v", len(resumeNums), executed)
+	}
+	for i := range resumeNums {
+		if executed[i] != resumeNums[i] {
+			t.Errorf("Expected tasks %v to be executed on resume, got: %v", resumeNums, executed)
+		}
+	}
+	for _, num := range []string{"1", "2", "3", "4"} {
+		if _, err := os.Stat(outPath(num)); err != nil {
+			t.Errorf("Expected output of task for number %s to exist after resuming: %v", num, err)
+		}
+	}
+	for _, ts := range wf.State().Tasks {
+		if ts.Status != TaskDone {
+			t.Errorf("Expected all tasks to be done after resuming, but task %s was %s", ts.TaskID, ts.Status)
+		}
+	}
+}
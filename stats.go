@@ -0,0 +1,107 @@
+package scipipe
+
+import (
+	"sort"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Task statistics
+// ----------------------------------------------------------------------------
+
+// TaskStats contains statistics about an executed task
+type TaskStats struct {
+	TaskID  string
+	Process string
+	Stage   string
+	Cores   int
+	Start   time.Time
+	Finish  time.Time
+	// ExpectedDuration is the duration the task was expected to finish
+	// within, if set with Process.SetExpectedDuration
+	ExpectedDuration time.Duration
+	// QueuedDuration is the time the task spent waiting for the shared
+	// resources it requires, and for the concurrency budget of the workflow,
+	// before it started executing
+	QueuedDuration time.Duration
+	// RunDuration is the time the task spent executing, the same as
+	// Duration()
+	RunDuration time.Duration
+}
+
+// Duration returns the time it took to execute the task
+func (ts TaskStats) Duration() time.Duration {
+	return ts.Finish.Sub(ts.Start)
+}
+
+// SLABreached tells whether the task took longer than its expected duration
+func (ts TaskStats) SLABreached() bool {
+	return ts.ExpectedDuration > 0 && ts.Duration() > ts.ExpectedDuration
+}
+
+// addTaskStats records the statistics of an executed task
+func (wf *Workflow) addTaskStats(ts TaskStats) {
+	wf.taskStatsMx.Lock()
+	wf.taskStats = append(wf.taskStats, ts)
+	wf.taskStatsMx.Unlock()
+}
+
+// TaskStats returns statistics for all tasks executed by the workflow so far,
+// in the order they were finished
+func (wf *Workflow) TaskStats() []TaskStats {
+	wf.taskStatsMx.Lock()
+	defer wf.taskStatsMx.Unlock()
+	taskStats := make([]TaskStats, len(wf.taskStats))
+	copy(taskStats, wf.taskStats)
+	return taskStats
+}
+
+// ----------------------------------------------------------------------------
+// Stage statistics
+// ----------------------------------------------------------------------------
+
+// StageStats contains statistics aggregated over all executed tasks of the
+// processes in a stage (see Process.SetStage)
+type StageStats struct {
+	Stage     string
+	Processes []string
+	Tasks     int
+	// TotalDuration is the sum of the execution times of all tasks
+	TotalDuration time.Duration
+	// CoreDuration is the sum of the execution times of all tasks, multiplied
+	// by the number of cores used by each task
+	CoreDuration time.Duration
+}
+
+// StageStats returns statistics for the tasks executed so far, aggregated by
+// the stage of their processes, and sorted by stage name. Tasks of processes
+// not put in any stage are aggregated under an empty stage name.
+func (wf *Workflow) StageStats() []StageStats {
+	statsByStage := map[string]*StageStats{}
+	procsByStage := map[string]map[string]bool{}
+	for _, ts := range wf.TaskStats() {
+		ss, ok := statsByStage[ts.Stage]
+		if !ok {
+			ss = &StageStats{Stage: ts.Stage}
+			statsByStage[ts.Stage] = ss
+			procsByStage[ts.Stage] = map[string]bool{}
+		}
+		ss.Tasks++
+		ss.TotalDuration += ts.Duration()
+		ss.CoreDuration += ts.Duration() * time.Duration(ts.Cores)
+		if !procsByStage[ts.Stage][ts.Process] {
+			procsByStage[ts.Stage][ts.Process] = true
+			ss.Processes = append(ss.Processes, ts.Process)
+		}
+	}
+
+	stageStats := []StageStats{}
+	for _, ss := range statsByStage {
+		sort.Strings(ss.Processes)
+		stageStats = append(stageStats, *ss)
+	}
+	sort.Slice(stageStats, func(i, j int) bool {
+		return stageStats[i].Stage < stageStats[j].Stage
+	})
+	return stageStats
+}
@@ -0,0 +1,87 @@
+package scipipe
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStageStats(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("test_wf", 4)
+	nSource := NewParamSource(wf, "numbers", "1", "2", "3")
+
+	qc1 := wf.NewProc("qc1", "# {p:number} {o:out}")
+	qc1.InParam("number").From(nSource.Out())
+	qc1.SetOut("out", "stage_stats_qc1_{p:number}.txt")
+	qc1.CustomExecute = func(tsk *Task) { tsk.OutIP("out").Write([]byte("qc1\n")) }
+	qc1.SetStage("qc")
+
+	qc2 := wf.NewProc("qc2", "# {i:in} {o:out}")
+	qc2.In("in").From(qc1.Out("out"))
+	qc2.SetOut("out", "{i:in}.qc2.txt")
+	qc2.CustomExecute = func(tsk *Task) { tsk.OutIP("out").Write([]byte("qc2\n")) }
+	qc2.SetStage("qc")
+
+	align := wf.NewProc("align", "# {i:in} {o:out}")
+	align.In("in").From(qc2.Out("out"))
+	align.SetOut("out", "{i:in}.aligned.txt")
+	align.CustomExecute = func(tsk *Task) { tsk.OutIP("out").Write([]byte("align\n")) }
+	align.CoresPerTask = 2
+	align.SetStage("alignment")
+
+	wf.Run()
+
+	stageStats := wf.StageStats()
+	if len(stageStats) != 2 {
+		t.Fatalf("Expected stats for 2 stages, got %d: %+v", len(stageStats), stageStats)
+	}
+	alignStats, qcStats := stageStats[0], stageStats[1]
+	if alignStats.Stage != "alignment" || alignStats.Tasks != 3 || !reflect.DeepEqual(alignStats.Processes, []string{"align"}) {
+		t.Errorf("Unexpected stats for alignment stage: %+v", alignStats)
+	}
+	if qcStats.Stage != "qc" || qcStats.Tasks != 6 || !reflect.DeepEqual(qcStats.Processes, []string{"qc1", "qc2"}) {
+		t.Errorf("Unexpected stats for qc stage: %+v", qcStats)
+	}
+	if alignStats.CoreDuration != 2*alignStats.TotalDuration {
+		t.Errorf("Expected core duration of alignment stage (%v) to be twice its total duration (%v)", alignStats.CoreDuration, alignStats.TotalDuration)
+	}
+
+	for _, n := range []string{"1", "2", "3"} {
+		base := "stage_stats_qc1_" + n + ".txt"
+		cleanFiles(base, base+".qc2.txt", base+".qc2.txt.aligned.txt")
+	}
+}
+
+func TestQueuedDuration(t *testing.T) {
+	initTestLogs()
+
+	// With a budget of one concurrent task, tasks have to wait for each other
+	wf := NewWorkflow("test_wf", 1)
+	nSource := NewParamSource(wf, "numbers", "1", "2", "3")
+	p := wf.NewProc("sleeper", "sleep 0.1; echo {p:number} > {o:out}")
+	p.InParam("number").From(nSource.Out())
+	p.SetOut("out", "queued_duration_test_{p:number}.txt")
+	wf.Run()
+	defer cleanFiles("queued_duration_test_1.txt", "queued_duration_test_2.txt", "queued_duration_test_3.txt")
+
+	taskStats := wf.TaskStats()
+	if len(taskStats) != 3 {
+		t.Fatalf("Expected stats for 3 tasks, got %d: %+v", len(taskStats), taskStats)
+	}
+	for i, ts := range taskStats {
+		if ts.RunDuration < 100*time.Millisecond || ts.RunDuration != ts.Duration() {
+			t.Errorf("Expected run duration of task %s to be at least 100ms, and equal to its duration, got %v", ts.TaskID, ts.RunDuration)
+		}
+		// Tasks are finished in the order they got the budget, so all but
+		// the first one had to wait
+		if i > 0 && ts.QueuedDuration <= 0 {
+			t.Errorf("Expected task %s, finished as number %d, to have been queued, got queued duration %v", ts.TaskID, i+1, ts.QueuedDuration)
+		}
+	}
+	// The last task waited for the two tasks before it, of at least 100ms each
+	if lastQueued := taskStats[2].QueuedDuration; lastQueued < 150*time.Millisecond {
+		t.Errorf("Expected the last task to be queued for at least 150ms, got %v", lastQueued)
+	}
+}
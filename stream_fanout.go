@@ -0,0 +1,139 @@
+package scipipe
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ----------------------------------------------------------------------------
+// Fanning out streams to multiple consumers
+// ----------------------------------------------------------------------------
+
+// multiConsumerStreams returns a message for every streaming out-port among
+// procs that is connected to more than one in-port. Since every byte written
+// to a FIFO file is read by only one reader, such streams can not be
+// broadcast, unless Workflow.AutoTeeStreams is set.
+func multiConsumerStreams(procs map[string]WorkflowProcess) []string {
+	msgs := []string{}
+	for _, procName := range sortedWFProcMapKeys(procs) {
+		proc := procs[procName]
+		for _, optName := range sortedOutPortMapKeys(proc.OutPorts()) {
+			opt := proc.OutPorts()[optName]
+			if isStreamingOutPort(opt) && len(opt.RemotePorts) > 1 {
+				msgs = append(msgs, fmt.Sprintf("Streaming out-port %s is connected to %d in-ports (%v), but a FIFO file can only be read by one of them. Set Workflow.AutoTeeStreams to fan out the stream to all of them, or stream to only one in-port.", opt.Name(), len(opt.RemotePorts), sortedInPortMapKeys(opt.RemotePorts)))
+			}
+		}
+	}
+	return msgs
+}
+
+// insertStreamFanOuts inserts a stream fan-out process after every streaming
+// out-port among procs connected to more than one in-port, which forwards the
+// stream to each of the in-ports via a separate FIFO file. The fan-out
+// processes are added to procs, as well as to the workflow.
+func (wf *Workflow) insertStreamFanOuts(procs map[string]WorkflowProcess) {
+	for _, procName := range sortedWFProcMapKeys(procs) {
+		proc := procs[procName]
+		for _, optName := range sortedOutPortMapKeys(proc.OutPorts()) {
+			opt := proc.OutPorts()[optName]
+			if !isStreamingOutPort(opt) || len(opt.RemotePorts) < 2 {
+				continue
+			}
+			fanOut := newStreamFanOut(wf, procName+"_"+optName+"_tee")
+			for i, rptName := range sortedInPortMapKeys(opt.RemotePorts) {
+				rpt := opt.RemotePorts[rptName]
+				opt.Disconnect(rptName)
+				rpt.Disconnect(opt.Name())
+				fanOutOptName := fmt.Sprintf("out_%d", i+1)
+				fanOut.InitOutPort(fanOut, fanOutOptName)
+				fanOut.OutPort(fanOutOptName).To(rpt)
+			}
+			opt.To(fanOut.InPort("in"))
+			procs[fanOut.Name()] = fanOut
+			wf.Logger().Printf("| workflow:%-23s | Fanning out stream of out-port %s to %d in-ports, via process %s", wf.Name(), opt.Name(), len(fanOut.OutPorts()), fanOut.Name())
+		}
+	}
+}
+
+// streamFanOut is a process forwarding every stream received on its in-port
+// to all its out-ports, via a separate FIFO file for each out-port. The
+// forwarded streams keep the path of the received stream, so that the paths
+// of outputs named after it are not affected.
+type streamFanOut struct {
+	BaseProcess
+}
+
+// newStreamFanOut returns a new streamFanOut process, without out-ports, which
+// are added for each in-port to forward the stream to
+func newStreamFanOut(wf *Workflow, name string) *streamFanOut {
+	p := &streamFanOut{
+		BaseProcess: NewBaseProcess(wf, name),
+	}
+	p.InitInPort(p, "in")
+	wf.AddProc(p)
+	return p
+}
+
+// Run runs the streamFanOut process
+func (p *streamFanOut) Run() {
+	defer p.CloseAllOutPorts()
+	for inIP := range p.InPort("in").Chan {
+		outIPs := []*FileIP{}
+		for i, optName := range sortedOutPortMapKeys(p.OutPorts()) {
+			outIP := NewFileIP(inIP.Path())
+			outIP.SetStreaming(true)
+			outIP.fifoDir = inIP.FifoPath() + fmt.Sprintf(".tee_%d", i+1)
+			outIP.AddTags(inIP.Tags())
+			outIP.CreateFifo()
+			p.OutPort(optName).Send(outIP)
+			outIPs = append(outIPs, outIP)
+		}
+		err := fanOutFifo(inIP.FifoPath(), outIPs)
+		CheckWithMsg(err, "Could not fan out stream "+inIP.FifoPath()+" in process "+p.Name())
+		for _, outIP := range outIPs {
+			err := os.RemoveAll(outIP.fifoDir)
+			CheckWithMsg(err, "Could not remove FIFO dir "+outIP.fifoDir)
+		}
+	}
+}
+
+// fanOutFifo copies everything read from the FIFO file at inPath to the FIFO
+// files of outIPs. Opening a FIFO file blocks until it is opened at the other
+// end too, so all files are opened concurrently.
+func fanOutFifo(inPath string, outIPs []*FileIP) error {
+	outFhs := make([]*os.File, len(outIPs))
+	errs := make([]error, len(outIPs))
+	wg := sync.WaitGroup{}
+	for i, outIP := range outIPs {
+		wg.Add(1)
+		go func(i int, fifoPath string) {
+			defer wg.Done()
+			outFhs[i], errs[i] = os.OpenFile(fifoPath, os.O_WRONLY, 0)
+		}(i, outIP.FifoPath())
+	}
+	inFh, inErr := os.Open(inPath)
+	wg.Wait()
+
+	writers := []io.Writer{}
+	for _, outFh := range outFhs {
+		if outFh != nil {
+			defer outFh.Close()
+			writers = append(writers, outFh)
+		}
+	}
+	if inErr != nil {
+		return errWrap(inErr, "Could not open FIFO file "+inPath)
+	}
+	defer inFh.Close()
+	for i, err := range errs {
+		if err != nil {
+			return errWrap(err, "Could not open FIFO file "+outIPs[i].FifoPath())
+		}
+	}
+	if _, err := io.Copy(io.MultiWriter(writers...), inFh); err != nil {
+		return errWrap(err, "Could not copy stream from "+inPath)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAutoTeeStreams(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	wf.AutoTeeStreams = true
+	src := wf.NewProc("src", "printf 'a\\nb\\n' > {os:out}")
+	src.SetOut("out", "/tmp/auto_tee_src.txt")
+	for _, name := range []string{"c1", "c2"} {
+		consumer := wf.NewProc(name, "cat {i:in} > {o:out}")
+		consumer.In("in").From(src.Out("out"))
+		consumer.SetOut("out", "{i:in}."+name+".txt")
+	}
+	wf.Run()
+	defer cleanFilePatterns("/tmp/auto_tee_src.txt*")
+
+	for _, outPath := range []string{"/tmp/auto_tee_src.txt.c1.txt", "/tmp/auto_tee_src.txt.c2.txt"} {
+		out, err := ioutil.ReadFile(outPath)
+		if err != nil {
+			t.Fatalf("Expected output of stream consumer to exist: %s", err.Error())
+		}
+		if string(out) != "a\nb\n" {
+			t.Errorf("Expected each consumer to get the whole stream, but %s was %q", outPath, out)
+		}
+	}
+	if leftovers, _ := filepath.Glob("/tmp/auto_tee_src.txt.fifo*"); len(leftovers) > 0 {
+		t.Errorf("Expected FIFO files to be removed, found: %v", leftovers)
+	}
+}
+
+func TestMultiConsumerStreamFails(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		InitLogError()
+		wf := NewWorkflow("TestMultiConsumerStreamWF", 4)
+		src := wf.NewProc("src", "printf 'a\\nb\\n' > {os:out}")
+		src.SetOut("out", "/tmp/multi_consumer_src.txt")
+		for _, name := range []string{"c1", "c2"} {
+			consumer := wf.NewProc(name, "cat {i:in} > {o:out}")
+			consumer.In("in").From(src.Out("out"))
+			consumer.SetOut("out", "{i:in}."+name+".txt")
+		}
+		wf.Run()
+		return
+	}
+
+	out := runFailingSubprocess(t)
+	if !strings.Contains(out, "Streaming out-port src.out is connected to 2 in-ports") {
+		t.Errorf("Expected output to explain that the stream has multiple consumers, got: %s", out)
+	}
+	cleanFilePatterns("/tmp/multi_consumer_src.txt*")
+}
@@ -1,12 +1,17 @@
 package scipipe
 
 import (
+	"bytes"
 	"crypto/sha1"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 )
@@ -27,6 +32,10 @@ type Task struct {
 	Process       *Process
 	portInfos     map[string]*PortInfo
 	subStreamIPs  map[string][]*FileIP
+	failed        bool
+	cacheKey      string
+	extraFiles    []string
+	exitCode      int
 }
 
 // ------------------------------------------------------------------------
@@ -68,25 +77,32 @@ func NewTask(workflow *Workflow, process *Process, name string, cmdPat string, i
 		if ptInfo, ok := portInfos[oname]; ok {
 			if ptInfo.doStream {
 				oip.doStream = true
+				if workflow != nil {
+					oip.fifoDir = workflow.fifoDir
+				}
 			}
 		}
 		t.OutIPs[oname] = oip
 	}
-	t.Command = formatCommand(cmdPat, portInfos, inIPs, t.subStreamIPs, t.OutIPs, params, tags, prepend)
+	t.Command = formatCommand(workflow.placeHolderRegex(), cmdPat, portInfos, inIPs, t.subStreamIPs, t.OutIPs, params, tags, prepend)
 	return t
 }
 
 // formatCommand is a helper function for NewTask, that formats a shell command
-// based on concrete file paths and parameter values
-func formatCommand(cmd string, portInfos map[string]*PortInfo, inIPs map[string]*FileIP, subStreamIPs map[string][]*FileIP, outIPs map[string]*FileIP, params map[string]string, tags map[string]string, prepend string) string {
-	r := getShellCommandPlaceHolderRegex()
-	placeHolderMatches := r.FindAllStringSubmatch(cmd, -1)
-	placeholders := map[string]string{}
+// based on concrete file paths and parameter values, replacing the
+// placeholders matched by placeHolderRegex
+func formatCommand(placeHolderRegex *regexp.Regexp, cmd string, portInfos map[string]*PortInfo, inIPs map[string]*FileIP, subStreamIPs map[string][]*FileIP, outIPs map[string]*FileIP, params map[string]string, tags map[string]string, prepend string) string {
+	placeHolderMatches := placeHolderRegex.FindAllStringSubmatch(cmd, -1)
+	// A port can be referenced by multiple placeholders, with different
+	// modifiers, so keep all of them
+	placeholders := map[string][]string{}
+	modifiers := map[string][]string{}
 	for _, placeHolderMatch := range placeHolderMatches {
-		rest := placeHolderMatch[2]
-		restParts := strings.Split(rest, "|")
+		placeHolder := placeHolderMatch[0]
+		restParts := strings.Split(placeHolderMatch[2], "|")
 		portName := restParts[0]
-		placeholders[portName] = placeHolderMatch[0]
+		placeholders[portName] = append(placeholders[portName], placeHolder)
+		modifiers[placeHolder] = restParts[1:]
 	}
 
 	for portName, portInfo := range portInfos {
@@ -110,7 +126,11 @@ func formatCommand(cmd string, portInfos map[string]*PortInfo, inIPs map[string]
 				// Merge multiple input paths from a substream on the IP, into one string
 				paths := []string{}
 				for _, ip := range subStreamIPs[portName] {
-					paths = append(paths, parentDirPath(ip.Path()))
+					path := parentDirPath(ip.Path())
+					if portInfo.quote {
+						path = shellQuote(path)
+					}
+					paths = append(paths, path)
 				}
 				filePath = strings.Join(paths, portInfo.joinSep)
 			} else {
@@ -140,7 +160,9 @@ func formatCommand(cmd string, portInfos map[string]*PortInfo, inIPs map[string]
 		default:
 			Fail("Replace failed for port ", portName, " for command '", cmd, "'")
 		}
-		cmd = strings.Replace(cmd, placeholders[portName], filePath, -1)
+		for _, placeHolder := range placeholders[portName] {
+			cmd = strings.Replace(cmd, placeHolder, applyPathModifiers(filePath, modifiers[placeHolder]), -1)
+		}
 	}
 
 	// Add prepend string to the command
@@ -182,7 +204,9 @@ func (t *Task) OutPath(portName string) string {
 	return t.OutIP(portName).Path()
 }
 
-// Param returns the value of a param, for the task
+// Param returns the value of a param, for the task. It is available already
+// when the paths of the task's outputs are formatted, such as in functions set
+// with Process.SetOutFunc().
 func (t *Task) Param(portName string) string {
 	if param, ok := t.Params[portName]; ok {
 		return param
@@ -191,7 +215,7 @@ func (t *Task) Param(portName string) string {
 	return "invalid"
 }
 
-// Tag returns the value of a param, for the task
+// Tag returns the value of a tag, for the task
 func (t *Task) Tag(tagName string) string {
 	if tag, ok := t.Tags[tagName]; ok {
 		return tag
@@ -200,6 +224,14 @@ func (t *Task) Tag(tagName string) string {
 	return "invalid"
 }
 
+// InTag returns the value of the tag tagName of the IP received on the in-port
+// inPortName, for the task. Tags of input IPs are available as task tags named
+// [in-port name].[tag name], such as for the {t:PORTNAME.TAGNAME} placeholder,
+// which this is a short-hand for.
+func (t *Task) InTag(inPortName string, tagName string) string {
+	return t.Tag(inPortName + "." + tagName)
+}
+
 // ------------------------------------------------------------------------
 // Execute the task
 // ------------------------------------------------------------------------
@@ -208,37 +240,141 @@ func (t *Task) Tag(tagName string) string {
 func (t *Task) Execute() {
 	defer close(t.Done)
 
+	// When re-running failed tasks only, skip all other tasks, and clean up
+	// after the failed ones
+	if t.workflow != nil && t.workflow.failedTasksOnly != nil {
+		if !t.workflow.failedTasksOnly[t.TempDir()] {
+			t.workflow.Logger().Printf("| %-32s | Task not marked as failed in prior run, so skipping: %s\n", t.Name, t.TempDir())
+			t.workflow.recordTaskOutcome(t, taskSkipped)
+			t.Done <- 1
+			return
+		}
+		err := os.RemoveAll(t.TempDir())
+		CheckWithMsg(err, "Could not remove temp dir of failed task: "+t.TempDir())
+	}
+
+	// When resuming from a restored run state, skip tasks that were done
+	if t.workflow.restoredStatus(t) == TaskDone {
+		t.workflow.Logger().Printf("| %-32s | Task done according to restored run state, so skipping: %s\n", t.Name, t.ID())
+		t.workflow.setTaskStatus(t, TaskDone)
+		t.workflow.recordTaskOutcome(t, taskSkipped)
+		t.signalSuccess()
+		t.Done <- 1
+		return
+	}
+	t.workflow.setTaskStatus(t, TaskPending)
+
 	// Do some sanity checks
 	if t.tempDirsExist() {
 		Failf("| %-32s | Existing temp folders found, so existing. Clean up temporary folders (starting with '%s') before restarting the workflow!", t.Name, tempDirPrefix)
 	}
 
-	if t.anyOutputsExist() {
+	if t.cachingEnabled() {
+		if t.cacheHit() {
+			t.workflow.Logger().Printf("| %-32s | Cache key of all outputs matches, so skipping: %s\n", t.Name, t.ID())
+			t.workflow.setTaskStatus(t, TaskDone)
+			t.workflow.recordTaskOutcome(t, taskSkipped)
+			t.signalSuccess()
+			t.Done <- 1
+			return
+		}
+	} else if t.anyOutputsExist() {
+		t.workflow.setTaskStatus(t, TaskDone)
+		t.workflow.recordTaskOutcome(t, taskSkipped)
+		t.signalSuccess()
+		t.Done <- 1
+		return
+	}
+
+	// In dry runs, the command is only printed
+	if t.workflow != nil && t.workflow.DryRun {
+		t.printDryRun()
+		t.signalSuccess()
 		t.Done <- 1
 		return
 	}
 
 	// Execute task
-	t.workflow.IncConcurrentTasks(t.cores) // Will block if max concurrent tasks is reached
-	t.createDirs()                         // Create output directories needed for any outputs
+	// Resources are acquired before cores, so that tasks waiting for resources
+	// don't hold on to cores needed by the tasks currently holding them
+	queueStartTime := time.Now()
+	t.acquireResources()                                             // Will block until required resources are available
+	t.workflow.incConcurrentTasksWithPriority(t.cores, t.priority()) // Will block if max concurrent tasks is reached
+	queuedDuration := time.Since(queueStartTime)
+
+	// If another task of the process has already succeeded, and the process
+	// should stop on the first success, cancel this task
+	if t.Process != nil && t.Process.StopOnFirstSuccess && t.Process.hasSucceeded() {
+		t.workflow.Logger().Printf("| %-32s | Another task already succeeded, so cancelling task %s\n", t.Name, t.ID())
+		t.workflow.DecConcurrentTasks(t.cores)
+		t.releaseResources()
+		t.workflow.forgetTask(t)
+		t.workflow.recordTaskOutcome(t, taskSkipped)
+		t.Done <- 1
+		return
+	}
+
+	t.workflow.setTaskStatus(t, TaskRunning)
+	t.workflow.sendEvent(EventTaskStarted, t, "")
+	if t.cachingEnabled() {
+		t.removeStaleOutputs()
+	}
+	t.createDirs() // Create output directories needed for any outputs
 	startTime := time.Now()
+	stopSLATimer := t.startSLATimer()
 	if t.CustomExecute != nil {
 		outputsStr := ""
 		for oipName, oip := range t.OutIPs {
 			outputsStr += " " + oipName + ": " + oip.Path()
 		}
-		LogAuditf(t.Name, "Executing: Custom Go function with outputs: %s", outputsStr)
+		t.workflow.logAuditf(t.logName(), "Executing task %s: Custom Go function with outputs: %s", t.ID(), outputsStr)
 		t.CustomExecute(t)
-		LogAuditf(t.Name, "Executing: Custom Go function with outputs: %s", outputsStr)
+		t.workflow.logAuditf(t.logName(), "Finished task %s: Custom Go function with outputs: %s", t.ID(), outputsStr)
 	} else {
-		LogAuditf(t.Name, "Executing: %s", t.Command)
+		t.workflow.logAuditf(t.logName(), "Executing task %s: %s", t.ID(), t.Command)
 		t.executeCommand(t.Command)
-		LogAuditf(t.Name, "Finished: %s", t.Command)
+		t.workflow.logAuditf(t.logName(), "Finished task %s: %s", t.ID(), t.Command)
 	}
+	stopSLATimer()
 	finishTime := time.Now()
+	t.workflow.addTaskStats(t.stats(queuedDuration, startTime, finishTime))
+	if t.failed {
+		// Only processes that stop on the first success tolerate failed tasks,
+		// in which case the outputs of the failed task are discarded
+		t.workflow.addAuditReportTask(t, startTime, finishTime)
+		err := os.RemoveAll(t.TempDir())
+		CheckWithMsg(err, "Could not remove temp dir of failed task: "+t.TempDir())
+		t.workflow.DecConcurrentTasks(t.cores)
+		t.releaseResources()
+		t.workflow.forgetTask(t)
+		t.Done <- 1
+		return
+	}
+	if err := t.validateOutputs(); err != nil {
+		t.markFailed()
+		t.workflow.sendEvent(EventTaskFailed, t, err.Error())
+		t.workflow.recordTaskOutcome(t, taskFailed)
+		Failf("| %-32s | Output validation failed: %s\n", t.Name, err.Error())
+	}
 	t.writeAuditLogs(startTime, finishTime)
+	if t.writesManifest() {
+		t.collectExtraFiles()
+	}
 	t.atomizeIPs()
+	t.storeOutputsInCAS()
+	if t.cachingEnabled() {
+		t.writeCacheRecords()
+	}
+	if t.writesManifest() {
+		t.WriteManifest(t.manifestPath())
+	}
+	t.workflow.addAuditReportTask(t, startTime, finishTime)
 	t.workflow.DecConcurrentTasks(t.cores)
+	t.releaseResources()
+	t.workflow.setTaskStatus(t, TaskDone)
+	t.workflow.sendEvent(EventTaskFinished, t, "")
+	t.workflow.recordTaskOutcome(t, taskCompleted)
+	t.signalSuccess()
 
 	t.Done <- 1
 }
@@ -247,6 +383,95 @@ func (t *Task) Execute() {
 // Helper methods for the Execute method
 // ------------------------------------------------------------------------
 
+// startSLATimer starts a timer logging a warning if the task is still running
+// after the expected duration of its process, if set. The returned function
+// stops the timer, and waits for the warning to be logged, if the timer has
+// already fired.
+func (t *Task) startSLATimer() (stop func()) {
+	if t.Process == nil || t.Process.expectedDuration <= 0 {
+		return func() {}
+	}
+	expected := t.Process.expectedDuration
+	fired := make(chan struct{})
+	timer := time.AfterFunc(expected, func() {
+		defer close(fired)
+		t.workflow.WarningLogger().Printf("| %-32s | SLA breached: Task %s has been running for longer than the expected duration of %v\n", t.Name, t.ID(), expected)
+	})
+	return func() {
+		if !timer.Stop() {
+			<-fired
+		}
+	}
+}
+
+// acquireResources acquires the shared resources required by the task's
+// process, in the order of their names, to avoid deadlocks between tasks
+// requiring multiple resources
+func (t *Task) acquireResources() {
+	if t.Process == nil {
+		return
+	}
+	for _, resName := range sortedIntMapKeys(t.Process.resources) {
+		t.workflow.resources[resName].acquire(t.Process.resources[resName])
+	}
+}
+
+// releaseResources releases the shared resources acquired by acquireResources
+func (t *Task) releaseResources() {
+	if t.Process == nil {
+		return
+	}
+	for _, resName := range sortedIntMapKeys(t.Process.resources) {
+		t.workflow.resources[resName].release(t.Process.resources[resName])
+	}
+}
+
+// priority returns the scheduling priority of the task, which is the one of
+// its process
+func (t *Task) priority() int {
+	if t.Process == nil {
+		return 0
+	}
+	return t.Process.Priority
+}
+
+// signalSuccess tells the task's process that the task succeeded, if the
+// process should stop on the first successful task
+func (t *Task) signalSuccess() {
+	if t.Process != nil && t.Process.StopOnFirstSuccess {
+		t.Process.setSucceeded(t)
+	}
+}
+
+// logName returns the name to use for the task in logs, which includes the
+// stage of the task's process, if set
+func (t *Task) logName() string {
+	if t.Process != nil && t.Process.Stage() != "" {
+		return t.Process.Stage() + "/" + t.Name
+	}
+	return t.Name
+}
+
+// stats returns statistics for the task, given the time it was queued, and
+// its start and finish times
+func (t *Task) stats(queuedDuration time.Duration, startTime time.Time, finishTime time.Time) TaskStats {
+	ts := TaskStats{
+		TaskID:         t.ID(),
+		Process:        t.Name,
+		Cores:          t.cores,
+		Start:          startTime,
+		Finish:         finishTime,
+		QueuedDuration: queuedDuration,
+		RunDuration:    finishTime.Sub(startTime),
+	}
+	if t.Process != nil {
+		ts.Process = t.Process.Name()
+		ts.Stage = t.Process.Stage()
+		ts.ExpectedDuration = t.Process.expectedDuration
+	}
+	return ts
+}
+
 // anyTempFileExists checks if any temporary workflow files exist and if so, returns true
 func (t *Task) tempDirsExist() bool {
 	if _, err := os.Stat(t.TempDir()); os.IsNotExist(err) {
@@ -262,7 +487,7 @@ func (t *Task) anyOutputsExist() (anyFileExists bool) {
 		if !oip.doStream {
 			opath := oip.Path()
 			if _, err := os.Stat(opath); err == nil {
-				Audit.Printf("| %-32s | Output file already exists, so skipping: %s\n", t.Name, opath)
+				t.workflow.Logger().Printf("| %-32s | Output file already exists, so skipping: %s\n", t.Name, opath)
 				anyFileExists = true
 			}
 		}
@@ -286,24 +511,250 @@ func (t *Task) createDirs() {
 
 }
 
+// runCommand runs command, and returns its combined stdout and stderr output.
+// If the task's process has a progress parser, progress is parsed from
+// stderr while the command runs. If the task's process has a timeout, the
+// command is killed if it runs for longer than that, and a *TimeoutError is
+// returned.
+func (t *Task) runCommand(command *exec.Cmd) ([]byte, error) {
+	out := &bytes.Buffer{}
+	command.Stdout = out
+	command.Stderr = out
+	var pw *progressWriter
+	if t.Process != nil && t.Process.progressParser != nil {
+		outWriter := &syncWriter{w: out}
+		pw = &progressWriter{
+			parser: t.Process.progressParser,
+			report: func(fraction float64) {
+				t.workflow.reportProgress(ProgressEvent{TaskID: t.ID(), Process: t.Process.Name(), Fraction: fraction})
+			},
+		}
+		command.Stdout = outWriter
+		command.Stderr = io.MultiWriter(outWriter, pw)
+	}
+	timeout := time.Duration(0)
+	if t.Process != nil {
+		timeout = t.Process.Timeout
+	}
+	err := runWithTimeout(command, timeout)
+	if pw != nil {
+		pw.Flush()
+	}
+	return out.Bytes(), err
+}
+
+// timeoutGracePeriod is the time commands that have timed out are given to
+// terminate, after SIGTERM, before they are killed with SIGKILL
+const timeoutGracePeriod = 5 * time.Second
+
+// TimeoutError is the error for commands that were killed because they ran
+// for longer than the timeout of their process (see Process.Timeout)
+type TimeoutError struct {
+	Timeout time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("Command timed out after %v", e.Timeout)
+}
+
+// runWithTimeout runs command, and if timeout is larger than zero, kills it,
+// together with any processes it started, if it runs for longer than timeout,
+// returning a *TimeoutError
+func runWithTimeout(command *exec.Cmd, timeout time.Duration) error {
+	if timeout <= 0 {
+		return command.Run()
+	}
+	setProcessGroup(command)
+	if err := command.Start(); err != nil {
+		return err
+	}
+	done := make(chan error, 1)
+	go func() { done <- command.Wait() }()
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+	}
+	terminateProcessGroup(command)
+	select {
+	case <-done:
+	case <-time.After(timeoutGracePeriod):
+		killProcessGroup(command)
+		<-done
+	}
+	return &TimeoutError{Timeout: timeout}
+}
+
 // executeCommand executes the shell command cmd via bash
 func (t *Task) executeCommand(cmd string) {
-	// cd into the task's tempdir, execute the command, and cd back
-	out, err := exec.Command("bash", "-c", "cd "+t.TempDir()+" && "+cmd+" && cd ..").CombinedOutput()
+	out, err := t.runCommandWithRetries(cmd)
 	if err != nil {
+		t.workflow.sendEvent(EventTaskFailed, t, err.Error())
+		t.workflow.recordTaskOutcome(t, taskFailed)
+		if _, ok := err.(*TimeoutError); ok {
+			t.removeTempOutputs()
+		}
+		if t.Process != nil && t.Process.StopOnFirstSuccess {
+			t.workflow.WarningLogger().Printf("| %-32s | Command of task %s failed, so trying the next task:\n%s\nOutput:\n%s\nOriginal error: %s\n", t.Name, t.ID(), cmd, string(out), err.Error())
+			t.failed = true
+			if exitErr, ok := err.(interface{ ExitCode() int }); ok {
+				t.exitCode = exitErr.ExitCode()
+			}
+			return
+		}
+		t.markFailed()
+		if _, ok := err.(*TimeoutError); ok {
+			Failf("Command timed out!\nCommand:\n%s\n\nOutput:\n%s\nOriginal error:%s\n", cmd, string(out), err.Error())
+		}
 		Failf("Command failed!\nCommand:\n%s\n\nOutput:\n%s\nOriginal error:%s\n", cmd, string(out), err.Error())
 	}
 }
 
+// removeTempOutputs removes the partially written (non-streaming) outputs of
+// the task from its temp dir
+func (t *Task) removeTempOutputs() {
+	for _, oip := range t.OutIPs {
+		if oip.doStream {
+			continue
+		}
+		tempPath := filepath.Join(t.TempDir(), oip.TempPath())
+		if err := os.Remove(tempPath); err != nil && !os.IsNotExist(err) {
+			t.workflow.WarningLogger().Printf("| %-32s | Could not remove partially written output %s: %s\n", t.Name, tempPath, err.Error())
+		}
+	}
+}
+
+// runCommandWithRetries runs the shell command cmd via bash, in the task's
+// temp dir, retrying it up to MaxRetries times if it fails with an exit code
+// that the process considers retryable, with exponential backoff between
+// attempts. The output and error of the last attempt are returned.
+func (t *Task) runCommandWithRetries(cmd string) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		out, err := t.runCommandInExecMode(cmd)
+		if err == nil || t.Process == nil || attempt > t.Process.MaxRetries || !t.Process.isRetryable(err) {
+			return out, err
+		}
+		backoff := t.Process.retryBackoff(attempt)
+		t.workflow.logAuditf(t.logName(), "Command of task %s failed (attempt %d of %d), so retrying in %v: %s", t.ID(), attempt, t.Process.MaxRetries+1, backoff, err.Error())
+		t.workflow.DebugLogger().Printf("| %-32s | Output of failed attempt %d of task %s:\n%s\n", t.Name, attempt, t.ID(), string(out))
+		t.resetTempDir()
+		time.Sleep(backoff)
+	}
+}
+
+// runCommandInExecMode runs the shell command cmd in the task's temp dir, in
+// the exec mode of the task's process
+func (t *Task) runCommandInExecMode(cmd string) ([]byte, error) {
+	if t.Process != nil && t.Process.umaskSet {
+		cmd = fmt.Sprintf("umask %04o && %s", t.Process.umask, cmd)
+	}
+	if t.Process != nil && t.Process.ExecMode == ExecModePBS {
+		return t.runOnPBS(cmd)
+	}
+	if t.Process != nil && t.Process.ExecMode == ExecModeDocker {
+		return t.runInDocker(cmd)
+	}
+	if t.Process != nil && t.Process.ExecMode == ExecModeSingularity {
+		return t.runInSingularity(cmd)
+	}
+	if t.Process != nil && t.Process.EnforceMemoryPerTask && t.Process.MemoryPerTask != "" {
+		cmd = ulimitMemoryCommand(t.Process.memoryPerTaskBytes(), cmd)
+	}
+	// cd into the task's tempdir, execute the command, and cd back
+	return t.runCommand(exec.Command("bash", "-c", "cd "+t.TempDir()+" && "+cmd+" && cd .."))
+}
+
+// resetTempDir removes anything written to the task's temp dir, such as
+// partially written outputs of a failed command, and re-creates the
+// directories needed for the outputs
+func (t *Task) resetTempDir() {
+	err := os.RemoveAll(t.TempDir())
+	CheckWithMsg(err, "Could not remove temp dir of task: "+t.TempDir())
+	t.createDirs()
+}
+
+// failedTaskMarkerFile is the name of the file written to the temp dir of a
+// failed task, containing the task's audit info
+const failedTaskMarkerFile = "failed.audit.json"
+
+// markFailed marks the task as failed, by writing its audit info to a marker
+// file in its temp dir, which is kept after the failure. This allows
+// re-running just the failed tasks, with Workflow.RunFailedOnly().
+func (t *Task) markFailed() {
+	auditInfo := NewAuditInfo()
+	auditInfo.TaskID = t.ID()
+	auditInfo.Command = t.Command
+	if t.Process != nil {
+		auditInfo.ProcessName = t.Process.Name()
+	}
+	auditInfo.Params = t.Params
+	auditInfo.Tags = t.Tags
+	for oipName, oip := range t.OutIPs {
+		auditInfo.OutFiles[oipName] = oip.Path()
+	}
+	auditJSON, err := json.MarshalIndent(auditInfo, "", "    ")
+	if err != nil {
+		t.workflow.WarningLogger().Printf("Could not marshal audit info of failed task %s: %s\n", t.Name, err.Error())
+		return
+	}
+	markerPath := filepath.Join(t.TempDir(), failedTaskMarkerFile)
+	if err := ioutil.WriteFile(markerPath, auditJSON, 0644); err != nil {
+		t.workflow.WarningLogger().Printf("Could not write failed task marker %s: %s\n", markerPath, err.Error())
+	}
+}
+
+// readFailedTaskIDs returns the ids (temp dir names) of the tasks marked as
+// failed, in the directory dir
+func readFailedTaskIDs(dir string) (map[string]bool, error) {
+	markerPaths, err := filepath.Glob(filepath.Join(dir, tempDirPrefix+".*", failedTaskMarkerFile))
+	if err != nil {
+		return nil, errWrap(err, "Could not glob for failed task markers in "+dir)
+	}
+	failedTaskIDs := map[string]bool{}
+	for _, markerPath := range markerPaths {
+		failedTaskIDs[filepath.Base(filepath.Dir(markerPath))] = true
+	}
+	return failedTaskIDs, nil
+}
+
+// validateOutputs runs the ValidateOutput function of the task's process, if
+// set, on all the task's non-streaming outputs
+func (t *Task) validateOutputs() error {
+	if t.Process == nil || t.Process.ValidateOutput == nil {
+		return nil
+	}
+	for _, oipName := range sortedFileIPMapKeys(t.OutIPs) {
+		oip := t.OutIPs[oipName]
+		if oip.doStream {
+			continue
+		}
+		// Outputs of shell commands are still in the task's temp dir, while
+		// custom Go functions might have written them directly to the temp path
+		path := t.TempDir() + "/" + oip.TempPath()
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			path = oip.TempPath()
+		}
+		if err := t.Process.ValidateOutput(path); err != nil {
+			return errWrapf(err, "Output %s (for out-port %s) is not valid", oip.Path(), oipName)
+		}
+	}
+	return nil
+}
+
 func (t *Task) writeAuditLogs(startTime time.Time, finishTime time.Time) {
 	// Append audit info for the task to all its output IPs
 	auditInfo := NewAuditInfo()
+	auditInfo.TaskID = t.ID()
 	auditInfo.Command = t.Command
 	auditInfo.ProcessName = t.Process.Name()
 	auditInfo.Params = t.Params
 	auditInfo.StartTime = startTime
 	auditInfo.FinishTime = finishTime
 	auditInfo.ExecTimeNS = finishTime.Sub(startTime)
+	if t.Process.AuditEnv {
+		auditInfo.Env = t.workflow.auditEnv()
+	}
 	// Set the audit infos from incoming IPs into the "Upstream" map
 	for inpName, iip := range t.InIPs {
 		if t.portInfos[inpName].join {
@@ -328,6 +779,25 @@ func (t *Task) writeAuditLogs(startTime time.Time, finishTime time.Time) {
 	}
 }
 
+// storeOutputsInCAS stores the (non-streaming) output files of the task in the
+// content-addressed store of the workflow, if enabled
+func (t *Task) storeOutputsInCAS() {
+	if t.workflow == nil || t.workflow.casDir == "" {
+		return
+	}
+	for _, oip := range t.OutIPs {
+		if oip.doStream {
+			continue
+		}
+		if fi, err := os.Stat(oip.Path()); err != nil || !fi.Mode().IsRegular() {
+			continue
+		}
+		if err := t.workflow.storeInCAS(oip.Path()); err != nil {
+			t.workflow.WarningLogger().Printf("| %-32s | Could not store output in content-addressed store, so leaving it as is: %s\n", t.Name, err.Error())
+		}
+	}
+}
+
 func (t *Task) atomizeIPs() {
 	outIPs := []*FileIP{}
 	for _, ip := range t.OutIPs {
@@ -375,6 +845,42 @@ var tempDirPrefix = "_scipipe_tmp"
 // values that a task takes as input, joined with dots.
 func (t *Task) TempDir() string {
 	pathPrefix := tempDirPrefix + "." + sanitizePathFragment(t.Name)
+	hashPcs := t.inputHashPieces()
+
+	// If resulting name is longer than 255
+	if len(pathPrefix) > (255 - 40 - 1) {
+		hashPcs = append(hashPcs, pathPrefix)
+		pathPrefix = tempDirPrefix
+	}
+	sha1sum := sha1.Sum([]byte(strings.Join(hashPcs, "")))
+	pathSegment := pathPrefix + "." + hex.EncodeToString(sha1sum[:])
+	return pathSegment
+}
+
+// ID returns a short, human-readable id for the task, which is stable across
+// runs, built up from the name of the task's process, the file names of the
+// task's inputs and its parameter values, joined with dots, such as:
+// align.sample_1.fq.genome_hg38.3f2a9c1
+// The id ends with a short hash of the full input paths, parameters and tags,
+// to keep it unique even for inputs with the same file name.
+func (t *Task) ID() string {
+	idPcs := []string{sanitizePathFragment(t.Name)}
+	for _, ipName := range sortedFileIPMapKeys(t.InIPs) {
+		if ipPath := t.InIP(ipName).Path(); ipPath != "" {
+			idPcs = append(idPcs, sanitizePathFragment(filepath.Base(ipPath)))
+		}
+	}
+	for _, paramName := range sortedStringMapKeys(t.Params) {
+		idPcs = append(idPcs, sanitizePathFragment(paramName+"_"+t.Param(paramName)))
+	}
+	sha1sum := sha1.Sum([]byte(strings.Join(t.inputHashPieces(), "")))
+	idPcs = append(idPcs, hex.EncodeToString(sha1sum[:])[:7])
+	return strings.Join(idPcs, ".")
+}
+
+// inputHashPieces returns the input paths, parameters and tags of the task,
+// used for computing ids unique to the task
+func (t *Task) inputHashPieces() []string {
 	hashPcs := []string{}
 	for _, ipName := range sortedFileIPMapKeys(t.InIPs) {
 		hashPcs = append(hashPcs, splitAllPaths(t.InIP(ipName).Path())...)
@@ -390,15 +896,7 @@ func (t *Task) TempDir() string {
 	for _, tagName := range sortedStringMapKeys(t.Tags) {
 		hashPcs = append(hashPcs, tagName+"_"+t.Tag(tagName))
 	}
-
-	// If resulting name is longer than 255
-	if len(pathPrefix) > (255 - 40 - 1) {
-		hashPcs = append(hashPcs, pathPrefix)
-		pathPrefix = tempDirPrefix
-	}
-	sha1sum := sha1.Sum([]byte(strings.Join(hashPcs, "")))
-	pathSegment := pathPrefix + "." + hex.EncodeToString(sha1sum[:])
-	return pathSegment
+	return hashPcs
 }
 
 func parentDirPath(path string) string {
@@ -0,0 +1,160 @@
+package scipipe
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ----------------------------------------------------------------------------
+// Task count reporting
+// ----------------------------------------------------------------------------
+
+// TaskCounts contains the numbers of tasks of a process that have been
+// created, are running, and are done, in a workflow run. Created tasks
+// include the running and done ones, as well as the ones waiting to run.
+type TaskCounts struct {
+	Process string
+	Created int
+	Running int
+	Done    int
+}
+
+// TaskCounts returns the numbers of created, running and done tasks of each
+// process in the current run of the workflow, sorted by process name, as
+// tracked in its run state (see State)
+func (wf *Workflow) TaskCounts() []TaskCounts {
+	countsByProc := map[string]*TaskCounts{}
+	for _, ts := range wf.State().Tasks {
+		counts, ok := countsByProc[ts.Process]
+		if !ok {
+			counts = &TaskCounts{Process: ts.Process}
+			countsByProc[ts.Process] = counts
+		}
+		counts.Created++
+		switch ts.Status {
+		case TaskRunning:
+			counts.Running++
+		case TaskDone:
+			counts.Done++
+		}
+	}
+	allCounts := []TaskCounts{}
+	for _, counts := range countsByProc {
+		allCounts = append(allCounts, *counts)
+	}
+	sort.Slice(allCounts, func(i, j int) bool {
+		return allCounts[i].Process < allCounts[j].Process
+	})
+	return allCounts
+}
+
+// SetProgressReporter makes the workflow log a summary line of the numbers
+// of created, running and done tasks of each process (see TaskCounts), every
+// interval while it runs, and when it has finished. When stderr is a
+// terminal, a single progress bar line is updated in place on stderr instead.
+func (wf *Workflow) SetProgressReporter(interval time.Duration) {
+	if interval <= 0 {
+		Failf("%s workflow: Progress reporting interval must be positive, but was %s\n", wf.name, interval)
+	}
+	r := &taskCountReporter{
+		name:     wf.name + "_task_count_reporter",
+		workflow: wf,
+		interval: interval,
+	}
+	if isTerminal(os.Stderr) {
+		r.barOut = os.Stderr
+	}
+	wf.AddBackgroundProc(r)
+}
+
+// taskCountReporter is a background process reporting the task counts of a
+// workflow periodically
+type taskCountReporter struct {
+	name     string
+	workflow *Workflow
+	interval time.Duration
+	// barOut is where the progress bar is written, or nil if summary lines
+	// are logged instead
+	barOut io.Writer
+}
+
+// Name returns the name of the taskCountReporter process
+func (r *taskCountReporter) Name() string { return r.name }
+
+// Run runs the taskCountReporter process, until stop is closed
+func (r *taskCountReporter) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			r.report()
+			if r.barOut != nil {
+				fmt.Fprintln(r.barOut)
+			}
+			return
+		case <-ticker.C:
+			r.report()
+		}
+	}
+}
+
+// report reports the current task counts of the workflow
+func (r *taskCountReporter) report() {
+	counts := r.workflow.TaskCounts()
+	if r.barOut != nil {
+		fmt.Fprint(r.barOut, "\r"+formatTaskCountBar(counts, 30))
+		return
+	}
+	r.workflow.Logger().Printf("| workflow:%-23s | %s", r.workflow.Name(), formatTaskCounts(counts))
+}
+
+// formatTaskCounts returns a summary line of the task counts, with the totals
+// for all processes first
+func formatTaskCounts(counts []TaskCounts) string {
+	total := sumTaskCounts(counts)
+	procSummaries := []string{}
+	for _, c := range counts {
+		procSummaries = append(procSummaries, fmt.Sprintf("%s: %d/%d done, %d running", c.Process, c.Done, c.Created, c.Running))
+	}
+	summary := fmt.Sprintf("Tasks: %d/%d done, %d running", total.Done, total.Created, total.Running)
+	if len(procSummaries) > 0 {
+		summary += " (" + strings.Join(procSummaries, "; ") + ")"
+	}
+	return summary
+}
+
+// formatTaskCountBar returns a progress bar of width characters, for the
+// fraction of all created tasks that are done, followed by the total counts
+func formatTaskCountBar(counts []TaskCounts, width int) string {
+	total := sumTaskCounts(counts)
+	filled := 0
+	if total.Created > 0 {
+		filled = width * total.Done / total.Created
+	}
+	return fmt.Sprintf("[%s%s] %d/%d tasks done, %d running", strings.Repeat("#", filled), strings.Repeat("-", width-filled), total.Done, total.Created, total.Running)
+}
+
+// sumTaskCounts returns the sums of the task counts of all processes
+func sumTaskCounts(counts []TaskCounts) TaskCounts {
+	total := TaskCounts{}
+	for _, c := range counts {
+		total.Created += c.Created
+		total.Running += c.Running
+		total.Done += c.Done
+	}
+	return total
+}
+
+// isTerminal tells whether f is a terminal (character device)
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
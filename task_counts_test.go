@@ -0,0 +1,56 @@
+package scipipe
+
+import (
+	"bytes"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSetProgressReporter(t *testing.T) {
+	initTestLogs()
+	buf := &bytes.Buffer{}
+	wf := NewWorkflow("test_wf", 4)
+	wf.SetLogger(log.New(buf, "", 0))
+	wf.SetProgressReporter(10 * time.Millisecond)
+	src := NewParamSource(wf, "src", "1", "2", "3")
+	slow := wf.NewProc("slow", "sleep 0.05 && echo {p:n} > {o:out}")
+	slow.InParam("n").From(src.Out())
+	slow.SetOut("out", "/tmp/task_counts_{p:n}.txt")
+	cpy := wf.NewProc("cpy", "cat {i:in} > {o:out}")
+	cpy.In("in").From(slow.Out("out"))
+	cpy.SetOut("out", "{i:in}.copy")
+	wf.Run()
+	defer cleanFilePatterns("/tmp/task_counts_*")
+
+	expected := []TaskCounts{
+		{Process: "cpy", Created: 3, Running: 0, Done: 3},
+		{Process: "slow", Created: 3, Running: 0, Done: 3},
+	}
+	if !reflect.DeepEqual(wf.TaskCounts(), expected) {
+		t.Errorf("Expected task counts %v, got %v", expected, wf.TaskCounts())
+	}
+	logged := buf.String()
+	if !strings.Contains(logged, "Tasks: 6/6 done, 0 running (cpy: 3/3 done, 0 running; slow: 3/3 done, 0 running)") {
+		t.Errorf("Expected final task counts to be logged, got:\n%s", logged)
+	}
+	if strings.Count(logged, "Tasks: ") < 2 {
+		t.Errorf("Expected task counts to be logged periodically while running, got:\n%s", logged)
+	}
+}
+
+func TestFormatTaskCountBar(t *testing.T) {
+	counts := []TaskCounts{
+		{Process: "a", Created: 4, Running: 1, Done: 2},
+		{Process: "b", Created: 4, Running: 0, Done: 4},
+	}
+	expected := "[#######---] 6/8 tasks done, 1 running"
+	if bar := formatTaskCountBar(counts, 10); bar != expected {
+		t.Errorf("Expected progress bar %q, got %q", expected, bar)
+	}
+	if bar := formatTaskCountBar(nil, 4); bar != "[----] 0/0 tasks done, 0 running" {
+		t.Errorf("Expected empty progress bar without tasks, got %q", bar)
+	}
+}
@@ -3,8 +3,11 @@ package scipipe
 import (
 	"io/ioutil"
 	"os"
+	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestTempDirsExist(t *testing.T) {
@@ -29,6 +32,33 @@ func TestTempDir(t *testing.T) {
 	}
 }
 
+func TestTaskID(t *testing.T) {
+	newTask := func(inPath string, param string) *Task {
+		return NewTask(nil, nil, "align", "echo foo", map[string]*FileIP{"in": NewFileIP(inPath)}, nil, nil, map[string]string{"genome": param}, nil, "", nil, 1)
+	}
+
+	tsk := newTask("data/sample_1.fq", "hg38")
+	expectedPrefix := "align.sample_1.fq.genome_hg38."
+	if !strings.HasPrefix(tsk.ID(), expectedPrefix) || len(tsk.ID()) != len(expectedPrefix)+7 {
+		t.Errorf("Expected task id on the form %s[7 char hash], got %s", expectedPrefix, tsk.ID())
+	}
+	if tsk.ID() != newTask("data/sample_1.fq", "hg38").ID() {
+		t.Errorf("Expected task ids to be stable for the same inputs and params")
+	}
+
+	// Ids should be unique across a fan-out
+	ids := map[string]bool{}
+	for _, inPath := range []string{"data/sample_1.fq", "data/sample_2.fq", "other/sample_1.fq"} {
+		for _, param := range []string{"hg19", "hg38"} {
+			id := newTask(inPath, param).ID()
+			if ids[id] {
+				t.Errorf("Task id %s is not unique", id)
+			}
+			ids[id] = true
+		}
+	}
+}
+
 func TestTempDirNotOver255(t *testing.T) {
 	longFileName := "very_long_filename_______________________________50_______________________________________________100_______________________________________________150_______________________________________________200_______________________________________________250__255_____"
 	tsk := NewTask(nil, nil, "test_task", "echo foo", map[string]*FileIP{"in1": NewFileIP(longFileName), "in2": NewFileIP("infile2.txt")}, nil, nil, map[string]string{"p1": "p1val", "p2": "p2val"}, nil, "", nil, 4)
@@ -86,3 +116,220 @@ func TestExtraFilesAtomizeAbsolute(t *testing.T) {
 		t.Error("Atomize removed absolute directory")
 	}
 }
+
+func TestFormatCommandPathModifiers(t *testing.T) {
+	portInfos := map[string]*PortInfo{"in": &PortInfo{portType: "i"}}
+	inIPs := map[string]*FileIP{"in": NewFileIP("data/sub/foo.txt")}
+
+	cmdsAndExpected := map[string]string{
+		"cat {i:in}":                            "cat ../data/sub/foo.txt",
+		"cat {i:in|basename}":                   "cat foo.txt",
+		"cd {i:in|dir}":                         "cd ../data/sub",
+		"echo {i:in|noext}":                     "echo ../data/sub/foo",
+		"echo {i:in|basename|noext}":            "echo foo",
+		"cp {i:in} {i:in|basename}":             "cp ../data/sub/foo.txt foo.txt",
+		"echo {i:in|dir|basename} {i:in|%.txt}": "echo sub ../data/sub/foo",
+	}
+	for cmd, expected := range cmdsAndExpected {
+		actual := formatCommand(getShellCommandPlaceHolderRegex("{", "}"), cmd, portInfos, inIPs, nil, nil, nil, nil, "")
+		if actual != expected {
+			t.Errorf("Wrong command formatted for pattern '%s'. Got: '%s' Expected: '%s'", cmd, actual, expected)
+		}
+	}
+}
+
+func TestValidateOutputs(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	p := wf.NewProc("make_empty", "touch {o:out}")
+	p.SetOut("out", "validate_outputs_test.txt")
+	p.ValidateOutput = OutputNotEmpty
+
+	tsk := NewTask(wf, p, "make_empty", p.CommandPattern, map[string]*FileIP{}, p.PathFuncs, p.PortInfo, map[string]string{}, map[string]string{}, "", nil, 1)
+	tsk.createDirs()
+	defer os.RemoveAll(tsk.TempDir())
+
+	tempPath := filepath.Join(tsk.TempDir(), tsk.OutIP("out").TempPath())
+	err := ioutil.WriteFile(tempPath, []byte{}, 0644)
+	Check(err)
+	if err := tsk.validateOutputs(); err == nil {
+		t.Error("Expected empty output file to be rejected, but it was not")
+	}
+
+	err = ioutil.WriteFile(tempPath, []byte("foo\n"), 0644)
+	Check(err)
+	if err := tsk.validateOutputs(); err != nil {
+		t.Errorf("Expected non-empty output file to be accepted, but got error: %v", err)
+	}
+}
+
+func TestAuditEnv(t *testing.T) {
+	initTestLogs()
+
+	os.Setenv("SCIPIPE_TEST_AUDIT_VAR", "foo")
+	os.Setenv("SCIPIPE_TEST_AUDIT_TOKEN", "s3cr3t-t0k3n")
+	defer os.Unsetenv("SCIPIPE_TEST_AUDIT_VAR")
+	defer os.Unsetenv("SCIPIPE_TEST_AUDIT_TOKEN")
+
+	wf := NewWorkflow("test_wf", 4)
+	wf.RegisterSecret("SCIPIPE_TEST_AUDIT_TOKEN")
+	p := wf.NewProc("print_var", "echo $SCIPIPE_TEST_AUDIT_VAR > {o:out}")
+	p.SetOut("out", "/tmp/audit_env.txt")
+	p.AuditEnv = true
+	wf.Run()
+
+	auditPath := "/tmp/audit_env.txt.audit.json"
+	auditJSON, err := ioutil.ReadFile(auditPath)
+	Check(err)
+	auditInfo := UnmarshalAuditInfoJSONFile(auditPath)
+	if auditInfo.Env["SCIPIPE_TEST_AUDIT_VAR"] != "foo" {
+		t.Errorf("Expected environment variable to be captured in audit info, but got: '%s'", auditInfo.Env["SCIPIPE_TEST_AUDIT_VAR"])
+	}
+	if auditInfo.Env["SCIPIPE_TEST_AUDIT_TOKEN"] != redactedValue {
+		t.Errorf("Expected secret to be redacted in audit info, but got: '%s'", auditInfo.Env["SCIPIPE_TEST_AUDIT_TOKEN"])
+	}
+	if strings.Contains(string(auditJSON), "s3cr3t-t0k3n") {
+		t.Errorf("Secret value found in audit file %s", auditPath)
+	}
+	cleanFiles("/tmp/audit_env.txt")
+}
+
+func TestRetryableExitCodes(t *testing.T) {
+	initTestLogs()
+	attemptsPath := "/tmp/retry_attempts.txt"
+	defer cleanFiles(attemptsPath)
+
+	// The command appends a line to the attempts file for each attempt, and
+	// fails with the given exit code for the first two attempts
+	runTask := func(exitCode string) (int, error) {
+		os.Remove(attemptsPath)
+		wf := NewWorkflow("test_wf", 4)
+		p := wf.NewProc("flaky", "echo x >> "+attemptsPath+"; [ $(wc -l < "+attemptsPath+") -gt 2 ] || exit "+exitCode+"; echo foo > {o:out}")
+		p.SetOut("out", "retry_test.txt")
+		p.MaxRetries = 3
+		p.RetryableExitCodes = []int{75}
+
+		tsk := NewTask(wf, p, "flaky", p.CommandPattern, map[string]*FileIP{}, p.PathFuncs, p.PortInfo, map[string]string{}, map[string]string{}, "", nil, 1)
+		tsk.createDirs()
+		defer os.RemoveAll(tsk.TempDir())
+		_, err := tsk.runCommandWithRetries(tsk.Command)
+
+		attempts, readErr := ioutil.ReadFile(attemptsPath)
+		Check(readErr)
+		return strings.Count(string(attempts), "\n"), err
+	}
+
+	attempts, err := runTask("1")
+	if err == nil {
+		t.Error("Expected command failing with non-retryable exit code to fail")
+	}
+	if attempts != 1 {
+		t.Errorf("Expected command failing with non-retryable exit code to be run once, but was run %d times", attempts)
+	}
+
+	attempts, err = runTask("75")
+	if err != nil {
+		t.Errorf("Expected command failing with retryable exit code to succeed on retry, but got error: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("Expected command failing with retryable exit code to be run 3 times, but was run %d times", attempts)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	initTestLogs()
+	attemptsPath := "/tmp/retry_backoff_attempts.txt"
+	defer cleanFiles(attemptsPath)
+
+	wf := NewWorkflow("test_wf", 4)
+	// The command fails, with exit code 75, for the first two attempts,
+	// after leaving a partially written output behind
+	p := wf.NewProc("flaky", "echo x >> "+attemptsPath+"; echo partial >> {o:out}; [ $(wc -l < "+attemptsPath+") -gt 2 ] || exit 75; echo foo >> {o:out}")
+	p.SetOut("out", "retry_backoff_test.txt")
+	p.MaxRetries = 2
+	p.RetryBackoff = 50 * time.Millisecond
+	p.RetryableExitCode = func(exitCode int) bool { return exitCode == 75 }
+	wf.Run()
+	defer cleanFiles("retry_backoff_test.txt")
+
+	out, err := ioutil.ReadFile("retry_backoff_test.txt")
+	Check(err)
+	if string(out) != "partial\nfoo\n" {
+		t.Errorf("Expected output of the last attempt only, but got: '%s'", string(out))
+	}
+
+	if p.retryBackoff(1) != 50*time.Millisecond || p.retryBackoff(2) != 100*time.Millisecond || p.retryBackoff(3) != 200*time.Millisecond {
+		t.Errorf("Expected retry backoff to double for each attempt, got: %v, %v, %v", p.retryBackoff(1), p.retryBackoff(2), p.retryBackoff(3))
+	}
+	stats := wf.TaskStats()
+	if len(stats) != 1 || stats[0].Duration() < 150*time.Millisecond {
+		t.Errorf("Expected task to take at least 150ms, including backoff between retries, got: %v", stats)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	// The sleep in the background keeps the output pipe open, so the command
+	// only finishes quickly if the whole process group is killed
+	p := wf.NewProc("hanging", "echo partial > {o:out}; sleep 30 & wait")
+	p.SetOut("out", "timeout_test.txt")
+	p.Timeout = 200 * time.Millisecond
+
+	tsk := NewTask(wf, p, "hanging", p.CommandPattern, map[string]*FileIP{}, p.PathFuncs, p.PortInfo, map[string]string{}, map[string]string{}, "", nil, 1)
+	tsk.createDirs()
+	defer os.RemoveAll(tsk.TempDir())
+
+	start := time.Now()
+	_, err := tsk.runCommandWithRetries(tsk.Command)
+	elapsed := time.Since(start)
+	if _, ok := err.(*TimeoutError); !ok {
+		t.Fatalf("Expected a timeout error for the hanging command, got: %v", err)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("Expected the hanging command to be killed after the timeout, but it took %v", elapsed)
+	}
+
+	tempPath := filepath.Join(tsk.TempDir(), tsk.OutIP("out").TempPath())
+	if _, err := os.Stat(tempPath); err != nil {
+		t.Fatalf("Expected partially written output to exist before clean-up: %v", err)
+	}
+	tsk.removeTempOutputs()
+	if _, err := os.Stat(tempPath); !os.IsNotExist(err) {
+		t.Errorf("Expected partially written output to be removed: %s", tempPath)
+	}
+
+	// Commands not timing out should not be affected
+	if err := runWithTimeout(exec.Command("bash", "-c", "true"), time.Minute); err != nil {
+		t.Errorf("Expected command finishing before the timeout to succeed, got: %v", err)
+	}
+}
+
+func TestUmask(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	private := wf.NewProc("private", "echo private > {o:out}")
+	private.SetOut("out", "/tmp/umask_test_private.txt")
+	private.SetUmask(0077)
+	shared := wf.NewProc("shared", "echo shared > {o:out}")
+	shared.SetOut("out", "/tmp/umask_test_shared.txt")
+	shared.SetUmask(0002)
+	// A umask of 0000 must be set too, rather than taken as unset
+	world := wf.NewProc("world", "echo world > {o:out}")
+	world.SetOut("out", "/tmp/umask_test_world.txt")
+	world.SetUmask(0000)
+	wf.Run()
+	defer cleanFiles("/tmp/umask_test_private.txt", "/tmp/umask_test_shared.txt", "/tmp/umask_test_world.txt")
+
+	for path, expectedPerm := range map[string]os.FileMode{
+		"/tmp/umask_test_private.txt": 0600,
+		"/tmp/umask_test_shared.txt":  0664,
+		"/tmp/umask_test_world.txt":   0666,
+	} {
+		fi, err := os.Stat(path)
+		Check(err)
+		if fi.Mode().Perm() != expectedPerm {
+			t.Errorf("Expected %s to have permissions %v, got %v", path, expectedPerm, fi.Mode().Perm())
+		}
+	}
+}
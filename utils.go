@@ -7,7 +7,9 @@ import (
 	"os/exec"
 	"path/filepath"
 	re "regexp"
+	"strings"
 	"time"
+	"unicode"
 
 	"errors"
 )
@@ -53,15 +55,60 @@ func Failf(msg string, vs ...interface{}) {
 	Fail(fmt.Sprintf(msg, vs...))
 }
 
+// OutputNotEmpty returns an error if the file at path does not exist or is
+// empty. It is meant to be used as a Process.ValidateOutput function.
+func OutputNotEmpty(path string) error {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return errWrap(err, "Could not stat file: "+path)
+	}
+	if fi.Size() == 0 {
+		return errors.New("File is empty: " + path)
+	}
+	return nil
+}
+
 // Return the regular expression used to parse the place-holder syntax for in-, out- and
-// parameter ports, that can be used to instantiate a Process.
-func getShellCommandPlaceHolderRegex() *re.Regexp {
-	regex := "{(o|os|i|is|p|t):([^{}]+)}"
-	r, err := re.Compile(regex)
-	CheckWithMsg(err, "Could not compile regex: "+regex)
+// parameter ports, that can be used to instantiate a Process, with the
+// place-holders delimited by open and close, such as "{" and "}". Port names
+// can not contain any of the characters of the delimiters.
+func getShellCommandPlaceHolderRegex(open string, close string) *re.Regexp {
+	r, err := compilePlaceHolderRegex(open, close)
+	CheckWithMsg(err, "Could not compile place-holder regex")
 	return r
 }
 
+// compilePlaceHolderRegex compiles the regular expression for place-holders
+// delimited by open and close (see getShellCommandPlaceHolderRegex)
+func compilePlaceHolderRegex(open string, close string) (*re.Regexp, error) {
+	regex := re.QuoteMeta(open) + "(o|os|i|i\\*|is|p|t):([^" + quoteCharClass(open+close) + "]+)" + re.QuoteMeta(close)
+	r, err := re.Compile(regex)
+	if err != nil {
+		return nil, errWrap(err, "Could not compile regex: "+regex)
+	}
+	return r, nil
+}
+
+// quoteCharClass escapes all ASCII punctuation characters in chars, such as
+// '-', '^' and ']', which have special meanings inside a character class, so
+// that chars can be used as the characters of a class
+func quoteCharClass(chars string) string {
+	quoted := ""
+	for _, c := range chars {
+		if c < 128 && !unicode.IsLetter(c) && !unicode.IsDigit(c) {
+			quoted += "\\"
+		}
+		quoted += string(c)
+	}
+	return quoted
+}
+
+// shellQuote quotes str with single quotes, for use as a single argument in a
+// shell command
+func shellQuote(str string) string {
+	return "'" + strings.Replace(str, "'", "'\\''", -1) + "'"
+}
+
 var letters = []byte("abcdefghijklmnopqrstuvwxyz0123456789")
 
 func randSeqLC(n int) string {
@@ -14,7 +14,7 @@ func TestExecCmd_EchoFooBar(t *testing.T) {
 }
 
 func TestRegexPatternMatchesCases(t *testing.T) {
-	r := getShellCommandPlaceHolderRegex()
+	r := getShellCommandPlaceHolderRegex("{", "}")
 	placeHolders := []string{
 		"{i:hej}",
 		"{is:hej}",
@@ -33,3 +33,21 @@ func TestRegexPatternMatchesCases(t *testing.T) {
 		}
 	}
 }
+
+func TestRegexPatternSpecialDelimiters(t *testing.T) {
+	// Delimiters with characters special in character classes, such as '-',
+	// '^', ']' and '\', should be usable
+	for _, delims := range [][2]string{
+		{"<-", "->"},
+		{"[^", "^]"},
+		{"\\[", "]\\"},
+		{"%%", "%%"},
+	} {
+		r := getShellCommandPlaceHolderRegex(delims[0], delims[1])
+		cmd := "cat " + delims[0] + "i:in" + delims[1] + " > " + delims[0] + "o:out|.txt" + delims[1]
+		matches := r.FindAllStringSubmatch(cmd, -1)
+		if len(matches) != 2 || matches[0][2] != "in" || matches[1][2] != "out|.txt" {
+			t.Errorf("Expected placeholders with delimiters %s and %s to be found in %q, got: %v", delims[0], delims[1], cmd, matches)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package scipipe
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Validating the workflow
+// ----------------------------------------------------------------------------
+
+// Validate checks that the workflow can run without deadlocking, which it can
+// not if any in-port or param in-port of its processes is left unconnected,
+// unless marked optional (see InPort.SetOptional), or if the connections
+// between its processes form a cycle. The returned error lists all problems
+// found, or is nil if there are none. Validate is called when the workflow is
+// run, which fails if there are any problems. Unconnected out-ports are not
+// problems, since they are connected to the sink of the workflow when it runs.
+func (wf *Workflow) Validate() error {
+	return validateProcs(wf.procsAndDriver(wf.procs))
+}
+
+// procsAndDriver returns procs, together with the driver process of the
+// workflow, if it is not the sink, since the driver is removed from the
+// processes of the workflow when it is run
+func (wf *Workflow) procsAndDriver(procs map[string]WorkflowProcess) map[string]WorkflowProcess {
+	allProcs := map[string]WorkflowProcess{}
+	for name, proc := range procs {
+		allProcs[name] = proc
+	}
+	if wf.driver != nil && wf.driver != WorkflowProcess(wf.sink) {
+		allProcs[wf.driver.Name()] = wf.driver
+	}
+	return allProcs
+}
+
+// validateProcs returns an error listing all unconnected, non-optional
+// in-ports and param in-ports of procs, and all cycles in the connections
+// between them, or nil if there are none
+func validateProcs(procs map[string]WorkflowProcess) error {
+	problems := []string{}
+	for _, procName := range sortedWFProcMapKeys(procs) {
+		proc := procs[procName]
+		for _, iptName := range sortedInPortMapKeys(proc.InPorts()) {
+			ipt := proc.InPorts()[iptName]
+			if !ipt.Ready() && !ipt.Optional() {
+				problems = append(problems, fmt.Sprintf("In-port '%s' of process '%s' is not connected (connect it, or mark it optional with SetOptional)", iptName, procName))
+			}
+		}
+		for _, pipName := range sortedInParamPortMapKeys(proc.InParamPorts()) {
+			if !proc.InParamPorts()[pipName].Ready() {
+				problems = append(problems, fmt.Sprintf("Param in-port '%s' of process '%s' is not connected", pipName, procName))
+			}
+		}
+		if p, ok := proc.(*Process); ok && p.MemoryPerTask != "" {
+			if bytes, err := parseMemorySize(p.MemoryPerTask); err != nil {
+				problems = append(problems, fmt.Sprintf("MemoryPerTask of process '%s' is not valid: %s", procName, err.Error()))
+			} else if int64(p.MaxMemoryMB)*memorySizeUnits["M"] > bytes {
+				problems = append(problems, fmt.Sprintf("MaxMemoryMB (%d) of process '%s' is larger than its MemoryPerTask (%s), so its tasks are expected to use more memory than they may", p.MaxMemoryMB, procName, p.MemoryPerTask))
+			}
+		}
+	}
+	for _, cycle := range dagCycles(procs) {
+		problems = append(problems, "Processes are connected in a cycle: "+strings.Join(cycle, " -> "))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+	return errors.New("Workflow is not valid:\n- " + strings.Join(problems, "\n- "))
+}
+
+// dagCycles returns the cycles in the connections between procs, each as the
+// names of the processes along the cycle, in the direction of the
+// connections, starting and ending with the same process
+func dagCycles(procs map[string]WorkflowProcess) [][]string {
+	// Connections are followed from the out-ports of the processes, since
+	// parameters fed to a process with InParamPort.FromStr are sent from
+	// ports belonging to the process itself, which are not out-ports
+	downstream := map[string][]string{}
+	for _, procName := range sortedWFProcMapKeys(procs) {
+		downNames := map[string]bool{}
+		for _, opt := range procs[procName].OutPorts() {
+			for _, rpt := range opt.RemotePorts {
+				downNames[rpt.Process().Name()] = true
+			}
+		}
+		for _, pop := range procs[procName].OutParamPorts() {
+			for _, rpt := range pop.RemotePorts {
+				downNames[rpt.Process().Name()] = true
+			}
+		}
+		for downName := range downNames {
+			if _, ok := procs[downName]; ok {
+				downstream[procName] = append(downstream[procName], downName)
+			}
+		}
+		sort.Strings(downstream[procName])
+	}
+
+	cycles := [][]string{}
+	visited := map[string]bool{}
+	onPath := map[string]bool{}
+	path := []string{}
+	var visit func(name string)
+	visit = func(name string) {
+		visited[name] = true
+		onPath[name] = true
+		path = append(path, name)
+		for _, downName := range downstream[name] {
+			if onPath[downName] {
+				// The cycle is the part of the path from the downstream
+				// process, back to itself
+				for i, pathName := range path {
+					if pathName == downName {
+						cycle := append([]string{}, path[i:]...)
+						cycles = append(cycles, append(cycle, downName))
+					}
+				}
+			} else if !visited[downName] {
+				visit(downName)
+			}
+		}
+		path = path[:len(path)-1]
+		onPath[name] = false
+	}
+	for _, procName := range sortedWFProcMapKeys(procs) {
+		if !visited[procName] {
+			visit(procName)
+		}
+	}
+	return cycles
+}
+
+// closeUnconnectedOptionalInPorts closes the channels of the unconnected
+// optional in-ports of procs, so that their processes do not wait for IPs on
+// them
+func closeUnconnectedOptionalInPorts(procs map[string]WorkflowProcess) {
+	for _, proc := range procs {
+		for _, ipt := range proc.InPorts() {
+			if ipt.Optional() && !ipt.Ready() {
+				// A new channel is closed, so that the workflow can be run
+				// more than once
+				ipt.Chan = make(chan *FileIP)
+				close(ipt.Chan)
+			}
+		}
+	}
+}
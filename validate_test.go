@@ -0,0 +1,90 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	foo := wf.NewProc("foo", "echo foo > {o:out}")
+	foo.SetOut("out", "/tmp/validate_foo.txt")
+	bar := wf.NewProc("bar", "cat {i:in} {i:other} > {o:out}; echo {p:param}")
+	bar.In("in").From(foo.Out("out"))
+	bar.SetOut("out", "{i:in}.bar")
+	// A cycle: baz -> qux -> baz
+	baz := wf.NewProc("baz", "cat {i:in} > {o:out}")
+	qux := wf.NewProc("qux", "cat {i:in} > {o:out}")
+	baz.In("in").From(bar.Out("out"))
+	qux.In("in").From(baz.Out("out"))
+	baz.In("in").From(qux.Out("out"))
+
+	err := wf.Validate()
+	if err == nil {
+		t.Fatal("Expected workflow with unconnected ports and a cycle not to be valid")
+	}
+	for _, expected := range []string{
+		"In-port 'other' of process 'bar' is not connected",
+		"Param in-port 'param' of process 'bar' is not connected",
+		"Processes are connected in a cycle: baz -> qux -> baz",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, got: %s", expected, err.Error())
+		}
+	}
+	if strings.Contains(err.Error(), "'in' of process 'bar'") {
+		t.Errorf("Expected no error for connected in-port, got: %s", err.Error())
+	}
+}
+
+func TestValidateOptionalInPort(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("test_wf", 4)
+	foo := wf.NewProc("foo", "echo foo > {o:out}")
+	foo.SetOut("out", "/tmp/validate_optional_foo.txt")
+	merge := newOptionalInPortMerger(wf, "merge")
+	merge.InPort("in").From(foo.Out("out"))
+	cpy := wf.NewProc("cpy", "cat {i:in} > {o:out}")
+	cpy.In("in").From(merge.OutPort("out"))
+	cpy.SetOut("out", "{i:in}.copy")
+
+	if err := wf.Validate(); err != nil {
+		t.Fatalf("Expected workflow with unconnected optional in-port to be valid, got: %s", err.Error())
+	}
+	wf.Run()
+	defer cleanFiles("/tmp/validate_optional_foo.txt", "/tmp/validate_optional_foo.txt.copy")
+
+	out, err := ioutil.ReadFile("/tmp/validate_optional_foo.txt.copy")
+	Check(err)
+	if string(out) != "foo\n" {
+		t.Errorf("Expected output 'foo', got %q", out)
+	}
+}
+
+// optionalInPortMerger forwards the IPs received on its in-port "in", and its
+// optional in-port "extra", to its out-port
+type optionalInPortMerger struct {
+	BaseProcess
+}
+
+func newOptionalInPortMerger(wf *Workflow, name string) *optionalInPortMerger {
+	p := &optionalInPortMerger{BaseProcess: NewBaseProcess(wf, name)}
+	p.InitInPort(p, "in")
+	p.InitInPort(p, "extra")
+	p.InPort("extra").SetOptional(true)
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+func (p *optionalInPortMerger) Run() {
+	defer p.CloseAllOutPorts()
+	for ip := range p.InPort("in").Chan {
+		p.OutPort("out").Send(ip)
+	}
+	for ip := range p.InPort("extra").Chan {
+		p.OutPort("out").Send(ip)
+	}
+}
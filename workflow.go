@@ -7,7 +7,11 @@ package scipipe
 
 import (
 	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
 	"os"
+	"path/filepath"
 	"regexp"
 	"sort"
 	"strings"
@@ -26,14 +30,62 @@ import (
 // methods for creating new processes, that automatically gets plugged in to the
 // workflow on creation
 type Workflow struct {
-	name              string
-	procs             map[string]WorkflowProcess
-	concurrentTasks   chan struct{}
-	concurrentTasksMx sync.Mutex
-	sink              *Sink
-	driver            WorkflowProcess
-	logFile           string
-	PlotConf          WorkflowPlotConf
+	name            string
+	procs           map[string]WorkflowProcess
+	concurrentTasks chan struct{}
+	sink            *Sink
+	driver          WorkflowProcess
+	logFile         string
+	PlotConf        WorkflowPlotConf
+	// AutoTeeStreams makes streaming out-ports connected to more than one
+	// in-port fan out their streams to all of them, via a separate FIFO file
+	// for each in-port. Otherwise, such connections make the workflow fail
+	// before running, since a FIFO file can only be read by one reader.
+	AutoTeeStreams bool
+	// DryRun makes tasks print their formatted commands and the output files
+	// they would create, as one line of JSON per task, to stdout, rather than
+	// executing. Their output IPs are still sent downstream, without any
+	// files being created, so that the commands of all downstream tasks are
+	// printed, too. Components reading the content of files do not support
+	// dry runs.
+	DryRun           bool
+	taskStats        []TaskStats
+	taskStatsMx      sync.Mutex
+	failedTasksOnly  map[string]bool
+	resources        map[string]*resource
+	maxInFlight      int
+	backgroundProcs  map[string]BackgroundProcess
+	paused           bool
+	schedCond        *sync.Cond
+	slotRequests     []*slotRequest
+	slotRequestSeq   int
+	logger           *log.Logger
+	warningLogger    *log.Logger
+	debugLogger      *log.Logger
+	secrets          map[string]bool
+	progressHandler  func(ProgressEvent)
+	casDir           string
+	casMx            sync.Mutex
+	taskStates       map[string]*TaskState
+	taskStatesMx     sync.Mutex
+	restoredStatuses map[string]string
+	fifoDir          string
+	dryRunOut        io.Writer
+	dryRunOutMx      sync.Mutex
+	cacheMode        CacheMode
+	diskHardLimits   []diskHardLimit
+	runFromProcs     map[string]bool
+	runFromExecuted  map[string]bool
+	auditFilePath    string
+	auditTasks       []AuditReportTask
+	auditTasksMx     sync.Mutex
+	ipPostProcessor  func(*FileIP)
+	events           chan Event
+	eventsMx         sync.Mutex
+	placeHolderOpen  string
+	placeHolderClose string
+	procStatuses     map[string]*ProcStatus
+	procStatusesMx   sync.Mutex
 }
 
 // WorkflowPlotConf contains configuraiton for plotting the workflow as a graph
@@ -53,6 +105,15 @@ type WorkflowProcess interface {
 	Run()
 }
 
+// BackgroundProcess is an interface for processes that are not connected to
+// other processes, but run in the background while the workflow runs, such as
+// for monitoring. Run should return when the stop channel is closed, which
+// happens when the workflow has finished.
+type BackgroundProcess interface {
+	Name() string
+	Run(stop <-chan struct{})
+}
+
 // ----------------------------------------------------------------------------
 // Factory function(s)
 // ----------------------------------------------------------------------------
@@ -86,6 +147,11 @@ func newWorkflowWithoutLogging(name string, maxConcurrentTasks int) *Workflow {
 		procs:           map[string]WorkflowProcess{},
 		concurrentTasks: make(chan struct{}, maxConcurrentTasks),
 		PlotConf:        WorkflowPlotConf{EdgeLabels: true},
+		resources:       map[string]*resource{},
+		backgroundProcs: map[string]BackgroundProcess{},
+		schedCond:       sync.NewCond(&sync.Mutex{}),
+		secrets:         map[string]bool{},
+		taskStates:      map[string]*TaskState{},
 	}
 	sink := NewSink(wf, name+"_default_sink")
 	wf.sink = sink
@@ -147,6 +213,15 @@ func (wf *Workflow) AddProc(proc WorkflowProcess) {
 	wf.procs[proc.Name()] = proc
 }
 
+// AddBackgroundProc adds a BackgroundProcess to the workflow, to be run in the
+// background while the workflow runs, and stopped when it has finished
+func (wf *Workflow) AddBackgroundProc(proc BackgroundProcess) {
+	if wf.backgroundProcs[proc.Name()] != nil {
+		Failf(wf.name+" workflow: A background process with name '%s' already exists in the workflow! Use a more unique name!\n", proc.Name())
+	}
+	wf.backgroundProcs[proc.Name()] = proc
+}
+
 // AddProcs takes one or many Processes and adds them to the workflow, to be run
 // when the workflow runs.
 func (wf *Workflow) AddProcs(procs ...WorkflowProcess) {
@@ -171,30 +246,261 @@ func (wf *Workflow) SetSink(sink *Sink) {
 // IncConcurrentTasks increases the conter for how many concurrent tasks are
 // currently running in the workflow
 func (wf *Workflow) IncConcurrentTasks(slots int) {
-	// We must lock so that multiple processes don't end up with partially "filled slots"
-	wf.concurrentTasksMx.Lock()
+	wf.incConcurrentTasksWithPriority(slots, 0)
+}
+
+// incConcurrentTasksWithPriority increases the counter for how many concurrent
+// tasks are currently running in the workflow, like IncConcurrentTasks, but
+// when multiple tasks are waiting for slots, the ones with higher priority get
+// them first, while ones with the same priority get them in the order they
+// started waiting
+func (wf *Workflow) incConcurrentTasksWithPriority(slots int, priority int) {
+	wf.schedCond.L.Lock()
+	wf.slotRequestSeq++
+	req := &slotRequest{priority: priority, seq: wf.slotRequestSeq}
+	wf.slotRequests = append(wf.slotRequests, req)
+	for wf.paused || wf.nextSlotRequest() != req || cap(wf.concurrentTasks)-len(wf.concurrentTasks) < slots {
+		wf.schedCond.Wait()
+	}
+	wf.removeSlotRequest(req)
+	// Slots are only taken while holding the lock, and there are enough free
+	// ones, so this will not block
 	for i := 0; i < slots; i++ {
 		wf.concurrentTasks <- struct{}{}
-		Debug.Println("Increased concurrent tasks")
+		wf.DebugLogger().Println("Increased concurrent tasks")
 	}
-	wf.concurrentTasksMx.Unlock()
+	wf.schedCond.L.Unlock()
+	// The next request in line might be able to get slots too
+	wf.schedCond.Broadcast()
 }
 
 // DecConcurrentTasks decreases the conter for how many concurrent tasks are
 // currently running in the workflow
 func (wf *Workflow) DecConcurrentTasks(slots int) {
+	wf.schedCond.L.Lock()
 	for i := 0; i < slots; i++ {
 		<-wf.concurrentTasks
-		Debug.Println("Decreased concurrent tasks")
+		wf.DebugLogger().Println("Decreased concurrent tasks")
+	}
+	wf.schedCond.L.Unlock()
+	wf.schedCond.Broadcast()
+}
+
+// slotRequest is a request from a task waiting for slots for concurrent tasks
+type slotRequest struct {
+	priority int
+	seq      int
+}
+
+// nextSlotRequest returns the waiting slot request with the highest priority,
+// and among those, the one that has waited the longest. The caller must hold
+// wf.schedCond.L.
+func (wf *Workflow) nextSlotRequest() *slotRequest {
+	var next *slotRequest
+	for _, req := range wf.slotRequests {
+		if next == nil || req.priority > next.priority || (req.priority == next.priority && req.seq < next.seq) {
+			next = req
+		}
+	}
+	return next
+}
+
+// removeSlotRequest removes req from the waiting slot requests. The caller
+// must hold wf.schedCond.L.
+func (wf *Workflow) removeSlotRequest(req *slotRequest) {
+	for i, r := range wf.slotRequests {
+		if r == req {
+			wf.slotRequests = append(wf.slotRequests[:i], wf.slotRequests[i+1:]...)
+			return
+		}
+	}
+}
+
+// SetLogger sets a logger to which the workflow, and its processes and tasks,
+// write their audit level log messages, instead of to the global Audit logger.
+// This allows capturing the logs of each workflow separately, when running
+// multiple workflows in the same program. It must be set before the workflow
+// is run. Warning and debug level messages are written to the loggers set
+// with SetWarningLogger() and SetDebugLogger().
+func (wf *Workflow) SetLogger(logger *log.Logger) {
+	wf.logger = logger
+}
+
+// SetWarningLogger sets a logger to which the workflow, and its processes and
+// tasks, write their warning level log messages, such as about retried tasks,
+// breached SLAs and failing PBS status polls, instead of to the global Warning
+// logger. It must be set before the workflow is run.
+func (wf *Workflow) SetWarningLogger(logger *log.Logger) {
+	wf.warningLogger = logger
+}
+
+// WarningLogger returns the logger to which the workflow writes its warning
+// level log messages, which is the global Warning logger unless another one
+// has been set with SetWarningLogger()
+func (wf *Workflow) WarningLogger() *log.Logger {
+	if wf == nil || wf.warningLogger == nil {
+		return Warning
+	}
+	return wf.warningLogger
+}
+
+// SetDebugLogger sets a logger to which the workflow, and its processes and
+// tasks, write their debug level log messages, instead of to the global Debug
+// logger. It must be set before the workflow is run.
+func (wf *Workflow) SetDebugLogger(logger *log.Logger) {
+	wf.debugLogger = logger
+}
+
+// DebugLogger returns the logger to which the workflow writes its debug level
+// log messages, which is the global Debug logger unless another one has been
+// set with SetDebugLogger()
+func (wf *Workflow) DebugLogger() *log.Logger {
+	if wf == nil || wf.debugLogger == nil {
+		return Debug
+	}
+	return wf.debugLogger
+}
+
+// Logger returns the logger to which the workflow writes its audit level log
+// messages, which is the global Audit logger unless another one has been set
+// with SetLogger()
+func (wf *Workflow) Logger() *log.Logger {
+	if wf == nil || wf.logger == nil {
+		return Audit
+	}
+	return wf.logger
+}
+
+// logAuditf logs a pretty printed log message with the AUDIT log level to the
+// logger of the workflow, in the manner of LogAuditf
+func (wf *Workflow) logAuditf(componentName string, message string, values ...interface{}) {
+	wf.Logger().Printf(auditLogPattern, componentName, fmt.Sprintf(message, values...))
+}
+
+// RegisterSecret registers environment variables whose values are secret,
+// such as passwords and access tokens, so that their values are redacted in
+// the environment included in audit info (see Process.AuditEnv)
+func (wf *Workflow) RegisterSecret(envVarNames ...string) {
+	for _, name := range envVarNames {
+		wf.secrets[name] = true
+	}
+}
+
+// redactedValue replaces the values of secrets in audit info
+const redactedValue = "[REDACTED]"
+
+// auditEnv returns the current environment variables, with the values of
+// registered secrets redacted
+func (wf *Workflow) auditEnv() map[string]string {
+	env := map[string]string{}
+	for _, keyVal := range os.Environ() {
+		kv := strings.SplitN(keyVal, "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		if wf != nil && wf.secrets[kv[0]] {
+			kv[1] = redactedValue
+		}
+		env[kv[0]] = kv[1]
 	}
+	return env
 }
 
-// PlotGraph writes the workflow structure to a dot file
+// SetFifoDir sets a directory for the FIFO files (named pipes) used for
+// streaming outputs (see the {os:PORTNAME} placeholder), which are otherwise
+// created next to the output files. This is useful when the output files are
+// on a file system that does not support FIFO files, or when their paths are
+// too long for FIFO files, in which case a directory with a short path, such
+// as on /tmp, can be used.
+func (wf *Workflow) SetFifoDir(path string) {
+	// Commands are executed in the temp dirs of tasks, so the path needs to be
+	// absolute for FIFO files to be found
+	absPath, err := filepath.Abs(path)
+	CheckWithMsg(err, "Could not get absolute path of FIFO dir: "+path)
+	wf.fifoDir = absPath
+}
+
+// SetIPPostProcessor sets a function that is run on every IP sent by any
+// process in the workflow, before it is sent, such as for registering all
+// outputs in a catalog. Streaming IPs are not post-processed, as their files
+// are not complete when sent. The function is run from the go-routines of the
+// sending processes, so it needs to be safe for concurrent use, and must be
+// set before the workflow is run.
+func (wf *Workflow) SetIPPostProcessor(postProcessor func(*FileIP)) {
+	wf.ipPostProcessor = postProcessor
+}
+
+// SetPlaceholderDelimiters sets the strings delimiting the placeholders in the
+// command and path patterns of the processes of the workflow, such as "<<" and
+// ">>", for using placeholders like <<i:in>> rather than {i:in}, when the
+// latter collide with the syntax of the commands. It must be called before
+// any processes are created, since their ports are created from the
+// placeholders in their command patterns.
+func (wf *Workflow) SetPlaceholderDelimiters(open string, close string) {
+	if open == "" || close == "" {
+		Failf("%s workflow: Placeholder delimiters can not be empty, but were '%s' and '%s'\n", wf.name, open, close)
+	}
+	if _, err := compilePlaceHolderRegex(open, close); err != nil {
+		Failf("%s workflow: Invalid placeholder delimiters '%s' and '%s': %s\n", wf.name, open, close, err.Error())
+	}
+	wf.placeHolderOpen = open
+	wf.placeHolderClose = close
+}
+
+// placeHolderRegex returns the regular expression for the placeholders in
+// command and path patterns, with the delimiters set with
+// SetPlaceholderDelimiters, or curly braces by default
+func (wf *Workflow) placeHolderRegex() *regexp.Regexp {
+	if wf == nil || wf.placeHolderOpen == "" {
+		return getShellCommandPlaceHolderRegex("{", "}")
+	}
+	return getShellCommandPlaceHolderRegex(wf.placeHolderOpen, wf.placeHolderClose)
+}
+
+// Pause pauses the scheduling of tasks in the workflow, so that no new tasks
+// are started until Resume() is called. Tasks already running are allowed to
+// finish. Pause is safe to call from another go-routine while the workflow
+// runs, such as from a signal handler.
+func (wf *Workflow) Pause() {
+	wf.schedCond.L.Lock()
+	wf.paused = true
+	wf.schedCond.L.Unlock()
+	wf.Logger().Printf("| workflow:%-23s | Paused workflow, so not starting any new tasks", wf.Name())
+}
+
+// Resume resumes the scheduling of tasks in the workflow, after it has been
+// paused with Pause()
+func (wf *Workflow) Resume() {
+	wf.schedCond.L.Lock()
+	wf.paused = false
+	wf.schedCond.L.Unlock()
+	wf.schedCond.Broadcast()
+	wf.Logger().Printf("| workflow:%-23s | Resumed workflow", wf.Name())
+}
+
+// IsPaused tells whether the workflow is currently paused
+func (wf *Workflow) IsPaused() bool {
+	wf.schedCond.L.Lock()
+	defer wf.schedCond.L.Unlock()
+	return wf.paused
+}
+
+// SetMaxInFlight sets the max number of IPs (or parameters) that can be sent to
+// an in-port, but not yet received by its process, which bounds the memory
+// used when a fast process sends to a slow one. When the limit is reached,
+// the sending process blocks until the receiving process catches up. It
+// defaults to BUFSIZE, and must be set before the workflow is run.
+func (wf *Workflow) SetMaxInFlight(n int) {
+	if n < 1 {
+		Failf(wf.name+" workflow: Max number of in-flight IPs must be at least 1, got %d\n", n)
+	}
+	wf.maxInFlight = n
+}
+
+// PlotGraph writes the workflow structure to a dot file (the same as
+// WriteDotFile)
 func (wf *Workflow) PlotGraph(filePath string) {
-	dot := wf.DotGraph()
-	dotFile, err := os.Create(filePath)
-	CheckWithMsg(err, "Could not create dot file "+filePath)
-	dotFile.WriteString(dot)
+	wf.WriteDotFile(filePath)
 }
 
 // PlotGraphPDF writes the workflow structure to a dot file, and also runs the
@@ -205,10 +511,20 @@ func (wf *Workflow) PlotGraphPDF(filePath string) {
 	ExecCmd(fmt.Sprintf("dot -Tpdf %s -o %s.pdf", filePath, filePath))
 }
 
+// WriteDotFile writes the workflow structure, as generated by DotGraph, to a
+// dot file at filePath
+func (wf *Workflow) WriteDotFile(filePath string) {
+	err := ioutil.WriteFile(filePath, []byte(wf.DotGraph()), 0644)
+	CheckWithMsg(err, "Could not write dot file "+filePath)
+}
+
 // DotGraph generates a graph description in DOT format
 // (See https://en.wikipedia.org/wiki/DOT_%28graph_description_language%29)
 // If Workflow.PlotConf.EdgeLabels is set to true, a label containing the
 // in-port and out-port to which edges are connected to, will be printed.
+// Processes with commands are labeled with their name and command. Edges
+// of streaming (FIFO) outputs are dotted, and edges of parameter connections
+// dashed.
 func (wf *Workflow) DotGraph() (dot string) {
 	dot = fmt.Sprintf(`digraph "%s" {`+"\n", wf.Name())
 	dot += `  rankdir=LR;` + "\n"
@@ -219,12 +535,25 @@ func (wf *Workflow) DotGraph() (dot string) {
 	con := ""
 	remToDotPtn := regexp.MustCompile(`^.*\.`)
 	for _, p := range wf.ProcsSorted() {
-		dot += fmt.Sprintf(`  "%s" [shape=box];`+"\n", p.Name())
+		if proc, ok := p.(*Process); ok && proc.CommandPattern != "" {
+			dot += fmt.Sprintf(`  "%s" [shape=box, label="%s\n%s"];`+"\n", p.Name(), dotEscape(p.Name()), dotEscape(proc.CommandPattern))
+		} else {
+			dot += fmt.Sprintf(`  "%s" [shape=box];`+"\n", p.Name())
+		}
 		// File connections
-		for opname, op := range p.OutPorts() {
-			for rpname, rp := range op.RemotePorts {
+		for _, opname := range sortedOutPortMapKeys(p.OutPorts()) {
+			op := p.OutPorts()[opname]
+			for _, rpname := range sortedInPortMapKeys(op.RemotePorts) {
+				rp := op.RemotePorts[rpname]
+				attrs := []string{}
+				if outPortDoesStream(p, opname) {
+					attrs = append(attrs, `style="dotted"`)
+				}
 				if wf.PlotConf.EdgeLabels {
-					con += fmt.Sprintf(`  "%s" -> "%s" [taillabel="%s", headlabel="%s"];`+"\n", op.Process().Name(), rp.Process().Name(), remToDotPtn.ReplaceAllString(opname, ""), remToDotPtn.ReplaceAllString(rpname, ""))
+					attrs = append(attrs, fmt.Sprintf(`taillabel="%s", headlabel="%s"`, remToDotPtn.ReplaceAllString(opname, ""), remToDotPtn.ReplaceAllString(rpname, "")))
+				}
+				if len(attrs) > 0 {
+					con += fmt.Sprintf(`  "%s" -> "%s" [%s];`+"\n", op.Process().Name(), rp.Process().Name(), strings.Join(attrs, ", "))
 				} else {
 					con += fmt.Sprintf(`  "%s" -> "%s";`+"\n", op.Process().Name(), rp.Process().Name())
 				}
@@ -246,6 +575,24 @@ func (wf *Workflow) DotGraph() (dot string) {
 	return
 }
 
+// outPortDoesStream tells whether the out-port named portName of proc streams
+// its outputs via FIFO files
+func outPortDoesStream(proc WorkflowProcess, portName string) bool {
+	p, ok := proc.(*Process)
+	if !ok {
+		return false
+	}
+	ptInfo, ok := p.PortInfo[portName]
+	return ok && ptInfo.doStream
+}
+
+// dotEscape escapes str for use inside a double-quoted string in DOT format
+func dotEscape(str string) string {
+	str = strings.Replace(str, `\`, `\\`, -1)
+	str = strings.Replace(str, `"`, `\"`, -1)
+	return strings.Replace(str, "\n", `\n`, -1)
+}
+
 // ----------------------------------------------------------------------------
 // Run methods
 // ----------------------------------------------------------------------------
@@ -255,6 +602,21 @@ func (wf *Workflow) Run() {
 	wf.runProcs(wf.procs)
 }
 
+// RunFailedOnly runs the workflow, but executes only the tasks marked as failed
+// in a prior run, which are identified by the id of their temp dirs (see
+// Task.TempDir()). The temp dirs of the failed tasks are cleaned up before
+// they are re-executed. All other tasks, including ones that were never
+// executed in the prior run, are skipped, so that successful tasks are not
+// touched. Use Run() afterwards to run any remaining tasks.
+func (wf *Workflow) RunFailedOnly() {
+	failedTaskIDs, err := readFailedTaskIDs(".")
+	CheckWithMsg(err, "Could not read failed tasks of prior run")
+	wf.Logger().Printf("| workflow:%-23s | Re-running %d failed tasks", wf.Name(), len(failedTaskIDs))
+	wf.failedTasksOnly = failedTaskIDs
+	defer func() { wf.failedTasksOnly = nil }()
+	wf.Run()
+}
+
 // RunTo runs all processes upstream of, and including, the process with
 // names provided as arguments
 func (wf *Workflow) RunTo(finalProcNames ...string) {
@@ -292,27 +654,150 @@ func (wf *Workflow) RunToProcs(finalProcs ...WorkflowProcess) {
 	wf.runProcs(procsToRun)
 }
 
+// RunFrom runs the workflow from the processes with names provided as
+// arguments, whose outputs are expected to exist already, such as from a prior
+// run. Only the processes downstream of them execute any tasks. The named
+// processes, and the processes upstream of any executing process, do not
+// execute tasks, but just send IPs for the existing outputs the tasks would
+// have produced, so that intermediate files upstream of the named processes
+// may have been removed. Before anything downstream starts, the workflow fails
+// with a list of the outputs of the named processes that do not exist.
+// Processes that are not connected to the named processes are not run.
+// Streaming outputs (via FIFO files) of processes that don't execute are not
+// supported.
+func (wf *Workflow) RunFrom(fromProcNames ...string) {
+	fromProcs := map[string]bool{}
+	executed := map[string]bool{}
+	for _, procName := range fromProcNames {
+		fromProcs[procName] = true
+		for downName := range downstreamProcsForProc(wf.Proc(procName)) {
+			// The sink is not among the processes of the workflow
+			if _, ok := wf.procs[downName]; ok {
+				executed[downName] = true
+			}
+		}
+	}
+	procsToRun := map[string]WorkflowProcess{}
+	for procName := range mergeBoolMaps(fromProcs, executed) {
+		proc := wf.Proc(procName)
+		procsToRun[procName] = proc
+		// Upstream processes are needed for sending IPs, for all processes
+		// that are run
+		procsToRun = mergeWFMaps(procsToRun, upstreamProcsForProc(proc))
+	}
+	for procName := range fromProcs {
+		// A named process downstream of another one is run like a named one
+		delete(executed, procName)
+	}
+	wf.runFromProcs = fromProcs
+	wf.runFromExecuted = executed
+	defer func() {
+		wf.runFromProcs = nil
+		wf.runFromExecuted = nil
+	}()
+	wf.runProcs(procsToRun)
+}
+
 // ----------------------------------------------------------------------------
 // Helper methods for running the workflow
 // ----------------------------------------------------------------------------
 
+// executesTasks tells whether the process with name procName should execute
+// its tasks, rather than just send IPs for existing outputs, which it should
+// not when running the workflow from certain processes (see RunFrom)
+func (wf *Workflow) executesTasks(procName string) bool {
+	return wf.runFromExecuted == nil || wf.runFromExecuted[procName]
+}
+
 // runProcs runs a specified set of processes only
 func (wf *Workflow) runProcs(procs map[string]WorkflowProcess) {
+	wf.resetProcessStatus()
 	wf.reconnectDeadEndConnections(procs)
+	if wf.AutoTeeStreams {
+		wf.insertStreamFanOuts(procs)
+	}
 
 	if !wf.readyToRun(procs) {
 		Fail("Workflow not ready to run, due to previously reported errors, so exiting.")
 	}
+	for _, warning := range streamingDeadlockRisks(procs) {
+		wf.WarningLogger().Println(wf.name + ": " + warning)
+	}
+	if wf.maxInFlight > 0 {
+		for _, proc := range procs {
+			resizeInPortBuffers(proc, wf.maxInFlight)
+		}
+		resizeInPortBuffers(wf.driver, wf.maxInFlight)
+		if wf.driver != wf.sink {
+			resizeInPortBuffers(wf.sink, wf.maxInFlight)
+		}
+	}
+	closeUnconnectedOptionalInPorts(wf.procsAndDriver(procs))
+	if wf.ipPostProcessor != nil {
+		for _, proc := range procs {
+			setIPPostProcessor(proc, wf.ipPostProcessor)
+		}
+		setIPPostProcessor(wf.driver, wf.ipPostProcessor)
+	}
+
+	// Disk space is checked before any process starts, so that no tasks are
+	// started if a limit is already breached
+	stopDiskHardLimitChecks := wf.startDiskHardLimitChecks()
 
 	for _, proc := range procs {
-		Debug.Printf(wf.name+": Starting process %s in new go-routine", proc.Name())
+		wf.DebugLogger().Printf(wf.name+": Starting process %s in new go-routine", proc.Name())
 		go proc.Run()
 	}
 
-	Debug.Printf("%s: Starting driver process (%s) in main go-routine", wf.name, wf.driver.Name())
-	Audit.Printf("| workflow:%-23s | Starting workflow (Writing log to %s)", wf.Name(), wf.logFile)
+	stopBackgroundProcs := wf.startBackgroundProcs()
+
+	wf.DebugLogger().Printf("%s: Starting driver process (%s) in main go-routine", wf.name, wf.driver.Name())
+	wf.Logger().Printf("| workflow:%-23s | Starting workflow (Writing log to %s)", wf.Name(), wf.logFile)
 	wf.driver.Run()
-	Audit.Printf("| workflow:%-23s | Finished workflow (Log written to %s)", wf.Name(), wf.logFile)
+	stopDiskHardLimitChecks()
+	stopBackgroundProcs()
+	wf.writeAuditReport()
+	wf.Logger().Printf("| workflow:%-23s | Finished workflow (Log written to %s)", wf.Name(), wf.logFile)
+}
+
+// startBackgroundProcs starts all background processes in separate
+// go-routines, and returns a function that stops them, and waits for them to
+// return
+func (wf *Workflow) startBackgroundProcs() (stopAll func()) {
+	stop := make(chan struct{})
+	wg := sync.WaitGroup{}
+	for _, proc := range wf.backgroundProcs {
+		wf.DebugLogger().Printf(wf.name+": Starting background process %s in new go-routine", proc.Name())
+		wg.Add(1)
+		go func(proc BackgroundProcess) {
+			defer wg.Done()
+			proc.Run(stop)
+		}(proc)
+	}
+	return func() {
+		close(stop)
+		wg.Wait()
+	}
+}
+
+// resizeInPortBuffers replaces the channels of the in-ports and param in-ports
+// of proc with channels with buffer size size. This must be done before any
+// process is started.
+func resizeInPortBuffers(proc WorkflowProcess, size int) {
+	for _, ipt := range proc.InPorts() {
+		ipt.Chan = make(chan *FileIP, size)
+	}
+	for _, pip := range proc.InParamPorts() {
+		pip.Chan = make(chan string, size)
+	}
+}
+
+// setIPPostProcessor makes all out-ports of proc run postProcessor on the IPs
+// sent on them
+func setIPPostProcessor(proc WorkflowProcess, postProcessor func(*FileIP)) {
+	for _, opt := range proc.OutPorts() {
+		opt.postProcessor = postProcessor
+	}
 }
 
 func (wf *Workflow) readyToRun(procs map[string]WorkflowProcess) bool {
@@ -324,15 +809,70 @@ func (wf *Workflow) readyToRun(procs map[string]WorkflowProcess) bool {
 		Error.Println(wf.name + ": sink is nil!")
 		return false
 	}
+	if err := validateProcs(wf.procsAndDriver(procs)); err != nil {
+		Error.Println(wf.name + ": " + err.Error())
+		return false
+	}
 	for _, proc := range procs {
 		if !proc.Ready() {
 			Error.Println(wf.name + ": Not everything connected. Workflow shutting down.")
 			return false
 		}
 	}
+	if msgs := multiConsumerStreams(procs); len(msgs) > 0 {
+		for _, msg := range msgs {
+			Error.Println(wf.name + ": " + msg)
+		}
+		return false
+	}
 	return true
 }
 
+// streamingDeadlockRisks returns a warning message for each process that
+// receives more than one in-port connection from the same upstream process,
+// where at least one of the connections is streaming (via a FIFO file). Since
+// a process does not start a task until it has received IPs on all of its
+// in-ports, while the upstream task blocks on writing to its FIFOs until
+// they are read, such topologies risk deadlocking.
+func streamingDeadlockRisks(procs map[string]WorkflowProcess) []string {
+	warnings := []string{}
+	for _, procName := range sortedWFProcMapKeys(procs) {
+		proc := procs[procName]
+		// Collect connections per upstream process
+		conns := map[string][]string{}
+		hasStreaming := map[string]bool{}
+		for _, iptName := range sortedInPortMapKeys(proc.InPorts()) {
+			ipt := proc.InPorts()[iptName]
+			for _, rptName := range sortedOutPortMapKeys(ipt.RemotePorts) {
+				rpt := ipt.RemotePorts[rptName]
+				upstreamName := rpt.Process().Name()
+				conn := rpt.Name() + " -> " + ipt.Name()
+				if isStreamingOutPort(rpt) {
+					conn += " (streaming)"
+					hasStreaming[upstreamName] = true
+				}
+				conns[upstreamName] = append(conns[upstreamName], conn)
+			}
+		}
+		for _, upstreamName := range sortedStringSliceMapKeys(conns) {
+			if len(conns[upstreamName]) > 1 && hasStreaming[upstreamName] {
+				warnings = append(warnings, fmt.Sprintf("Process '%s' receives multiple inputs from process '%s', of which at least one is streaming, which risks deadlocking: %s", procName, upstreamName, strings.Join(conns[upstreamName], ", ")))
+			}
+		}
+	}
+	return warnings
+}
+
+// isStreamingOutPort tells whether an out-port sends its IPs via FIFO files
+func isStreamingOutPort(opt *OutPort) bool {
+	proc, ok := opt.Process().(*Process)
+	if !ok {
+		return false
+	}
+	portInfo, ok := proc.PortInfo[opt.name]
+	return ok && portInfo.doStream
+}
+
 // reconnectDeadEndConnections disonnects connections to processes which are
 // not in the set of processes to be run, and, if an out-port for a process
 // supposed to be run gets disconnected, its out-port(s) will be connected to
@@ -346,15 +886,15 @@ func (wf *Workflow) reconnectDeadEndConnections(procs map[string]WorkflowProcess
 			for iptName, ipt := range opt.RemotePorts {
 				// If the remotely connected process is not among the ones to run ...
 				if ipt.Process() == nil {
-					Debug.Printf("Disconnecting in-port %s from out-port %s", ipt.Name(), opt.Name())
+					wf.DebugLogger().Printf("Disconnecting in-port %s from out-port %s", ipt.Name(), opt.Name())
 					opt.Disconnect(iptName)
 				} else if _, ok := procs[ipt.Process().Name()]; !ok {
-					Debug.Printf("Disconnecting in-port %s from out-port %s", ipt.Name(), opt.Name())
+					wf.DebugLogger().Printf("Disconnecting in-port %s from out-port %s", ipt.Name(), opt.Name())
 					opt.Disconnect(iptName)
 				}
 			}
 			if !opt.Ready() {
-				Debug.Printf("Connecting disconnected out-port %s of process %s to workflow sink", opt.Name(), opt.Process().Name())
+				wf.DebugLogger().Printf("Connecting disconnected out-port %s of process %s to workflow sink", opt.Name(), opt.Process().Name())
 				wf.sink.From(opt)
 			}
 		}
@@ -364,15 +904,15 @@ func (wf *Workflow) reconnectDeadEndConnections(procs map[string]WorkflowProcess
 			for rppName, rpp := range pop.RemotePorts {
 				// If the remotely connected process is not among the ones to run ...
 				if rpp.Process() == nil {
-					Debug.Printf("Disconnecting in-port %s from out-port %s", rpp.Name(), pop.Name())
+					wf.DebugLogger().Printf("Disconnecting in-port %s from out-port %s", rpp.Name(), pop.Name())
 					pop.Disconnect(rppName)
 				} else if _, ok := procs[rpp.Process().Name()]; !ok {
-					Debug.Printf("Disconnecting in-port %s from out-port %s", rpp.Name(), pop.Name())
+					wf.DebugLogger().Printf("Disconnecting in-port %s from out-port %s", rpp.Name(), pop.Name())
 					pop.Disconnect(rppName)
 				}
 			}
 			if !pop.Ready() {
-				Debug.Printf("Connecting disconnected out-port %s of process %s to workflow sink", pop.Name(), pop.Process().Name())
+				wf.DebugLogger().Printf("Connecting disconnected out-port %s of process %s to workflow sink", pop.Name(), pop.Process().Name())
 				wf.sink.FromParam(pop)
 			}
 		}
@@ -413,6 +953,91 @@ func upstreamProcsForProc(proc WorkflowProcess) map[string]WorkflowProcess {
 	return procs
 }
 
+// downstreamProcsForProc returns all processes of the workflow that proc is
+// connected to, either directly or indirectly, via its out-ports and
+// param-out-ports
+func downstreamProcsForProc(proc WorkflowProcess) map[string]WorkflowProcess {
+	procs := map[string]WorkflowProcess{}
+	for _, opt := range proc.OutPorts() {
+		for _, rpt := range opt.RemotePorts {
+			procs[rpt.Process().Name()] = rpt.Process()
+			mergeWFMaps(procs, downstreamProcsForProc(rpt.Process()))
+		}
+	}
+	for _, pop := range proc.OutParamPorts() {
+		for _, rpp := range pop.RemotePorts {
+			procs[rpp.Process().Name()] = rpp.Process()
+			mergeWFMaps(procs, downstreamProcsForProc(rpp.Process()))
+		}
+	}
+	return procs
+}
+
+func sortedWFProcMapKeys(kv map[string]WorkflowProcess) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedInPortMapKeys(kv map[string]*InPort) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedOutPortMapKeys(kv map[string]*OutPort) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedOutParamPortMapKeys(kv map[string]*OutParamPort) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedInParamPortMapKeys(kv map[string]*InParamPort) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedStringSliceMapKeys(kv map[string][]string) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func mergeBoolMaps(a map[string]bool, b map[string]bool) map[string]bool {
+	merged := map[string]bool{}
+	for k, v := range a {
+		merged[k] = v
+	}
+	for k, v := range b {
+		merged[k] = v
+	}
+	return merged
+}
+
 func mergeWFMaps(a map[string]WorkflowProcess, b map[string]WorkflowProcess) map[string]WorkflowProcess {
 	for k, v := range b {
 		a[k] = v
@@ -1,13 +1,16 @@
 package scipipe
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"log"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -91,8 +94,8 @@ func TestDotGraph(t *testing.T) {
   graph [fontname="Arial",fontsize=13,color="#384A52",fontcolor="#384A52"];
   node  [fontname="Arial",fontsize=11,color="#384A52",fontcolor="#384A52",fillcolor="#EFF2F5",shape=box,style=filled];
   edge  [fontname="Arial",fontsize=9, color="#384A52",fontcolor="#384A52"];
-  "p1" [shape=box];
-  "p2" [shape=box];
+  "p1" [shape=box, label="p1\necho p1 > {o:out}"];
+  "p2" [shape=box, label="p2\ncat {i:in} > {o:out}"];
   "p1" -> "p2" [taillabel="out", headlabel="in"];
 }
 `
@@ -107,8 +110,8 @@ func TestDotGraph(t *testing.T) {
   graph [fontname="Arial",fontsize=13,color="#384A52",fontcolor="#384A52"];
   node  [fontname="Arial",fontsize=11,color="#384A52",fontcolor="#384A52",fillcolor="#EFF2F5",shape=box,style=filled];
   edge  [fontname="Arial",fontsize=9, color="#384A52",fontcolor="#384A52"];
-  "p1" [shape=box];
-  "p2" [shape=box];
+  "p1" [shape=box, label="p1\necho p1 > {o:out}"];
+  "p2" [shape=box, label="p2\ncat {i:in} > {o:out}"];
   "p1" -> "p2";
 }
 `
@@ -118,6 +121,33 @@ func TestDotGraph(t *testing.T) {
 	}
 }
 
+func TestDotGraphStreaming(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("testwf", 4)
+
+	p1 := wf.NewProc("p1", "echo \"p1\" > {os:out}")
+	p1.SetOut("out", "/tmp/p1.txt")
+
+	p2 := wf.NewProc("p2", "cat {i:in} > {o:out}")
+	p2.SetOut("out", "{i:in}.p2.txt")
+	p2.In("in").From(p1.Out("out"))
+
+	dotPath := "/tmp/dotgraph_streaming_test.dot"
+	defer cleanFiles(dotPath)
+	wf.WriteDotFile(dotPath)
+	dot, err := ioutil.ReadFile(dotPath)
+	Check(err)
+
+	for _, expectedLine := range []string{
+		`  "p1" [shape=box, label="p1\necho \"p1\" > {os:out}"];`,
+		`  "p1" -> "p2" [style="dotted", taillabel="out", headlabel="in"];`,
+	} {
+		if !strings.Contains(string(dot), expectedLine+"\n") {
+			t.Errorf("Expected dot file to contain line:\n%s\nACTUAL:\n%s\n", expectedLine, dot)
+		}
+	}
+}
+
 func TestRunToProc(t *testing.T) {
 	initTestLogs()
 
@@ -146,6 +176,85 @@ func TestRunToProc(t *testing.T) {
 	cleanFilePatterns("/tmp/foo.txt*")
 }
 
+func TestRunToRegex(t *testing.T) {
+	initTestLogs()
+
+	wf := getWorkflowForTestRunToProc("TestRunToRegexWF")
+	wf.RunToRegex("^m.g$")
+	defer cleanFilePatterns("/tmp/foo.txt*", "/tmp/bar.txt*")
+
+	for _, path := range []string{"/tmp/foo.txt", "/tmp/bar.txt", "/tmp/foo.txt.bar.txt"} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("File %s, of the target process or upstream of it, is not created, which it should: %v", path, err)
+		}
+	}
+	if _, err := os.Stat("/tmp/foo.txt.bar.txt.rpl.txt"); err == nil {
+		t.Error("File /tmp/foo.txt.bar.txt.rpl.txt, of a process downstream of the target, exists, which it should not")
+	}
+}
+
+func TestRunFrom(t *testing.T) {
+	initTestLogs()
+	defer cleanFilePatterns("/tmp/run_from_*")
+
+	getWorkflow := func() *Workflow {
+		wf := NewWorkflow("TestRunFromWF", 4)
+		first := wf.NewProc("first", "echo first > {o:out}")
+		first.SetOut("out", "/tmp/run_from_first.txt")
+		second := wf.NewProc("second", "cat {i:in} > {o:out}; echo second >> {o:out}")
+		second.In("in").From(first.Out("out"))
+		second.SetOut("out", "/tmp/run_from_second.txt")
+		third := wf.NewProc("third", "cat {i:in} > {o:out}; echo third >> {o:out}")
+		third.In("in").From(second.Out("out"))
+		third.SetOut("out", "/tmp/run_from_third.txt")
+		return wf
+	}
+	getWorkflow().Run()
+
+	// Remove the output of the first process, and change the one of the
+	// second, to check that they are not re-created, and that the third
+	// process is run on the existing output of the second
+	cleanFiles("/tmp/run_from_first.txt", "/tmp/run_from_third.txt")
+	err := ioutil.WriteFile("/tmp/run_from_second.txt", []byte("edited\n"), 0644)
+	Check(err)
+	getWorkflow().RunFrom("second")
+
+	if _, err := os.Stat("/tmp/run_from_first.txt"); err == nil {
+		t.Error("Output of process upstream of the one run from was re-created, which it should not")
+	}
+	out, err := ioutil.ReadFile("/tmp/run_from_third.txt")
+	Check(err)
+	if string(out) != "edited\nthird\n" {
+		t.Errorf("Expected process downstream of the one run from to run on its existing output, got: %q", string(out))
+	}
+}
+
+func TestRunFromMissingOutputs(t *testing.T) {
+	// Failing the workflow exits the process, so the workflow is run in a
+	// sub-process of the test binary
+	if inSubprocess(t) {
+		InitLogError()
+		wf := NewWorkflow("TestRunFromMissingWF", 4)
+		params := NewParamSource(wf, "params", "a", "b")
+		first := wf.NewProc("first", "echo {p:x} > {o:out}")
+		first.InParam("x").From(params.Out())
+		first.SetOut("out", "/tmp/run_from_missing_{p:x}.txt")
+		second := wf.NewProc("second", "cat {i:in} > {o:out}")
+		second.In("in").From(first.Out("out"))
+		second.SetOut("out", "{i:in}.second.txt")
+		wf.RunFrom("first")
+		return
+	}
+
+	out := runFailingSubprocess(t)
+	for _, missing := range []string{"/tmp/run_from_missing_a.txt", "/tmp/run_from_missing_b.txt"} {
+		if !strings.Contains(out, missing) {
+			t.Errorf("Expected output to list missing file %s, got: %s", missing, out)
+		}
+	}
+	cleanFilePatterns("/tmp/run_from_missing_*")
+}
+
 func getWorkflowForTestRunToProc(wfName string) *Workflow {
 	wf := NewWorkflow(wfName, 4)
 
@@ -361,6 +470,129 @@ func TestStreaming(t *testing.T) {
 	cleanFiles("/tmp/lsl.txt", "/tmp/lsl.txt.grepped.txt")
 }
 
+func TestSetFifoDir(t *testing.T) {
+	initTestLogs()
+	fifoDir, err := ioutil.TempDir("", "fifos")
+	Check(err)
+	defer os.RemoveAll(fifoDir)
+
+	wf := NewWorkflow("TestSetFifoDirWf", 4)
+	wf.SetFifoDir(fifoDir)
+	ls := wf.NewProc("ls", "ls -l / > {os:lsl}")
+	longPath := "/tmp/fifo_dir_test_with_a_long_name_" + strings.Repeat("x", 80) + ".txt"
+	ls.SetOut("lsl", longPath)
+	// List the FIFO dir while the FIFO file of the upstream process exists
+	grp := wf.NewProc("grp", "ls "+fifoDir+" > {o:fifos}; grep etc {i:in} > {o:grepped}")
+	grp.SetOut("fifos", "/tmp/fifo_dir_test_fifos.txt")
+	grp.SetOut("grepped", "/tmp/fifo_dir_test_grepped.txt")
+	grp.In("in").From(ls.Out("lsl"))
+	wf.Run()
+	defer cleanFiles(longPath, "/tmp/fifo_dir_test_fifos.txt", "/tmp/fifo_dir_test_grepped.txt")
+
+	fifos, err := ioutil.ReadFile("/tmp/fifo_dir_test_fifos.txt")
+	Check(err)
+	fifoNames := strings.Fields(string(fifos))
+	if len(fifoNames) != 1 || !strings.HasSuffix(fifoNames[0], ".fifo") || len(fifoNames[0]) > 32 {
+		t.Errorf("Expected one FIFO file with a short name in the FIFO dir, but found: %v", fifoNames)
+	}
+	if _, err := os.Stat("/tmp/fifo_dir_test_grepped.txt"); err != nil {
+		t.Errorf("Expected output of streaming through the FIFO dir to exist: %v", err)
+	}
+}
+
+func TestSetIPPostProcessor(t *testing.T) {
+	initTestLogs()
+	dir, err := ioutil.TempDir("", "ip_post_processor")
+	Check(err)
+	defer os.RemoveAll(dir)
+
+	mx := sync.Mutex{}
+	calls := map[string]int{}
+	wf := NewWorkflow("TestSetIPPostProcessorWf", 4)
+	wf.SetIPPostProcessor(func(ip *FileIP) {
+		mx.Lock()
+		calls[ip.Path()]++
+		mx.Unlock()
+	})
+
+	src := NewParamSource(wf, "src", "a", "b", "c")
+	wrt := wf.NewProc("wrt", "echo {p:letter} > {o:out}")
+	wrt.InParam("letter").From(src.Out())
+	wrt.SetOut("out", dir+"/{p:letter}.txt")
+	// Streamed outputs are not post-processed
+	cpy := wf.NewProc("cpy", "cat {i:in} > {os:stream}")
+	cpy.In("in").From(wrt.Out("out"))
+	cpy.SetOut("stream", "{i:in}.stream")
+	cnt := wf.NewProc("cnt", "wc -c < {i:in} > {o:out}")
+	cnt.In("in").From(cpy.Out("stream"))
+	cnt.SetOut("out", "{i:in}.count")
+	wf.Run()
+
+	expected := map[string]int{}
+	for _, letter := range []string{"a", "b", "c"} {
+		expected[dir+"/"+letter+".txt"] = 1
+		expected[dir+"/"+letter+".txt.stream.count"] = 1
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Expected the IP post-processor to be called once for each emitted IP: %v, but was called for: %v", expected, calls)
+	}
+}
+
+func TestSetPlaceholderDelimiters(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("TestSetPlaceholderDelimitersWf", 4)
+	wf.SetPlaceholderDelimiters("<<", ">>")
+	// The curly braces of the awk program are left as they are
+	src := wf.NewProc("src", "printf 'a 1\\nb 2\\n' > <<o:out>>")
+	src.SetOut("out", "/tmp/placeholder_delimiters.txt")
+	awk := wf.NewProc("awk", "awk '{ print $<<p:col>> }' <<i:in>> > <<o:out>>")
+	awk.In("in").From(src.Out("out"))
+	awk.InParam("col").FromStr("2")
+	awk.SetOut("out", "<<i:in|%.txt>>.col<<p:col>>.txt")
+	wf.Run()
+	defer cleanFiles("/tmp/placeholder_delimiters.txt", "/tmp/placeholder_delimiters.col2.txt")
+
+	out, err := ioutil.ReadFile("/tmp/placeholder_delimiters.col2.txt")
+	Check(err)
+	if string(out) != "1\n2\n" {
+		t.Errorf("Expected second column of input, got %q", out)
+	}
+}
+
+func TestStreamingDeadlockRisks(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("TestStreamingDeadlockRisksWf", 4)
+	split := wf.NewProc("split", "echo a > {os:a}; echo b > {os:b}")
+	split.SetOut("a", "/tmp/a.txt")
+	split.SetOut("b", "/tmp/b.txt")
+	pst := wf.NewProc("paste", "paste {i:x} {i:y} > {o:out}")
+	pst.SetOut("out", "/tmp/pasted.txt")
+	pst.In("x").From(split.Out("a"))
+	pst.In("y").From(split.Out("b"))
+
+	warnings := streamingDeadlockRisks(wf.Procs())
+	if len(warnings) != 1 {
+		t.Fatalf("Expected exactly one warning, got %d: %v", len(warnings), warnings)
+	}
+	for _, portName := range []string{"split.a", "split.b", "paste.x", "paste.y"} {
+		if !strings.Contains(warnings[0], portName) {
+			t.Errorf("Warning does not mention port %s: %s", portName, warnings[0])
+		}
+	}
+
+	// A single streaming connection should not be flagged
+	wf2 := NewWorkflow("TestStreamingNoDeadlockRisksWf", 4)
+	ls := wf2.NewProc("ls", "ls -l / > {os:lsl}")
+	ls.SetOut("lsl", "/tmp/lsl.txt")
+	grp := wf2.NewProc("grp", "grep etc {i:in} > {o:grepped}")
+	grp.SetOut("grepped", "{i:in}.grepped.txt")
+	grp.In("in").From(ls.Out("lsl"))
+	if warnings := streamingDeadlockRisks(wf2.Procs()); len(warnings) != 0 {
+		t.Errorf("Expected no warnings, got: %v", warnings)
+	}
+}
+
 func TestSubStreamJoinInPlaceHolder(t *testing.T) {
 	initTestLogs()
 
@@ -459,6 +691,32 @@ func TestPassOnTags(t *testing.T) {
 	cleanFiles("/tmp/hey.txt", "/tmp/hey.txt.you.txt")
 }
 
+func TestTaskInTag(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("TestTaskInTag_WF", 4)
+
+	hey := wf.NewProc("create_file", "echo hey > {o:heyfile}")
+	hey.SetOut("heyfile", "/tmp/in_tag_hey.txt")
+
+	tag := NewMapToTags(wf, "add_tag", func(ip *FileIP) map[string]string {
+		return map[string]string{"sample": "s1"}
+	})
+	tag.In().From(hey.Out("heyfile"))
+
+	cpy := wf.NewProc("copy_file", "cat {i:infile} > {o:outfile}")
+	cpy.SetOutFunc("outfile", func(tsk *Task) string {
+		return "/tmp/in_tag_" + tsk.InTag("infile", "sample") + ".txt"
+	})
+	cpy.In("infile").From(tag.Out())
+
+	wf.Run()
+	defer cleanFiles("/tmp/in_tag_hey.txt", "/tmp/in_tag_s1.txt")
+
+	if _, err := os.Stat("/tmp/in_tag_s1.txt"); err != nil {
+		t.Errorf("Expected output named after the tag of the input to exist: %s", err.Error())
+	}
+}
+
 // TestReceiveBothIPsAndParams makes sure that channels in the process
 // createTask process are not short-cut before all parameters and IPs are
 // received, by running a workflow that receives both a stream of params, and
@@ -509,6 +767,238 @@ func TestSanitizePathFragments(t *testing.T) {
 	}
 }
 
+func TestRunFailedOnly(t *testing.T) {
+	initTestLogs()
+
+	executed := []string{}
+	mx := sync.Mutex{}
+	newWf := func() (*Workflow, *Process) {
+		wf := NewWorkflow("test_wf", 4)
+		src := NewParamSource(wf, "src", "1", "2", "3")
+		mk := wf.NewProc("mk", "echo {p:num} > {o:out}")
+		mk.InParam("num").From(src.Out())
+		mk.SetOut("out", "run_failed_only_{p:num}.txt")
+		mk.CustomExecute = func(tsk *Task) {
+			mx.Lock()
+			executed = append(executed, tsk.Param("num"))
+			mx.Unlock()
+			tsk.OutIP("out").Write([]byte(tsk.Param("num") + "\n"))
+		}
+		return wf, mk
+	}
+
+	wf, _ := newWf()
+	wf.Run()
+	if len(executed) != 3 {
+		t.Fatalf("Expected 3 tasks to be executed in the first run, got: %v", executed)
+	}
+
+	// Simulate that the task for number 2 failed in the first run
+	cleanFiles("run_failed_only_2.txt")
+	wf, mk := newWf()
+	failedTask := NewTask(wf, mk, mk.Name(), mk.CommandPattern, map[string]*FileIP{}, mk.PathFuncs, mk.PortInfo, map[string]string{"num": "2"}, map[string]string{}, "", nil, 1)
+	failedTask.createDirs()
+	failedTask.markFailed()
+
+	executed = []string{}
+	wf.RunFailedOnly()
+	if len(executed) != 1 || executed[0] != "2" {
+		t.Errorf("Expected only the failed task (for number 2) to be re-executed, got: %v", executed)
+	}
+	if _, err := os.Stat("run_failed_only_2.txt"); err != nil {
+		t.Errorf("Expected output of re-executed task to exist: %v", err)
+	}
+	if _, err := os.Stat(failedTask.TempDir()); !os.IsNotExist(err) {
+		t.Errorf("Expected temp dir of failed task to be cleaned up: %s", failedTask.TempDir())
+	}
+
+	cleanFiles("run_failed_only_1.txt", "run_failed_only_2.txt", "run_failed_only_3.txt")
+}
+
+func TestMaxInFlight(t *testing.T) {
+	initTestLogs()
+
+	maxInFlight := 2
+	wf := NewWorkflow("test_wf", 4)
+	wf.SetMaxInFlight(maxInFlight)
+	counter := &inFlightCounter{}
+	producer := newInFlightProducer(wf, "producer", 20, counter)
+	consumer := newSlowConsumer(wf, "consumer", counter)
+	consumer.InPort("in").From(producer.OutPort("out"))
+	wf.Run()
+
+	if counter.received != 20 {
+		t.Errorf("Expected consumer to receive 20 IPs, got %d", counter.received)
+	}
+	// One IP might have been taken from the port by the consumer, but not yet
+	// counted as received
+	if counter.maxInFlight > maxInFlight+1 {
+		t.Errorf("Expected at most %d IPs in flight, but got %d", maxInFlight+1, counter.maxInFlight)
+	}
+}
+
+func TestPauseResume(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("test_wf", 1)
+	nSource := NewParamSource(wf, "numbers", "1", "2", "3", "4", "5")
+
+	mx := sync.Mutex{}
+	started := 0
+	getStarted := func() int {
+		mx.Lock()
+		defer mx.Unlock()
+		return started
+	}
+	work := wf.NewProc("work", "# {p:number} {o:out}")
+	work.InParam("number").From(nSource.Out())
+	work.SetOut("out", "pause_resume_{p:number}.txt")
+	work.CustomExecute = func(tsk *Task) {
+		mx.Lock()
+		started++
+		mx.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wf.Run()
+		close(done)
+	}()
+
+	for getStarted() == 0 {
+		time.Sleep(5 * time.Millisecond)
+	}
+	wf.Pause()
+	// Let any task started just before pausing be counted
+	time.Sleep(50 * time.Millisecond)
+	startedWhenPaused := getStarted()
+	time.Sleep(200 * time.Millisecond)
+	if getStarted() != startedWhenPaused {
+		t.Errorf("Expected no new tasks to start while paused, but %d tasks started", getStarted()-startedWhenPaused)
+	}
+	if startedWhenPaused == 5 {
+		t.Errorf("Expected the workflow to be paused before all tasks had started")
+	}
+
+	wf.Resume()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Workflow did not finish after being resumed")
+	}
+	if getStarted() != 5 {
+		t.Errorf("Expected all 5 tasks to run after resuming, but %d did", getStarted())
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	initTestLogs()
+
+	newLoggedWorkflow := func(name string) (*Workflow, *bytes.Buffer, *bytes.Buffer) {
+		buf := &bytes.Buffer{}
+		warningBuf := &bytes.Buffer{}
+		wf := NewWorkflow(name, 4)
+		wf.SetLogger(log.New(buf, "", 0))
+		wf.SetWarningLogger(log.New(warningBuf, "", 0))
+		p := wf.NewProc(name+"_writer", "sleep 0.2; echo "+name+" > {o:out}")
+		p.SetOut("out", "/tmp/"+name+".txt")
+		// Make the task breach its SLA, for a warning to be logged
+		p.SetExpectedDuration(time.Millisecond)
+		return wf, buf, warningBuf
+	}
+	wfA, bufA, warningBufA := newLoggedWorkflow("logger_wf_a")
+	wfB, bufB, warningBufB := newLoggedWorkflow("logger_wf_b")
+
+	wg := sync.WaitGroup{}
+	for _, wf := range []*Workflow{wfA, wfB} {
+		wg.Add(1)
+		go func(wf *Workflow) {
+			defer wg.Done()
+			wf.Run()
+		}(wf)
+	}
+	wg.Wait()
+
+	for _, tc := range []struct {
+		buf        *bytes.Buffer
+		warningBuf *bytes.Buffer
+		expected   string
+		other      string
+	}{
+		{bufA, warningBufA, "logger_wf_a", "logger_wf_b"},
+		{bufB, warningBufB, "logger_wf_b", "logger_wf_a"},
+	} {
+		logged := tc.buf.String()
+		if !strings.Contains(logged, "Executing task "+tc.expected+"_writer") {
+			t.Errorf("Expected log of workflow %s to contain its task being executed, but it was:\n%s", tc.expected, logged)
+		}
+		if strings.Contains(logged, tc.other) {
+			t.Errorf("Expected log of workflow %s to not contain messages from %s, but it was:\n%s", tc.expected, tc.other, logged)
+		}
+		warnings := tc.warningBuf.String()
+		if !strings.Contains(warnings, tc.expected+"_writer") || !strings.Contains(warnings, "SLA breached") {
+			t.Errorf("Expected warning log of workflow %s to contain the SLA breach of its task, but it was:\n%s", tc.expected, warnings)
+		}
+		if strings.Contains(warnings, tc.other) {
+			t.Errorf("Expected warning log of workflow %s to not contain messages from %s, but it was:\n%s", tc.expected, tc.other, warnings)
+		}
+	}
+	cleanFiles("/tmp/logger_wf_a.txt", "/tmp/logger_wf_b.txt")
+}
+
+func TestPriority(t *testing.T) {
+	initTestLogs()
+
+	wf := NewWorkflow("test_wf", 1)
+	lowPrio := wf.NewProc("low_prio", "echo low > {o:out}")
+	lowPrio.SetOut("out", "/tmp/priority_low.txt")
+	highPrio := wf.NewProc("high_prio", "echo high > {o:out}")
+	highPrio.SetOut("out", "/tmp/priority_high.txt")
+	highPrio.Priority = 10
+
+	numWaiting := func() int {
+		wf.schedCond.L.Lock()
+		defer wf.schedCond.L.Unlock()
+		return len(wf.slotRequests)
+	}
+	waitForWaiting := func(n int) {
+		for numWaiting() < n {
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	// Take the only slot, so that the tasks have to wait for it
+	wf.IncConcurrentTasks(1)
+
+	mx := sync.Mutex{}
+	started := []string{}
+	wg := sync.WaitGroup{}
+	runTask := func(p *Process) {
+		defer wg.Done()
+		tsk := NewTask(wf, p, p.Name(), p.CommandPattern, map[string]*FileIP{}, p.PathFuncs, p.PortInfo, map[string]string{}, map[string]string{}, "", nil, 1)
+		wf.incConcurrentTasksWithPriority(1, tsk.priority())
+		mx.Lock()
+		started = append(started, p.Name())
+		mx.Unlock()
+		wf.DecConcurrentTasks(1)
+	}
+	// Submit the low priority task first
+	wg.Add(2)
+	go runTask(lowPrio)
+	waitForWaiting(1)
+	go runTask(highPrio)
+	waitForWaiting(2)
+
+	wf.DecConcurrentTasks(1)
+	wg.Wait()
+
+	expected := []string{"high_prio", "low_prio"}
+	if !reflect.DeepEqual(started, expected) {
+		t.Errorf("Expected tasks to start in order %v, but they started in order %v", expected, started)
+	}
+}
+
 // --------------------------------------------------------------------------------
 // CombinatoricsProcess helper process
 // --------------------------------------------------------------------------------
@@ -735,3 +1225,88 @@ func (p *BogusProcess) Name() string {
 func (p *BogusProcess) Ready() bool {
 	return true
 }
+
+// --------------------------------------------------------------------------------
+// In-flight test helper processes
+// --------------------------------------------------------------------------------
+
+// inFlightCounter keeps track of the number of IPs sent but not yet received
+type inFlightCounter struct {
+	mx          sync.Mutex
+	sent        int
+	received    int
+	maxInFlight int
+}
+
+// inFlightProducer sends n IPs as fast as it can
+type inFlightProducer struct {
+	BaseProcess
+	n       int
+	counter *inFlightCounter
+}
+
+func newInFlightProducer(wf *Workflow, name string, n int, counter *inFlightCounter) *inFlightProducer {
+	p := &inFlightProducer{
+		BaseProcess: NewBaseProcess(wf, name),
+		n:           n,
+		counter:     counter,
+	}
+	p.InitOutPort(p, "out")
+	wf.AddProc(p)
+	return p
+}
+
+func (p *inFlightProducer) Run() {
+	defer p.CloseAllOutPorts()
+	for i := 0; i < p.n; i++ {
+		p.OutPort("out").Send(NewFileIP(fmt.Sprintf("in_flight_%d.txt", i)))
+		p.counter.mx.Lock()
+		p.counter.sent++
+		if inFlight := p.counter.sent - p.counter.received; inFlight > p.counter.maxInFlight {
+			p.counter.maxInFlight = inFlight
+		}
+		p.counter.mx.Unlock()
+	}
+}
+
+// slowConsumer receives IPs slowly
+type slowConsumer struct {
+	BaseProcess
+	counter *inFlightCounter
+}
+
+func newSlowConsumer(wf *Workflow, name string, counter *inFlightCounter) *slowConsumer {
+	p := &slowConsumer{
+		BaseProcess: NewBaseProcess(wf, name),
+		counter:     counter,
+	}
+	p.InitInPort(p, "in")
+	wf.AddProc(p)
+	return p
+}
+
+func (p *slowConsumer) Run() {
+	for range p.InPort("in").Chan {
+		p.counter.mx.Lock()
+		p.counter.received++
+		p.counter.mx.Unlock()
+		time.Sleep(2 * time.Millisecond)
+	}
+}
+
+func TestSetPlaceholderDelimitersDashes(t *testing.T) {
+	initTestLogs()
+	wf := NewWorkflow("TestSetPlaceholderDelimitersDashesWf", 4)
+	wf.SetPlaceholderDelimiters("<-", "->")
+	p := wf.NewProc("echo", "echo <-p:text-> > <-o:out->")
+	p.InParam("text").FromStr("hi")
+	p.SetOut("out", "/tmp/placeholder_delimiters_<-p:text->.txt")
+	wf.Run()
+	defer cleanFiles("/tmp/placeholder_delimiters_hi.txt")
+
+	out, err := ioutil.ReadFile("/tmp/placeholder_delimiters_hi.txt")
+	Check(err)
+	if string(out) != "hi\n" {
+		t.Errorf("Expected output 'hi', got %q", out)
+	}
+}
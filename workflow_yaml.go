@@ -0,0 +1,546 @@
+package scipipe
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ----------------------------------------------------------------------------
+// Loading workflows from YAML
+// ----------------------------------------------------------------------------
+
+// LoadWorkflowYAML reads a workflow definition from the YAML file at path, and
+// returns a Workflow with processes and connections set up accordingly, ready
+// to be run. A workflow definition can look like this:
+//
+//	name: my_workflow
+//	max_concurrent_tasks: 4
+//	processes:
+//	  - name: foo
+//	    command: echo foo > {o:out}
+//	    paths:
+//	      - out: out
+//	        static: foo.txt
+//	  - name: foo2bar
+//	    command: sed 's/foo/bar/g' {i:in} > {o:out}
+//	    paths:
+//	      - out: out
+//	        in: in
+//	        extend: .bar.txt
+//	connections:
+//	  - from: foo.out
+//	    to: foo2bar.in
+//
+// Paths for out-ports can be configured with one of the following formatters:
+// "static" (a fixed path), "extend" (the path of the in-port given in "in",
+// extended with a suffix), "replace" (the path of the in-port given in "in",
+// with the string in "replace" replaced by the one in "with") or "pattern"
+// (a path pattern, as used with Process.SetOut()). Parameter values can be fed
+// to parameter ports with a "params" mapping from port names to lists of
+// values.
+//
+// Only the block style subset of YAML is supported, so values starting with a
+// curly brace (such as path patterns starting with a placeholder) need to be
+// quoted. Comments start with a " #" outside of quoted values, so commands
+// containing such a sequence need to be quoted too. Block scalars (multi-line
+// values starting with "|" or ">") are not supported, and result in an error.
+func LoadWorkflowYAML(path string) (*Workflow, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errWrap(err, "Could not read workflow file: "+path)
+	}
+	doc, err := parseYAML(data)
+	if err != nil {
+		return nil, errWrap(err, "Could not parse workflow file: "+path)
+	}
+	wfDef, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("Workflow file must contain a mapping at the top level: " + path)
+	}
+	return newWorkflowFromDef(wfDef)
+}
+
+func newWorkflowFromDef(wfDef map[string]interface{}) (*Workflow, error) {
+	name, err := yamlString(wfDef, "name", true)
+	if err != nil {
+		return nil, err
+	}
+	maxConcurrentTasks := 1
+	if _, ok := wfDef["max_concurrent_tasks"]; ok {
+		maxConcurrentTasks, err = yamlInt(wfDef, "max_concurrent_tasks")
+		if err != nil {
+			return nil, err
+		}
+	}
+	wf := NewWorkflow(name, maxConcurrentTasks)
+
+	procDefs, err := yamlMapList(wfDef, "processes")
+	if err != nil {
+		return nil, err
+	}
+	for _, procDef := range procDefs {
+		err := addProcFromDef(wf, procDef)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	connDefs, err := yamlMapList(wfDef, "connections")
+	if err != nil {
+		return nil, err
+	}
+	for _, connDef := range connDefs {
+		err := connectFromDef(wf, connDef)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return wf, nil
+}
+
+func addProcFromDef(wf *Workflow, procDef map[string]interface{}) error {
+	name, err := yamlString(procDef, "name", true)
+	if err != nil {
+		return err
+	}
+	if _, exists := wf.Procs()[name]; exists {
+		return fmt.Errorf("Process '%s' is defined more than once", name)
+	}
+	cmd, err := yamlString(procDef, "command", true)
+	if err != nil {
+		return errWrapf(err, "Invalid definition of process '%s'", name)
+	}
+	proc := wf.NewProc(name, cmd)
+	if _, ok := procDef["cores_per_task"]; ok {
+		proc.CoresPerTask, err = yamlInt(procDef, "cores_per_task")
+		if err != nil {
+			return errWrapf(err, "Invalid definition of process '%s'", name)
+		}
+	}
+
+	pathDefs, err := yamlMapList(procDef, "paths")
+	if err != nil {
+		return errWrapf(err, "Invalid definition of process '%s'", name)
+	}
+	for _, pathDef := range pathDefs {
+		err := setPathFromDef(proc, pathDef)
+		if err != nil {
+			return errWrapf(err, "Invalid path definition for process '%s'", name)
+		}
+	}
+
+	if paramsDef, ok := procDef["params"]; ok {
+		params, ok := paramsDef.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("Field 'params' of process '%s' must be a mapping", name)
+		}
+		for _, paramName := range sortedIfaceMapKeys(params) {
+			if _, ok := proc.InParamPorts()[paramName]; !ok {
+				return fmt.Errorf("Process '%s' has no parameter port named '%s'", name, paramName)
+			}
+			values, err := yamlStringList(params, paramName)
+			if err != nil {
+				return errWrapf(err, "Invalid parameter values for process '%s'", name)
+			}
+			proc.InParam(paramName).FromStr(values...)
+		}
+	}
+	return nil
+}
+
+func setPathFromDef(proc *Process, pathDef map[string]interface{}) error {
+	outPortName, err := yamlString(pathDef, "out", true)
+	if err != nil {
+		return err
+	}
+	formatters := []string{}
+	for _, formatter := range []string{"static", "extend", "replace", "pattern"} {
+		if _, ok := pathDef[formatter]; ok {
+			formatters = append(formatters, formatter)
+		}
+	}
+	if len(formatters) != 1 {
+		return fmt.Errorf("Exactly one of 'static', 'extend', 'replace' or 'pattern' must be set for out-port '%s'", outPortName)
+	}
+	inPortName := ""
+	if formatters[0] == "extend" || formatters[0] == "replace" {
+		inPortName, err = yamlString(pathDef, "in", true)
+		if err != nil {
+			return err
+		}
+		if _, ok := proc.InPorts()[inPortName]; !ok {
+			return fmt.Errorf("No in-port named '%s' to base the path of out-port '%s' on", inPortName, outPortName)
+		}
+	}
+
+	switch formatters[0] {
+	case "static":
+		path, _ := yamlString(pathDef, "static", true)
+		proc.SetOutFunc(outPortName, func(t *Task) string {
+			return path
+		})
+	case "extend":
+		ext, _ := yamlString(pathDef, "extend", true)
+		proc.SetOutFunc(outPortName, func(t *Task) string {
+			return t.InPath(inPortName) + ext
+		})
+	case "replace":
+		old, _ := yamlString(pathDef, "replace", true)
+		replacement, err := yamlString(pathDef, "with", false)
+		if err != nil {
+			return err
+		}
+		proc.SetOutFunc(outPortName, func(t *Task) string {
+			return strings.Replace(t.InPath(inPortName), old, replacement, -1)
+		})
+	case "pattern":
+		pattern, _ := yamlString(pathDef, "pattern", true)
+		proc.SetOut(outPortName, pattern)
+	}
+	return nil
+}
+
+func connectFromDef(wf *Workflow, connDef map[string]interface{}) error {
+	from, err := yamlString(connDef, "from", true)
+	if err != nil {
+		return err
+	}
+	to, err := yamlString(connDef, "to", true)
+	if err != nil {
+		return err
+	}
+	fromProc, fromPortName, err := splitProcPortRef(wf, from)
+	if err != nil {
+		return err
+	}
+	toProc, toPortName, err := splitProcPortRef(wf, to)
+	if err != nil {
+		return err
+	}
+	outPort, ok := fromProc.OutPorts()[fromPortName]
+	if !ok {
+		return fmt.Errorf("Process '%s' has no out-port named '%s'", fromProc.Name(), fromPortName)
+	}
+	inPort, ok := toProc.InPorts()[toPortName]
+	if !ok {
+		return fmt.Errorf("Process '%s' has no in-port named '%s'", toProc.Name(), toPortName)
+	}
+	inPort.From(outPort)
+	return nil
+}
+
+// splitProcPortRef splits a reference on the form "procname.portname" into
+// the process and the port name
+func splitProcPortRef(wf *Workflow, ref string) (WorkflowProcess, string, error) {
+	idx := strings.LastIndex(ref, ".")
+	if idx < 1 || idx == len(ref)-1 {
+		return nil, "", fmt.Errorf("Port reference '%s' is not on the form procname.portname", ref)
+	}
+	proc, ok := wf.Procs()[ref[:idx]]
+	if !ok {
+		return nil, "", fmt.Errorf("No process named '%s' in workflow '%s'", ref[:idx], wf.Name())
+	}
+	return proc, ref[idx+1:], nil
+}
+
+// ----------------------------------------------------------------------------
+// Helpers for accessing parsed YAML values
+// ----------------------------------------------------------------------------
+
+func yamlString(m map[string]interface{}, key string, required bool) (string, error) {
+	v, ok := m[key]
+	if !ok {
+		if required {
+			return "", fmt.Errorf("Missing required field '%s'", key)
+		}
+		return "", nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("Field '%s' must be a string value", key)
+	}
+	if required && s == "" {
+		return "", fmt.Errorf("Field '%s' can not be empty", key)
+	}
+	return s, nil
+}
+
+func yamlInt(m map[string]interface{}, key string) (int, error) {
+	s, err := yamlString(m, key, true)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("Field '%s' must be an integer, got: %s", key, s)
+	}
+	return i, nil
+}
+
+func yamlStringList(m map[string]interface{}, key string) ([]string, error) {
+	list, ok := m[key].([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Field '%s' must be a list", key)
+	}
+	strs := []string{}
+	for _, v := range list {
+		s, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("Items in field '%s' must be string values", key)
+		}
+		strs = append(strs, s)
+	}
+	return strs, nil
+}
+
+func yamlMapList(m map[string]interface{}, key string) ([]map[string]interface{}, error) {
+	v, ok := m[key]
+	if !ok || v == "" {
+		return []map[string]interface{}{}, nil
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("Field '%s' must be a list", key)
+	}
+	maps := []map[string]interface{}{}
+	for _, item := range list {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("Items in field '%s' must be mappings", key)
+		}
+		maps = append(maps, itemMap)
+	}
+	return maps, nil
+}
+
+func sortedIfaceMapKeys(kv map[string]interface{}) []string {
+	keys := []string{}
+	for k := range kv {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ----------------------------------------------------------------------------
+// A minimal YAML parser
+// ----------------------------------------------------------------------------
+
+// yamlLine is a non-empty, non-comment line in a YAML document
+type yamlLine struct {
+	indent int
+	text   string
+	num    int
+}
+
+// yamlParser parses the block style subset of YAML (nested mappings and lists,
+// with plain or quoted scalar values) into map[string]interface{},
+// []interface{} and string values.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+}
+
+var yamlKeyPtn = regexp.MustCompile(`^[A-Za-z0-9_\-\.]+$`)
+
+func parseYAML(data []byte) (interface{}, error) {
+	p := &yamlParser{}
+	rawLines := strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n")
+	for i, raw := range rawLines {
+		text := stripYAMLComment(strings.TrimSpace(raw))
+		if text == "" || text == "---" {
+			continue
+		}
+		indentStr := raw[:len(raw)-len(strings.TrimLeft(raw, " \t"))]
+		if strings.Contains(indentStr, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		p.lines = append(p.lines, yamlLine{indent: len(indentStr), text: text, num: i + 1})
+	}
+	if len(p.lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	doc, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected indentation", p.lines[p.pos].num)
+	}
+	return doc, nil
+}
+
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if isYAMLListItem(p.lines[p.pos].text) {
+		return p.parseList(indent)
+	}
+	return p.parseMap(indent)
+}
+
+func (p *yamlParser) parseMap(indent int) (map[string]interface{}, error) {
+	m := map[string]interface{}{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		key, val, ok := splitYAMLKeyValue(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected a 'key: value' pair, got: %s", line.num, line.text)
+		}
+		if _, exists := m[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key '%s'", line.num, key)
+		}
+		p.pos++
+		if isYAMLBlockScalar(val) {
+			return nil, fmt.Errorf("line %d: block scalars are not supported, use a quoted value instead: %s", line.num, line.text)
+		}
+		if val != "" {
+			m[key] = unquoteYAML(val)
+			continue
+		}
+		// A key without a value on the same line can have a nested block,
+		// which for lists may also be on the same indentation level as the key
+		if p.pos < len(p.lines) {
+			next := p.lines[p.pos]
+			if next.indent > indent || (next.indent == indent && isYAMLListItem(next.text)) {
+				child, err := p.parseBlock(next.indent)
+				if err != nil {
+					return nil, err
+				}
+				m[key] = child
+				continue
+			}
+		}
+		m[key] = ""
+	}
+	return m, nil
+}
+
+func (p *yamlParser) parseList(indent int) ([]interface{}, error) {
+	list := []interface{}{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent || (line.indent == indent && !isYAMLListItem(line.text)) {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		rest := strings.TrimLeft(line.text[1:], " ")
+		if rest == "" {
+			// The item is a nested block on the following lines
+			p.pos++
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				list = append(list, "")
+				continue
+			}
+			child, err := p.parseBlock(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, child)
+			continue
+		}
+		if _, _, ok := splitYAMLKeyValue(rest); ok {
+			// The item is a mapping, starting on the same line as the dash,
+			// so treat the rest of the line as the first line of that mapping
+			itemIndent := line.indent + len(line.text) - len(rest)
+			p.lines[p.pos] = yamlLine{indent: itemIndent, text: rest, num: line.num}
+			child, err := p.parseMap(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, child)
+			continue
+		}
+		if isYAMLBlockScalar(rest) {
+			return nil, fmt.Errorf("line %d: block scalars are not supported, use a quoted value instead: %s", line.num, line.text)
+		}
+		list = append(list, unquoteYAML(rest))
+		p.pos++
+	}
+	return list, nil
+}
+
+func isYAMLListItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// yamlBlockScalarPtn matches the header of a literal (|) or folded (>) block
+// scalar, with optional chomping and indentation indicators
+var yamlBlockScalarPtn = regexp.MustCompile(`^[|>][-+0-9]*$`)
+
+func isYAMLBlockScalar(val string) bool {
+	return yamlBlockScalarPtn.MatchString(val)
+}
+
+// stripYAMLComment removes any comment from a trimmed line, that is, anything
+// from a "#" at the start of the line, or preceded by whitespace, unless it is
+// inside a quoted value
+func stripYAMLComment(text string) string {
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++ // Skip the escaped character
+		case quote == '\'' && c == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++ // An escaped single quote
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+			// Inside a quoted value, so nothing starts a comment
+		case (c == '"' || c == '\'') && startsYAMLScalar(text[:i]):
+			quote = c
+		case c == '#' && (i == 0 || text[i-1] == ' ' || text[i-1] == '\t'):
+			return strings.TrimRight(text[:i], " \t")
+		}
+	}
+	return text
+}
+
+// startsYAMLScalar tells whether a scalar value can start right after prefix,
+// being the start of a line, that is, after a list item dash or a mapping key
+func startsYAMLScalar(prefix string) bool {
+	if prefix == "" {
+		return true
+	}
+	trimmed := strings.TrimRight(prefix, " ")
+	return len(trimmed) < len(prefix) && (strings.HasSuffix(trimmed, ":") || trimmed == "-")
+}
+
+// splitYAMLKeyValue splits a line on the form "key: value" or "key:" into its
+// key and value
+func splitYAMLKeyValue(text string) (key string, val string, ok bool) {
+	if strings.HasSuffix(text, ":") {
+		key = text[:len(text)-1]
+	} else if idx := strings.Index(text, ": "); idx > 0 {
+		key = text[:idx]
+		val = strings.TrimSpace(text[idx+2:])
+	}
+	if !yamlKeyPtn.MatchString(key) {
+		return "", "", false
+	}
+	return key, val, true
+}
+
+func unquoteYAML(val string) string {
+	if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+		if unquoted, err := strconv.Unquote(val); err == nil {
+			return unquoted
+		}
+	}
+	if len(val) >= 2 && val[0] == '\'' && val[len(val)-1] == '\'' {
+		return strings.Replace(val[1:len(val)-1], "''", "'", -1)
+	}
+	return val
+}
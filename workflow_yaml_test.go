@@ -0,0 +1,126 @@
+package scipipe
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testWorkflowYAML = `# A simple two-process workflow
+name: yaml_wf
+max_concurrent_tasks: 2
+processes:
+  - name: foo
+    command: echo {p:word} > {o:out}
+    params:
+      word:
+        - foo
+    paths:
+      - out: out
+        pattern: "/tmp/yaml_wf_{p:word}.txt"
+  - name: foo2bar
+    command: sed 's/foo/bar/g' {i:in} > {o:out}
+    paths:
+      - out: out
+        in: in
+        replace: .txt
+        with: .bar.txt
+connections:
+  - from: foo.out
+    to: foo2bar.in
+`
+
+func TestLoadWorkflowYAML(t *testing.T) {
+	initTestLogs()
+
+	wfFile := "/tmp/yaml_wf.yaml"
+	err := ioutil.WriteFile(wfFile, []byte(testWorkflowYAML), 0644)
+	Check(err)
+
+	wf, err := LoadWorkflowYAML(wfFile)
+	if err != nil {
+		t.Fatalf("Could not load workflow from YAML: %v", err)
+	}
+	if wf.Name() != "yaml_wf" {
+		t.Errorf("Workflow name was %s, expected yaml_wf", wf.Name())
+	}
+	if len(wf.Procs()) != 2 {
+		t.Errorf("Expected 2 processes in workflow, got %d", len(wf.Procs()))
+	}
+
+	wf.Run()
+
+	outFile := "/tmp/yaml_wf_foo.bar.txt"
+	out, err := ioutil.ReadFile(outFile)
+	if err != nil {
+		t.Fatalf("Could not read output file %s: %v", outFile, err)
+	}
+	if string(out) != "bar\n" {
+		t.Errorf("Output file contained '%s', expected 'bar\\n'", string(out))
+	}
+
+	cleanFiles(wfFile, "/tmp/yaml_wf_foo.txt", outFile)
+}
+
+func TestLoadWorkflowYAMLUnknownPort(t *testing.T) {
+	initTestLogs()
+
+	wfFile := "/tmp/yaml_wf_unknown_port.yaml"
+	err := ioutil.WriteFile(wfFile, []byte(`name: yaml_wf_unknown_port
+processes:
+  - name: foo
+    command: echo foo > {o:out}
+  - name: cat
+    command: cat {i:in} > {o:out}
+connections:
+  - from: foo.nonexisting
+    to: cat.in
+`), 0644)
+	Check(err)
+
+	_, err = LoadWorkflowYAML(wfFile)
+	if err == nil {
+		t.Errorf("Expected error when connecting non-existing out-port, but got none")
+	}
+
+	cleanFiles(wfFile)
+}
+
+func TestParseYAMLComments(t *testing.T) {
+	doc, err := parseYAML([]byte(`# A comment
+name: wf # A trailing comment
+command: "echo '#1' # not a comment" # A comment
+pattern: 'it''s # not a comment'
+url: http://host/#anchor
+list:
+  - a # A comment
+  - "b # c"
+`))
+	if err != nil {
+		t.Fatalf("Could not parse YAML: %v", err)
+	}
+	expected := map[string]interface{}{
+		"name":    "wf",
+		"command": "echo '#1' # not a comment",
+		"pattern": "it's # not a comment",
+		"url":     "http://host/#anchor",
+		"list":    []interface{}{"a", "b # c"},
+	}
+	if !reflect.DeepEqual(doc, expected) {
+		t.Errorf("Expected parsed YAML to be %v, got %v", expected, doc)
+	}
+}
+
+func TestParseYAMLBlockScalar(t *testing.T) {
+	for _, doc := range []string{
+		"command: |\n  echo foo\n",
+		"command: >-\n  echo foo\n",
+		"commands:\n  - |\n    echo foo\n",
+	} {
+		_, err := parseYAML([]byte(doc))
+		if err == nil || !strings.Contains(err.Error(), "block scalars are not supported") {
+			t.Errorf("Expected error about block scalars for %q, got: %v", doc, err)
+		}
+	}
+}